@@ -2,16 +2,34 @@ package main
 
 import (
 	"context"
+	"flag"
+	"net/http"
 	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 
+	"github.com/game-sales-analytics/users-service/internal/apikey"
+	"github.com/game-sales-analytics/users-service/internal/audit"
 	"github.com/game-sales-analytics/users-service/internal/auth"
+	"github.com/game-sales-analytics/users-service/internal/authcache"
+	"github.com/game-sales-analytics/users-service/internal/authz"
 	"github.com/game-sales-analytics/users-service/internal/config"
 	"github.com/game-sales-analytics/users-service/internal/db"
+	"github.com/game-sales-analytics/users-service/internal/db/migrate"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/export"
+	"github.com/game-sales-analytics/users-service/internal/geoip"
 	"github.com/game-sales-analytics/users-service/internal/grpcsrv"
+	"github.com/game-sales-analytics/users-service/internal/loginretention"
+	"github.com/game-sales-analytics/users-service/internal/mailer"
+	"github.com/game-sales-analytics/users-service/internal/outbox"
+	"github.com/game-sales-analytics/users-service/internal/purge"
+	"github.com/game-sales-analytics/users-service/internal/ratelimit"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
 	"github.com/game-sales-analytics/users-service/internal/validate"
+	"github.com/game-sales-analytics/users-service/internal/webhook"
 )
 
 func main() {
@@ -33,11 +51,36 @@ func main() {
 		cancel()
 	}()
 
+	storage := flag.String("storage", "", "storage backend, one of 'mongo', 'postgres' or 'memory'. overrides the STORAGE_BACKEND environment variable")
+	flag.Parse()
+
+	command, args := "", []string{}
+	if flag.NArg() > 0 {
+		command, args = flag.Arg(0), flag.Args()[1:]
+	}
+	if len(command) != 0 && command != "migrate" {
+		logger.WithField("command", command).Fatal("unknown command. " + migrateUsage)
+	}
+	switch *storage {
+	case "", config.StorageBackendMongo, config.StorageBackendPostgres, config.StorageBackendMemory:
+	default:
+		logger.WithField("storage", *storage).Fatal("unknown storage backend. expected one of 'mongo', 'postgres' or 'memory'")
+	}
+
 	logger.Trace("loading configuration")
 	conf, err := config.Load(logger.WithField("srv", "config"))
 	if nil != err {
 		logger.WithError(err).Fatal("unable to load configuration")
 	}
+	if len(*storage) != 0 {
+		conf.Storage.Backend = *storage
+	}
+	if conf.Storage.Backend == config.StorageBackendMemory && command == "migrate" {
+		logger.Fatal("migrate command requires a database storage backend")
+	}
+	if conf.Storage.Backend == config.StorageBackendPostgres && len(conf.Postgres.URL) == 0 {
+		logger.Fatal("'POSTGRES_URL' environment variable is required for 'postgres' storage backend")
+	}
 
 	logger.Trace("initializing Sentry sdk")
 	sentryHTTPSyncTransport := sentry.NewHTTPSyncTransport()
@@ -62,29 +105,225 @@ func main() {
 
 	span := sentry.StartSpan(ctx, "startup", sentry.TransactionName("service-startup"))
 
-	logger.Trace("initializing database connection")
-	child := span.StartChild("create-database-connection")
-	database, err := db.Connect(db.NewConnectContext(ctx, child), logger.WithField("srv", "db"), &conf.Database)
+	var store repository.Store
+	var migrator migrate.Migrator
+	var mongoDB *db.DB
+	var child *sentry.Span
+	switch conf.Storage.Backend {
+	case config.StorageBackendMemory:
+		logger.Warn("using in-memory storage. every record is lost when the service stops")
+		store = repository.NewMemoryStore()
+	case config.StorageBackendPostgres:
+		logger.Trace("initializing postgres connection")
+		child = span.StartChild("create-postgres-connection")
+		database, err := db.ConnectPostgres(db.NewConnectContext(ctx, child), logger.WithField("srv", "db"), &conf.Postgres)
+		if nil != err {
+			defer child.Finish()
+
+			logger.WithError(err).Fatal("unable to connect to postgres")
+		}
+		child.Finish()
+
+		defer func() {
+			logger.Debug("closing postgres connection pool before exit")
+			database.Disconnect()
+		}()
+		logger.Trace("connected to postgres")
+
+		migrator = database.Migrator(logger.WithField("srv", "migrate"), &conf.Migrations)
+		store = database.Store
+	default:
+		logger.Trace("initializing database connection")
+		child = span.StartChild("create-database-connection")
+		database, err := db.Connect(db.NewConnectContext(ctx, child), logger.WithField("srv", "db"), &conf.Database)
+		if nil != err {
+			defer child.Finish()
+
+			logger.WithError(err).Fatal("unable to connect to database")
+		}
+		child.Finish()
+
+		defer func() {
+			logger.Debug("closing database connection before exit")
+			if err := database.Disconnect(); nil != err {
+				logger.WithError(err).Debug("unable to close database connection")
+			}
+		}()
+		logger.Trace("connected to database")
+
+		migrator = database.Migrator(logger.WithField("srv", "migrate"), &conf.Migrations)
+		mongoDB = database
+		store = &database.Repo
+	}
+
+	if nil != migrator {
+		if command == "migrate" {
+			child = span.StartChild("run-migrate-command")
+			err := runMigrateCommand(migrate.NewContext(ctx, child), migrator, args)
+			child.Finish()
+			span.Finish()
+			if nil != err {
+				logger.WithError(err).Fatal("migrate command failed")
+			}
+			return
+		}
+
+		if conf.Migrations.RunOnStartup {
+			logger.Trace("applying pending database migrations")
+			child = span.StartChild("apply-database-migrations")
+			if _, err := migrator.Up(migrate.NewContext(ctx, child)); nil != err {
+				defer child.Finish()
+
+				logger.WithError(err).Fatal("unable to apply database migrations")
+			}
+			child.Finish()
+		}
+	}
+
+	if nil != mongoDB {
+		logger.Trace("ensuring database indexes")
+		child = span.StartChild("ensure-database-indexes")
+		if err := mongoDB.EnsureIndexes(db.NewConnectContext(ctx, child)); nil != err {
+			defer child.Finish()
+
+			logger.WithError(err).Fatal("unable to ensure database indexes")
+		}
+		child.Finish()
+
+		logger.Trace("ensuring user logins retention")
+		child = span.StartChild("ensure-user-logins-retention")
+		if err := mongoDB.EnsureUserLoginsRetention(db.NewConnectContext(ctx, child), conf.Logins.Period); nil != err {
+			defer child.Finish()
+
+			logger.WithError(err).Fatal("unable to ensure user logins retention")
+		}
+		child.Finish()
+	}
+
+	logger.Trace("initializing authentication info cache")
+	authCache, err := authcache.New(&conf.AuthCache)
 	if nil != err {
-		defer child.Finish()
+		logger.WithError(err).Fatal("unable to initialize authentication info cache")
+	}
+	if nil != authCache {
+		defer func() {
+			logger.Debug("closing authentication info cache before exit")
+			if err := authCache.Close(); nil != err {
+				logger.WithError(err).Debug("unable to close authentication info cache")
+			}
+		}()
+
+		// every service gets the cached store, so writes made through any
+		// of them invalidate the cached entries they affect
+		cachedStore := repository.NewCachedStore(store, authCache, logger.WithField("srv", "authcache"))
+		store = cachedStore
+
+		logger.WithField("driver", conf.AuthCache.Driver).WithField("ttl", conf.AuthCache.TTL).Trace("starting authentication info cache stats reporter")
+		go authcache.NewReporter(logger.WithField("srv", "authcache"), cachedStore, conf.AuthCache.StatsInterval).Run(ctx)
+	}
 
-		logger.WithError(err).Fatal("unable to connect to database")
+	logger.Trace("opening geoip databases")
+	locator, err := geoip.Open(logger.WithField("srv", "geoip"), &conf.Enrichment)
+	if nil != err {
+		logger.WithError(err).Fatal("unable to open geoip databases")
 	}
-	child.Finish()
 
 	defer func() {
-		logger.Debug("closing database connection before exit")
-		if err := database.Disconnect(); nil != err {
-			logger.WithError(err).Debug("unable to close database connection")
+		logger.Debug("closing geoip databases before exit")
+		if err := locator.Close(); nil != err {
+			logger.WithError(err).Debug("unable to close geoip databases")
 		}
 	}()
-	logger.Trace("connected to database")
 
-	validator := validate.New(logger.WithField("srv", "validate"), &database.Repo)
-	authSrv := auth.New(&database.Repo, logger.WithField("srv", "auth"), &conf.Jwt)
+	logger.Trace("loading email domain policy")
+	domainPolicy, err := validate.NewDomainPolicy(&conf.EmailPolicy)
+	if nil != err {
+		logger.WithError(err).Fatal("unable to load email domain policy")
+	}
+
+	validator := validate.New(logger.WithField("srv", "validate"), store, validate.NewChallengeVerifier(&conf.Challenge), domainPolicy, &conf.Users, &conf.APIKeys)
+	authSrv := auth.New(store, logger.WithField("srv", "auth"), &conf.Jwt, &conf.Enrichment, locator)
+
+	auditTrail := audit.New(store, logger.WithField("srv", "audit"))
+	mailSender := mailer.New(logger.WithField("srv", "mailer"), &conf.Mailer)
+	exporter := export.New(store, logger.WithField("srv", "export"))
+	keys := apikey.New(store, logger.WithField("srv", "apikey"), &conf.APIKeys)
+	webhooks := webhook.New(store, logger.WithField("srv", "webhook"))
+
+	if len(conf.Roles.BootstrapAdminUserID) != 0 {
+		logger.Trace("bootstrapping first administrator")
+		child = span.StartChild("bootstrap-admin")
+		granted, err := rbac.BootstrapAdmin(repository.NewDBOperationContext(ctx, child), store, conf.Roles.BootstrapAdminUserID)
+		if nil != err {
+			defer child.Finish()
+
+			logger.WithError(err).Fatal("unable to bootstrap first administrator")
+		}
+		if granted {
+			auditTrail.Record(audit.NewContext(ctx, child), audit.Event{
+				Type:      audit.EventTypeAdminBootstrapped,
+				Outcome:   audit.OutcomeSuccess,
+				SubjectID: conf.Roles.BootstrapAdminUserID,
+			})
+			logger.WithField("user_id", conf.Roles.BootstrapAdminUserID).Info("granted admin role to bootstrap administrator")
+		}
+		child.Finish()
+	}
 
 	span.Finish()
 
-	server := grpcsrv.New(logger.WithField("srv", "grpc"), &database.Repo, validator, authSrv)
+	logger.Trace("starting deleted accounts purger")
+	go purge.New(logger.WithField("srv", "purge"), store, auditTrail, &conf.Deletion).Run(ctx)
+
+	// MongoDB expires logins through a TTL index, other stores are pruned by
+	// the enforcer
+	pruneExpiredLogins := nil == mongoDB
+	if conf.Logins.Aggregate || (pruneExpiredLogins && conf.Logins.Period != 0) {
+		logger.Trace("starting user logins retention enforcer")
+		go loginretention.New(logger.WithField("srv", "loginretention"), store, &conf.Logins, pruneExpiredLogins).Run(ctx)
+	}
+
+	logger.Trace("initializing outbox event publisher")
+	publisher, err := outbox.NewPublisher(logger.WithField("srv", "outbox"), &conf.Outbox)
+	if nil != err {
+		logger.WithError(err).Fatal("unable to initialize outbox event publisher")
+	}
+
+	defer func() {
+		logger.Debug("closing outbox event publisher before exit")
+		if err := publisher.Close(); nil != err {
+			logger.WithError(err).Debug("unable to close outbox event publisher")
+		}
+	}()
+
+	logger.Trace("starting outbox relay")
+	enqueuer := webhook.NewEnqueuer(logger.WithField("srv", "webhook"), store)
+	go outbox.New(logger.WithField("srv", "outbox"), store, outbox.NewMultiPublisher(publisher, enqueuer), &conf.Outbox).Run(ctx)
+
+	logger.Trace("starting webhook dispatcher")
+	webhookClient := &http.Client{
+		// a redirect response counts as a failed delivery instead of sending
+		// the signed body to wherever it points
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	go webhook.NewDispatcher(logger.WithField("srv", "webhook"), store, webhookClient, &conf.Webhooks).Run(ctx)
+
+	var interceptors []grpc.UnaryServerInterceptor
+	if conf.RateLimit.Enabled {
+		logger.Trace("enabling rate limiting interceptor")
+		interceptors = append(interceptors, ratelimit.UnaryServerInterceptor(logger.WithField("srv", "ratelimit"), ratelimit.NewMemoryStore(), &conf.RateLimit))
+	}
+
+	var streamInterceptors []grpc.StreamServerInterceptor
+	if conf.Authz.Enabled {
+		logger.Trace("enabling authorization interceptors")
+		policy := authz.DefaultPolicy()
+		interceptors = append(interceptors, authz.UnaryServerInterceptor(logger.WithField("srv", "authz"), authSrv, keys, policy))
+		streamInterceptors = append(streamInterceptors, authz.StreamServerInterceptor(logger.WithField("srv", "authz"), authSrv, keys, policy))
+	}
+
+	server := grpcsrv.New(logger.WithField("srv", "grpc"), store, validator, authSrv, auditTrail, exporter, keys, webhooks, mailSender, &conf.EmailChange, &conf.Deletion, &conf.Authz, interceptors, streamInterceptors)
 	logger.WithError(server.Listen(conf.Server.Host, conf.Server.Port)).Fatal("unable to start GRPC server")
 }
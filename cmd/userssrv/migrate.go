@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/migrate"
+)
+
+const migrateUsage = "usage: userssrv migrate up|down [steps]|status"
+
+// runMigrateCommand handles `userssrv migrate up|down [steps]|status`.
+func runMigrateCommand(ctx migrate.Context, migrator migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied %d: %s\n", migration.Version, migration.Description)
+		}
+		if nil != err {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return nil
+	case "down":
+		steps := uint64(1)
+		if len(args) > 2 {
+			return errors.New(migrateUsage)
+		}
+		if len(args) == 2 {
+			value, err := strconv.ParseUint(args[1], 10, 32)
+			if nil != err || value == 0 {
+				return fmt.Errorf("invalid step count '%s': must be a positive integer", args[1])
+			}
+			steps = value
+		}
+
+		reverted, err := migrator.Down(ctx, uint(steps))
+		for _, migration := range reverted {
+			fmt.Printf("reverted %d: %s\n", migration.Version, migration.Description)
+		}
+		if nil != err {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no applied migrations")
+		}
+		return nil
+	case "status":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+
+		statuses, err := migrator.Status(ctx)
+		if nil != err {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tDESCRIPTION")
+		for _, status := range statuses {
+			state, appliedAt := "pending", "-"
+			if status.Applied {
+				state = "applied"
+				appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			if status.Unknown {
+				state = "unknown"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, state, appliedAt, status.Description)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
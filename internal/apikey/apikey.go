@@ -0,0 +1,28 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type CreateRequest struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+	CreatedBy string
+}
+
+type CreatedKey struct {
+	Key repository.APIKey
+	// Secret is the full key handed to the caller. Only its hash is stored,
+	// so it cannot be shown again.
+	Secret string
+}
+
+type Keys interface {
+	Create(ctx Context, req CreateRequest) (*CreatedKey, error)
+	List(ctx Context, includeRevoked bool) ([]repository.APIKey, error)
+	Revoke(ctx Context, keyID string) (*repository.APIKey, error)
+	Verify(ctx Context, secret string) (*repository.APIKey, error)
+}
@@ -0,0 +1,11 @@
+package apikey
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidKey   = errors.New("api key is not valid")
+	ErrKeyNotExists = errors.New("api key does not exist")
+	ErrInternal     = errors.New("internal error occurred")
+)
@@ -0,0 +1,170 @@
+package apikey
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/id"
+)
+
+// lastUsedPrecision bounds how often the last use time of a key is written.
+const lastUsedPrecision = time.Minute
+
+func (k keys) Create(ctx Context, req CreateRequest) (*CreatedKey, error) {
+	span := ctx.span.StartChild("generate-api-key")
+	span.Status = sentry.SpanStatusOK
+	keyID, err := id.GenerateAPIKeyID()
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := k.logger.WithError(err).WithField("err_code", "E_GENERATE_API_KEY_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed generating api key id")
+		return nil, ErrInternal
+	}
+	prefix, secret, err := generateSecret()
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := k.logger.WithError(err).WithField("err_code", "E_GENERATE_API_KEY_SECRET")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed generating api key secret")
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	expiresAt := req.ExpiresAt
+	createdAt := time.Now()
+	if nil == expiresAt {
+		defaultExpiresAt := createdAt.Add(k.cfg.MaxTTL)
+		expiresAt = &defaultExpiresAt
+	}
+	key := repository.APIKey{
+		ID:        keyID,
+		Prefix:    prefix,
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		CreatedBy: req.CreatedBy,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}
+
+	span = ctx.span.StartChild("save-api-key")
+	span.Status = sentry.SpanStatusOK
+	if err := k.repo.SaveNewAPIKey(repository.NewDBOperationContext(ctx, span), repository.NewAPIKeyToSave{APIKey: key, SecretHash: k.hashSecret(secret)}); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	return &CreatedKey{
+		Key:    key,
+		Secret: secret,
+	}, nil
+}
+
+func (k keys) List(ctx Context, includeRevoked bool) ([]repository.APIKey, error) {
+	span := ctx.span.StartChild("list-api-keys")
+	span.Status = sentry.SpanStatusOK
+	list, err := k.repo.ListAPIKeys(repository.NewDBOperationContext(ctx, span), includeRevoked)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	return list, nil
+}
+
+func (k keys) Revoke(ctx Context, keyID string) (*repository.APIKey, error) {
+	span := ctx.span.StartChild("revoke-api-key")
+	span.Status = sentry.SpanStatusOK
+	key, err := k.repo.RevokeAPIKey(repository.NewDBOperationContext(ctx, span), keyID, time.Now())
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, repository.ErrAPIKeyNotExists) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrKeyNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	return key, nil
+}
+
+// Verify resolves the key behind the given secret. Unknown, mismatching,
+// revoked and expired keys are all reported as ErrInvalidKey.
+func (k keys) Verify(ctx Context, secret string) (*repository.APIKey, error) {
+	prefix, ok := parseSecret(secret)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	span := ctx.span.StartChild("get-api-key")
+	span.Status = sentry.SpanStatusOK
+	info, err := k.repo.GetAPIKeyAuthenticationInfo(repository.NewDBOperationContext(ctx, span), prefix)
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, repository.ErrAPIKeyNotExists) {
+			span.Status = sentry.SpanStatusUnauthenticated
+			return nil, ErrInvalidKey
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	now := time.Now()
+	span = ctx.span.StartChild("check-api-key")
+	span.Status = sentry.SpanStatusOK
+	if subtle.ConstantTimeCompare([]byte(k.hashSecret(secret)), []byte(info.SecretHash)) != 1 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusUnauthenticated
+		k.logger.WithField("api_key_id", info.ID).Debug("api key secret does not match")
+		return nil, ErrInvalidKey
+	}
+	if nil != info.RevokedAt {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusUnauthenticated
+		k.logger.WithField("api_key_id", info.ID).Debug("rejecting revoked api key")
+		return nil, ErrInvalidKey
+	}
+	if nil != info.ExpiresAt && !now.Before(*info.ExpiresAt) {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusUnauthenticated
+		k.logger.WithField("api_key_id", info.ID).Debug("rejecting expired api key")
+		return nil, ErrInvalidKey
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("touch-api-key")
+	span.Status = sentry.SpanStatusOK
+	if err := k.repo.TouchAPIKey(repository.NewDBOperationContext(ctx, span), info.ID, now, now.Add(-lastUsedPrecision)); nil != err {
+		// A missed last use time is not worth failing the request for.
+		span.Status = sentry.SpanStatusInternalError
+	}
+	span.Finish()
+
+	key := info.APIKey
+	return &key, nil
+}
@@ -0,0 +1,22 @@
+package apikey
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type keys struct {
+	repo   repository.Store
+	logger *logrus.Entry
+	cfg    *config.APIKeysConfig
+}
+
+func New(repo repository.Store, logger *logrus.Entry, cfg *config.APIKeysConfig) Keys {
+	return keys{
+		repo,
+		logger,
+		cfg,
+	}
+}
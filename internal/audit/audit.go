@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"time"
+)
+
+type EventType = string
+
+const (
+	EventTypeUserRegistered              EventType = "user.registered"
+	EventTypeUserLogin                   EventType = "user.login"
+	EventTypeUserProfileUpdated          EventType = "user.profile_updated"
+	EventTypeUserEmailChangeRequested    EventType = "user.email_change_requested"
+	EventTypeUserEmailChanged            EventType = "user.email_changed"
+	EventTypeUserDeletionRequested       EventType = "user.deletion_requested"
+	EventTypeUserDeletionCancelled       EventType = "user.deletion_cancelled"
+	EventTypeUserPurged                  EventType = "user.purged"
+	EventTypeUserDataExported            EventType = "user.data_exported"
+	EventTypeTokenVerificationFailed     EventType = "token.verification_failed"
+	EventTypeAdminAuditEventsQueried     EventType = "admin.audit_events.queried"
+	EventTypeAdminUsersListed            EventType = "admin.users.listed"
+	EventTypeAdminUserSuspended          EventType = "admin.user.suspended"
+	EventTypeAdminUserReactivated        EventType = "admin.user.reactivated"
+	EventTypeAdminUserDataExported       EventType = "admin.user.data_exported"
+	EventTypeAdminRoleGranted            EventType = "admin.role.granted"
+	EventTypeAdminRoleRevoked            EventType = "admin.role.revoked"
+	EventTypeAdminBootstrapped           EventType = "admin.bootstrapped"
+	EventTypeAdminAPIKeyCreated          EventType = "admin.api_key.created"
+	EventTypeAdminAPIKeyRevoked          EventType = "admin.api_key.revoked"
+	EventTypeAdminWebhookCreated         EventType = "admin.webhook.created"
+	EventTypeAdminWebhookDeleted         EventType = "admin.webhook.deleted"
+	EventTypeAdminWebhookDeliveryRetried EventType = "admin.webhook_delivery.retried"
+)
+
+type Outcome = string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+type Event struct {
+	Type      EventType
+	Outcome   Outcome
+	ActorID   string
+	SubjectID string
+	IPAddress string
+	Details   map[string]string
+}
+
+type RecordedEvent struct {
+	Event
+	ID         string
+	TraceID    string
+	OccurredAt time.Time
+}
+
+type Query struct {
+	Types     []EventType
+	Outcome   Outcome
+	ActorID   string
+	SubjectID string
+	From      *time.Time
+	To        *time.Time
+	PageSize  uint32
+	PageToken string
+}
+
+type QueryResult struct {
+	Events        []RecordedEvent
+	NextPageToken string
+}
+
+type Trail interface {
+	Record(ctx Context, event Event)
+	Query(ctx Context, query Query) (*QueryResult, error)
+}
@@ -0,0 +1,10 @@
+package audit
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrInternal         = errors.New("internal error occurred")
+)
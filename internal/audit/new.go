@@ -0,0 +1,19 @@
+package audit
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type trail struct {
+	repo   repository.Store
+	logger *logrus.Entry
+}
+
+func New(repo repository.Store, logger *logrus.Entry) Trail {
+	return trail{
+		repo,
+		logger,
+	}
+}
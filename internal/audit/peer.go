@@ -0,0 +1,22 @@
+package audit
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc/peer"
+)
+
+func PeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || nil == p.Addr {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if nil != err {
+		return p.Addr.String()
+	}
+
+	return host
+}
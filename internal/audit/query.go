@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/pagetoken"
+)
+
+func (t trail) Query(ctx Context, query Query) (*QueryResult, error) {
+	span := ctx.span.StartChild("decode-page-token")
+	span.Status = sentry.SpanStatusOK
+	after, err := decodePageToken(query.PageToken)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return nil, ErrInvalidPageToken
+	}
+	span.Finish()
+
+	filter := repository.AuditEventsFilter{
+		Types:     query.Types,
+		Outcome:   query.Outcome,
+		ActorID:   query.ActorID,
+		SubjectID: query.SubjectID,
+		From:      query.From,
+		To:        query.To,
+		After:     after,
+		Limit:     int64(query.PageSize) + 1,
+	}
+
+	span = ctx.span.StartChild("query-audit-events")
+	span.Status = sentry.SpanStatusOK
+	events, err := t.repo.QueryAuditEvents(repository.NewDBOperationContext(ctx, span), filter)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := t.logger.WithError(err).WithField("err_code", "E_QUERY_AUDIT_EVENTS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed querying audit events")
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	out := QueryResult{
+		Events: make([]RecordedEvent, 0, len(events)),
+	}
+	if len(events) > int(query.PageSize) {
+		events = events[:query.PageSize]
+		last := events[len(events)-1]
+		out.NextPageToken = pagetoken.Encode(last.OccurredAt, last.ID)
+	}
+	for _, event := range events {
+		out.Events = append(out.Events, RecordedEvent{
+			Event: Event{
+				Type:      event.Type,
+				Outcome:   event.Outcome,
+				ActorID:   event.ActorID,
+				SubjectID: event.SubjectID,
+				IPAddress: event.IPAddress,
+				Details:   event.Details,
+			},
+			ID:         event.ID,
+			TraceID:    event.TraceID,
+			OccurredAt: event.OccurredAt,
+		})
+	}
+
+	return &out, nil
+}
+
+func decodePageToken(token string) (*repository.AuditEventsCursor, error) {
+	if len(token) == 0 {
+		return nil, nil
+	}
+
+	occurredAt, id, err := pagetoken.Decode(token)
+	if nil != err {
+		return nil, err
+	}
+
+	return &repository.AuditEventsCursor{
+		OccurredAt: occurredAt,
+		ID:         id,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/id"
+)
+
+func (t trail) Record(ctx Context, event Event) {
+	span := ctx.span.StartChild("generate-audit-event-id")
+	span.Status = sentry.SpanStatusOK
+	eventID, err := id.GenerateAuditEventID()
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := t.logger.WithError(err).WithField("err_code", "E_GENERATE_AUDIT_EVENT_ID").WithField("event_type", event.Type)
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed generating audit event id. audit event is lost")
+		return
+	}
+	span.Finish()
+
+	if len(event.IPAddress) == 0 {
+		event.IPAddress = PeerIP(ctx)
+	}
+
+	recorded := repository.AuditEvent{
+		ID:         eventID,
+		Type:       event.Type,
+		Outcome:    event.Outcome,
+		ActorID:    event.ActorID,
+		SubjectID:  event.SubjectID,
+		IPAddress:  event.IPAddress,
+		TraceID:    ctx.span.TraceID.String(),
+		OccurredAt: time.Now(),
+		Details:    event.Details,
+	}
+
+	span = ctx.span.StartChild("save-audit-event")
+	span.Status = sentry.SpanStatusOK
+	if err := t.repo.SaveAuditEvent(repository.NewDBOperationContext(ctx, span), recorded); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := t.logger.WithError(err).WithField("err_code", "E_SAVE_AUDIT_EVENT").WithField("event_type", event.Type)
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed saving audit event. audit event is lost")
+		return
+	}
+	span.Finish()
+}
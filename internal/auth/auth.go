@@ -7,12 +7,16 @@ import (
 type Auth interface {
 	VerifyToken(ctx Context, token string) (*TokenVerificationResult, error)
 	LoginWithEmail(ctx Context, creds LoginWithEmailCreds) (*LoginResult, error)
+	VerifyPassword(ctx Context, userID, password string) error
 }
 
 type TokenVerificationResultUser struct {
-	ID        string
-	FirstName string
-	LastName  string
+	ID          string
+	FirstName   string
+	LastName    string
+	Version     uint64
+	Roles       []string
+	Permissions []string
 }
 
 type TokenVerificationResult struct {
@@ -27,5 +31,7 @@ type LoginResultToken struct {
 }
 
 type LoginResult struct {
-	Token LoginResultToken
+	UserID            string
+	Token             LoginResultToken
+	DeletionCancelled bool
 }
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+// cancelAccountDeletion brings an account pending deletion back to active.
+// Tokens issued before the deletion request stay revoked.
+func (a authsrv) cancelAccountDeletion(ctx Context, userID string) error {
+	change := repository.UserStatusChange{
+		Status:    repository.UserStatusActive,
+		ChangedAt: time.Now(),
+	}
+	allowedFrom := []repository.UserStatus{
+		repository.UserStatusPendingDeletion,
+	}
+
+	span := ctx.span.StartChild("change-user-status")
+	span.Status = sentry.SpanStatusOK
+	if _, err := a.repo.ChangeUserStatus(repository.NewDBOperationContext(ctx, span), userID, allowedFrom, change); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) || errors.Is(err, repository.ErrUserStatusTransitionNotAllowed) {
+			span.Status = sentry.SpanStatusUnauthenticated
+			return ErrUnauthenticated
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := a.logger.WithError(err).WithField("err_code", "E_CANCEL_ACCOUNT_DELETION")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed cancelling user account deletion")
+		return ErrInternal
+	}
+	span.Finish()
+
+	return nil
+}
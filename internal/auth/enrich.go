@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/useragent"
+)
+
+func (a authsrv) enrichUserLogin(ctx Context, loginRecord *repository.NewUserLoginToSave) {
+	if a.locator.Enabled() {
+		span := ctx.span.StartChild("locate-user-ip-address")
+		span.Status = sentry.SpanStatusOK
+		location, err := a.locator.Locate(loginRecord.UserIPAddress)
+		if nil != err {
+			span.Status = sentry.SpanStatusInternalError
+			log := a.logger.WithError(err).WithField("err_code", "E_LOCATE_USER_IP_ADDRESS")
+			apm.SetSpanTagsFromLogEntry(span, log)
+			log.Warn("failed locating user ip address. skipping location enrichment")
+		} else {
+			loginRecord.Location = &repository.UserLoginLocation{
+				CountryCode:     location.CountryCode,
+				CountryName:     location.CountryName,
+				City:            location.City,
+				ASN:             location.ASN,
+				ASNOrganization: location.ASNOrganization,
+			}
+		}
+		span.Finish()
+	}
+
+	if a.enrichmentCfg.ParseUserAgent {
+		span := ctx.span.StartChild("parse-user-device-user-agent")
+		span.Status = sentry.SpanStatusOK
+		device := useragent.Parse(loginRecord.UserDeviceUserAgent)
+		loginRecord.Device = &repository.UserLoginDevice{
+			Browser:        device.Browser,
+			BrowserVersion: device.BrowserVersion,
+			OS:             device.OS,
+			Class:          device.Class,
+		}
+		span.Finish()
+	}
+}
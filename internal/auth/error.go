@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"time"
 )
 
 var (
@@ -9,4 +10,18 @@ var (
 	ErrUnauthenticated  = errors.New("invalid credentials provided")
 	ErrInternal         = errors.New("internal error occurred")
 	ErrUserNotExists    = errors.New("no user with associated token exists")
+	ErrUserSuspended    = errors.New("user account is suspended")
 )
+
+type SuspendedError struct {
+	Reason    string
+	ExpiresAt *time.Time
+}
+
+func (e *SuspendedError) Error() string {
+	return ErrUserSuspended.Error()
+}
+
+func (e *SuspendedError) Unwrap() error {
+	return ErrUserSuspended
+}
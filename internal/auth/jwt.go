@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -10,6 +11,12 @@ import (
 	"github.com/lestrrat-go/jwx/jwt"
 
 	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+)
+
+const (
+	rolesClaim = "roles"
+	scopeClaim = "scope"
 )
 
 type GeneratedToken struct {
@@ -19,7 +26,7 @@ type GeneratedToken struct {
 	ExpirationDateTime time.Time
 }
 
-func (a *authsrv) generateToken(ctx Context, userID, secret string) (*GeneratedToken, error) {
+func (a *authsrv) generateToken(ctx Context, userID string, roles []string, secret string) (*GeneratedToken, error) {
 	child := ctx.span.StartChild("generate-auth-token-id")
 	tokenID, err := uuid.NewV4()
 	if nil != err {
@@ -120,6 +127,30 @@ func (a *authsrv) generateToken(ctx Context, userID, secret string) (*GeneratedT
 	}
 	child.Finish()
 
+	child = ctx.span.StartChild("set-roles-key")
+	if err := token.Set(rolesClaim, roles); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := a.logger.WithError(err).WithField("err_code", "E_SET_JWT_TOKEN_ROLES_CLAIM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed setting jwt token roles claim")
+		return nil, errors.New("unable to set auth token roles key")
+	}
+	child.Finish()
+
+	child = ctx.span.StartChild("set-scope-key")
+	if err := token.Set(scopeClaim, strings.Join(rbac.Permissions(roles), " ")); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := a.logger.WithError(err).WithField("err_code", "E_SET_JWT_TOKEN_SCOPE_CLAIM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed setting jwt token scope claim")
+		return nil, errors.New("unable to set auth token scope key")
+	}
+	child.Finish()
+
 	child = ctx.span.StartChild("sign-auth-token")
 	opts := []jwt.SignOption{}
 	serialized, err := jwt.Sign(token, jwa.HS512, []byte(secret), opts...)
@@ -143,7 +174,8 @@ func (a *authsrv) generateToken(ctx Context, userID, secret string) (*GeneratedT
 }
 
 type tokenDecodeResult struct {
-	userID string
+	userID   string
+	issuedAt time.Time
 }
 
 func verifyToken(ctx Context, token, secret string) (*tokenDecodeResult, error) {
@@ -165,7 +197,8 @@ func verifyToken(ctx Context, token, secret string) (*tokenDecodeResult, error)
 	span.Finish()
 
 	out := tokenDecodeResult{
-		userID: parsedToken.Subject(),
+		userID:   parsedToken.Subject(),
+		issuedAt: parsedToken.IssuedAt(),
 	}
 
 	return &out, nil
@@ -67,9 +67,39 @@ func (a authsrv) LoginWithEmail(ctx Context, creds LoginWithEmailCreds) (*LoginR
 	}
 	span.Finish()
 
+	deletionCancelled := false
+	if user.Status.Effective(time.Now()) == repository.UserStatusPendingDeletion {
+		span = ctx.span.StartChild("cancel-account-deletion")
+		span.Status = sentry.SpanStatusOK
+		if err := a.cancelAccountDeletion(NewContext(ctx, span), user.ID); nil != err {
+			defer span.Finish()
+
+			return nil, err
+		}
+		span.Finish()
+
+		deletionCancelled = true
+		user.Status = repository.UserStatusInfo{Status: repository.UserStatusActive}
+	}
+
+	span = ctx.span.StartChild("check-user-status")
+	span.Status = sentry.SpanStatusOK
+	if err := checkUserStatus(user.Status, time.Now()); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusPermissionDenied
+		if errors.Is(err, ErrUserNotExists) {
+			span.Status = sentry.SpanStatusUnauthenticated
+			return nil, ErrUnauthenticated
+		}
+
+		return nil, err
+	}
+	span.Finish()
+
 	span = ctx.span.StartChild("generate-auth-token")
 	span.Status = sentry.SpanStatusOK
-	token, err := a.generateToken(NewContext(ctx, span), user.ID, a.cfg.Secret)
+	token, err := a.generateToken(NewContext(ctx, span), user.ID, user.Roles, a.cfg.Secret)
 	if nil != err {
 		defer span.Finish()
 
@@ -103,6 +133,11 @@ func (a authsrv) LoginWithEmail(ctx Context, creds LoginWithEmailCreds) (*LoginR
 		UserDeviceUserAgent: creds.UserDeviceUserAgent,
 	}
 
+	span = ctx.span.StartChild("enrich-user-login-attempt")
+	span.Status = sentry.SpanStatusOK
+	a.enrichUserLogin(NewContext(ctx, span), &loginRecord)
+	span.Finish()
+
 	span = ctx.span.StartChild("save-user-login-attempt")
 	span.Status = sentry.SpanStatusOK
 	if err := a.repo.SaveNewUserLogin(repository.NewDBOperationContext(ctx, span), loginRecord); nil != err {
@@ -117,11 +152,13 @@ func (a authsrv) LoginWithEmail(ctx Context, creds LoginWithEmailCreds) (*LoginR
 	span.Finish()
 
 	return &LoginResult{
+		UserID: user.ID,
 		Token: LoginResultToken{
 			ID:                 token.ID,
 			Value:              token.Value,
 			NotBeforeDateTime:  token.NotBeforeDateTime,
 			ExpirationDateTime: token.ExpirationDateTime,
 		},
+		DeletionCancelled: deletionCancelled,
 	}, nil
 }
@@ -5,22 +5,29 @@ import (
 
 	"github.com/game-sales-analytics/users-service/internal/config"
 	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/geoip"
 )
 
 type authsrv struct {
-	repo   *repository.Repo
-	logger *logrus.Entry
-	cfg    *config.JwtConfig
+	repo          repository.Store
+	logger        *logrus.Entry
+	cfg           *config.JwtConfig
+	enrichmentCfg *config.EnrichmentConfig
+	locator       geoip.Locator
 }
 
 func New(
-	repo *repository.Repo,
+	repo repository.Store,
 	logger *logrus.Entry,
 	cfg *config.JwtConfig,
+	enrichmentCfg *config.EnrichmentConfig,
+	locator geoip.Locator,
 ) Auth {
 	return authsrv{
 		repo,
 		logger,
 		cfg,
+		enrichmentCfg,
+		locator,
 	}
 }
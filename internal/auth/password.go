@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/passhash"
+)
+
+func (a authsrv) VerifyPassword(ctx Context, userID, password string) error {
+	span := ctx.span.StartChild("get-user-login-info")
+	span.Status = sentry.SpanStatusOK
+	user, err := a.repo.GetUserLoginInfoByID(repository.NewDBOperationContext(ctx, span), userID)
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) {
+			span.Status = sentry.SpanStatusUnauthenticated
+			return ErrUnauthenticated
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := a.logger.WithError(err).WithField("err_code", "E_RETRIEVE_USER_LOGIN_INFO")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving user login information")
+		return ErrInternal
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("verify-user-password")
+	span.Status = sentry.SpanStatusOK
+	matched, err := passhash.Verify(password, user.Password)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := a.logger.WithError(err).WithField("err_code", "E_VERIFY_PASSWORD")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed verifying user password")
+		return ErrInternal
+	}
+	if !matched {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusUnauthenticated
+		return ErrUnauthenticated
+	}
+	span.Finish()
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+// checkUserStatus rejects users that are not allowed to authenticate. Users
+// pending verification are let through until a verification flow exists.
+func checkUserStatus(info repository.UserStatusInfo, now time.Time) error {
+	switch info.Effective(now) {
+	case repository.UserStatusSuspended:
+		return &SuspendedError{
+			Reason:    info.Reason,
+			ExpiresAt: info.ExpiresAt,
+		}
+	case repository.UserStatusPendingDeletion, repository.UserStatusDeleted:
+		return ErrUserNotExists
+	default:
+		return nil
+	}
+}
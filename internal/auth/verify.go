@@ -2,11 +2,13 @@ package auth
 
 import (
 	"errors"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 
 	"github.com/game-sales-analytics/users-service/internal/apm"
 	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
 )
 
 func (a authsrv) VerifyToken(ctx Context, token string) (*TokenVerificationResult, error) {
@@ -40,11 +42,41 @@ func (a authsrv) VerifyToken(ctx Context, token string) (*TokenVerificationResul
 	}
 	span.Finish()
 
+	span = ctx.span.StartChild("check-token-revocation")
+	span.Status = sentry.SpanStatusOK
+	// iat has whole second precision while the revocation time keeps
+	// milliseconds, so a token issued later in the same second would look
+	// older than the revocation without truncating it.
+	if nil != userAuthInfo.TokensNotBefore && decodeRes.issuedAt.Before(userAuthInfo.TokensNotBefore.Truncate(time.Second)) {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusUnauthenticated
+		return nil, ErrTokenNotVerified
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("check-user-status")
+	span.Status = sentry.SpanStatusOK
+	if err := checkUserStatus(userAuthInfo.Status, time.Now()); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusPermissionDenied
+		if errors.Is(err, ErrUserNotExists) {
+			span.Status = sentry.SpanStatusNotFound
+		}
+
+		return nil, err
+	}
+	span.Finish()
+
 	out := TokenVerificationResult{
 		User: TokenVerificationResultUser{
-			ID:        decodeRes.userID,
-			FirstName: userAuthInfo.FirstName,
-			LastName:  userAuthInfo.LastName,
+			ID:          decodeRes.userID,
+			FirstName:   userAuthInfo.FirstName,
+			LastName:    userAuthInfo.LastName,
+			Version:     userAuthInfo.Version,
+			Roles:       userAuthInfo.Roles,
+			Permissions: rbac.Permissions(userAuthInfo.Roles),
 		},
 	}
 
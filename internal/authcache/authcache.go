@@ -0,0 +1,40 @@
+package authcache
+
+import (
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type Cache interface {
+	repository.AuthenticationInfoCache
+	Close() error
+}
+
+// New creates the cache selected by the configured driver. It returns nil
+// when caching is disabled.
+func New(cfg *config.AuthCacheConfig) (Cache, error) {
+	switch cfg.Driver {
+	case config.AuthCacheDriverMemory:
+		return NewMemoryCache(cfg.Size, cfg.TTL), nil
+	case config.AuthCacheDriverRedis:
+		return NewRedisCache(cfg.RedisURL, cfg.RedisKeyPrefix, cfg.TTL)
+	default:
+		return nil, nil
+	}
+}
+
+func copyInfo(info repository.UserAuthenticationInfo) *repository.UserAuthenticationInfo {
+	if nil != info.Status.ExpiresAt {
+		expiresAt := *info.Status.ExpiresAt
+		info.Status.ExpiresAt = &expiresAt
+	}
+	if nil != info.TokensNotBefore {
+		tokensNotBefore := *info.TokensNotBefore
+		info.TokensNotBefore = &tokensNotBefore
+	}
+	if nil != info.Roles {
+		info.Roles = append([]string{}, info.Roles...)
+	}
+
+	return &info
+}
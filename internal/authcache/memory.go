@@ -0,0 +1,101 @@
+package authcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type memoryEntry struct {
+	userID    string
+	info      repository.UserAuthenticationInfo
+	expiresAt time.Time
+}
+
+// memoryCache is a least recently used cache whose entries also expire after
+// a fixed TTL. Every lookup moves its entry to the front of the list, and
+// once the cache is full the entry at the back is evicted.
+type memoryCache struct {
+	mu      sync.Mutex
+	size    uint
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+func NewMemoryCache(size uint, ttl time.Duration) Cache {
+	return &memoryCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *memoryCache) Get(ctx context.Context, userID string) (*repository.UserAuthenticationInfo, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[userID]
+	if !exists {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(element)
+
+	return copyInfo(entry.info), true, nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, userID string, info repository.UserAuthenticationInfo) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryEntry{
+		userID:    userID,
+		info:      *copyInfo(info),
+		expiresAt: c.now().Add(c.ttl),
+	}
+
+	if element, exists := c.entries[userID]; exists {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[userID] = c.order.PushFront(entry)
+	for uint(c.order.Len()) > c.size {
+		c.remove(c.order.Back())
+	}
+
+	return nil
+}
+
+func (c *memoryCache) Invalidate(ctx context.Context, userID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.entries[userID]; exists {
+		c.remove(element)
+	}
+
+	return nil
+}
+
+func (c *memoryCache) Close() error {
+	return nil
+}
+
+func (c *memoryCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*memoryEntry).userID)
+}
@@ -0,0 +1,116 @@
+package authcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+// redisEntry is the JSON form of a cached entry. It is decoupled from the
+// repository type so renaming a field there does not silently change what
+// other instances read from the shared cache.
+type redisEntry struct {
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Version         uint64     `json:"version"`
+	Status          string     `json:"status"`
+	StatusReason    string     `json:"status_reason,omitempty"`
+	StatusExpiresAt *time.Time `json:"status_expires_at,omitempty"`
+	TokensNotBefore *time.Time `json:"tokens_not_before,omitempty"`
+	Roles           []string   `json:"roles"`
+}
+
+// redisCache shares cached entries between every instance of the service, so
+// an invalidation on one instance is seen by all of them. Entries expire
+// through the Redis key TTL.
+type redisCache struct {
+	client    *redis.Client
+	keyPrefix string
+	ttl       time.Duration
+}
+
+func NewRedisCache(url, keyPrefix string, ttl time.Duration) (Cache, error) {
+	opts, err := redis.ParseURL(url)
+	if nil != err {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+
+	return redisCache{
+		redis.NewClient(opts),
+		keyPrefix,
+		ttl,
+	}, nil
+}
+
+func (c redisCache) Get(ctx context.Context, userID string) (*repository.UserAuthenticationInfo, bool, error) {
+	data, err := c.client.Get(ctx, c.key(userID)).Bytes()
+	if nil != err {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+
+		return nil, false, fmt.Errorf("unable to get cached entry from redis: %w", err)
+	}
+
+	var entry redisEntry
+	if err := json.Unmarshal(data, &entry); nil != err {
+		return nil, false, fmt.Errorf("unable to decode cached entry: %w", err)
+	}
+
+	return &repository.UserAuthenticationInfo{
+		FirstName: entry.FirstName,
+		LastName:  entry.LastName,
+		Version:   entry.Version,
+		Status: repository.UserStatusInfo{
+			Status:    entry.Status,
+			Reason:    entry.StatusReason,
+			ExpiresAt: entry.StatusExpiresAt,
+		},
+		TokensNotBefore: entry.TokensNotBefore,
+		Roles:           entry.Roles,
+	}, true, nil
+}
+
+func (c redisCache) Set(ctx context.Context, userID string, info repository.UserAuthenticationInfo) error {
+	data, err := json.Marshal(redisEntry{
+		FirstName:       info.FirstName,
+		LastName:        info.LastName,
+		Version:         info.Version,
+		Status:          info.Status.Status,
+		StatusReason:    info.Status.Reason,
+		StatusExpiresAt: info.Status.ExpiresAt,
+		TokensNotBefore: info.TokensNotBefore,
+		Roles:           info.Roles,
+	})
+	if nil != err {
+		return err
+	}
+
+	if err := c.client.Set(ctx, c.key(userID), data, c.ttl).Err(); nil != err {
+		return fmt.Errorf("unable to set cached entry in redis: %w", err)
+	}
+
+	return nil
+}
+
+func (c redisCache) Invalidate(ctx context.Context, userID string) error {
+	if err := c.client.Del(ctx, c.key(userID)).Err(); nil != err {
+		return fmt.Errorf("unable to delete cached entry from redis: %w", err)
+	}
+
+	return nil
+}
+
+func (c redisCache) Close() error {
+	return c.client.Close()
+}
+
+func (c redisCache) key(userID string) string {
+	return c.keyPrefix + userID
+}
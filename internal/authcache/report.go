@@ -0,0 +1,65 @@
+package authcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type StatsSource interface {
+	Stats() repository.CacheStats
+}
+
+type Reporter interface {
+	Run(ctx context.Context)
+}
+
+type reporter struct {
+	logger   *logrus.Entry
+	source   StatsSource
+	interval time.Duration
+}
+
+func NewReporter(logger *logrus.Entry, source StatsSource, interval time.Duration) Reporter {
+	return reporter{
+		logger,
+		source,
+		interval,
+	}
+}
+
+// Run logs the cache hits and misses of every interval, along with the
+// totals since the service started.
+func (r reporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	last := r.source.Stats()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := r.source.Stats()
+		hits, misses := current.Hits-last.Hits, current.Misses-last.Misses
+		ratio := 0.0
+		if hits+misses > 0 {
+			ratio = float64(hits) / float64(hits+misses)
+		}
+
+		r.logger.
+			WithField("hits", hits).
+			WithField("misses", misses).
+			WithField("errors", current.Errors-last.Errors).
+			WithField("hit_ratio", ratio).
+			WithField("total_hits", current.Hits).
+			WithField("total_misses", current.Misses).
+			Info("authentication info cache stats")
+		last = current
+	}
+}
@@ -0,0 +1,39 @@
+package authz
+
+import (
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/game-sales-analytics/users-service/internal/auth"
+)
+
+var (
+	errMissingToken     = status.Error(codes.Unauthenticated, "missing bearer token")
+	errInvalidToken     = status.Error(codes.Unauthenticated, "invalid credentials")
+	errPermissionDenied = status.Error(codes.PermissionDenied, "permission denied")
+	errInternal         = status.Error(codes.Internal, "internal error occurred. try again later.")
+)
+
+// AccountSuspendedError lets clients tell a suspended account apart from
+// other permission failures through the ACCOUNT_SUSPENDED error reason.
+func AccountSuspendedError(suspension *auth.SuspendedError) error {
+	st := status.New(codes.PermissionDenied, "account suspended")
+	info := errdetails.ErrorInfo{
+		Reason:   "ACCOUNT_SUSPENDED",
+		Domain:   "users-service",
+		Metadata: map[string]string{},
+	}
+	if nil != suspension.ExpiresAt {
+		info.Metadata["expires_at"] = suspension.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+
+	detailed, err := st.WithDetails(&info)
+	if nil != err {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
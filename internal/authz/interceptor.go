@@ -0,0 +1,188 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/game-sales-analytics/users-service/internal/apikey"
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/auth"
+)
+
+type authorizer struct {
+	logger *logrus.Entry
+	auth   auth.Auth
+	keys   apikey.Keys
+	policy Policy
+}
+
+func UnaryServerInterceptor(logger *logrus.Entry, authSrv auth.Auth, keys apikey.Keys, policy Policy) grpc.UnaryServerInterceptor {
+	a := authorizer{
+		logger,
+		authSrv,
+		keys,
+		policy,
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, path.Base(info.FullMethod))
+		if nil != err {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func StreamServerInterceptor(logger *logrus.Entry, authSrv auth.Auth, keys apikey.Keys, policy Policy) grpc.StreamServerInterceptor {
+	a := authorizer{
+		logger,
+		authSrv,
+		keys,
+		policy,
+	}
+
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(stream.Context(), path.Base(info.FullMethod))
+		if nil != err {
+			return err
+		}
+
+		return handler(srv, authorizedStream{stream, ctx})
+	}
+}
+
+func (a authorizer) authorize(ctx context.Context, method string) (context.Context, error) {
+	rule, exists := a.policy[method]
+	if !exists {
+		a.logger.WithField("method", method).Warn("denying call to method missing from authorization policy")
+		return nil, errPermissionDenied
+	}
+	if rule.Access == AccessPublic {
+		return ctx, nil
+	}
+
+	token, found := readBearerToken(ctx)
+	if !found {
+		return nil, errMissingToken
+	}
+
+	span := sentry.StartSpan(ctx, "authorize-request", sentry.TransactionName("authorize-"+method))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	var principal *Principal
+	var err error
+	if apikey.IsKey(token) {
+		principal, err = a.verifyAPIKey(ctx, span, method, token)
+	} else {
+		principal, err = a.verifyToken(ctx, span, method, token)
+	}
+	if nil != err {
+		return nil, err
+	}
+
+	if rule.Access == AccessPermission && !principal.HasPermission(rule.Permission) {
+		span.Status = sentry.SpanStatusPermissionDenied
+		a.logger.WithField("method", method).WithField("user_id", principal.UserID).WithField("api_key_id", principal.APIKeyID).WithField("permission", rule.Permission).Debug("caller lacks required permission")
+		return nil, errPermissionDenied
+	}
+
+	return NewPrincipalContext(ctx, principal), nil
+}
+
+func (a authorizer) verifyToken(ctx context.Context, span *sentry.Span, method, token string) (*Principal, error) {
+	child := span.StartChild("verify-token")
+	child.Status = sentry.SpanStatusOK
+	verificationResult, err := a.auth.VerifyToken(auth.NewContext(ctx, child), token)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, auth.ErrTokenNotVerified) || errors.Is(err, auth.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusUnauthenticated
+			return nil, errInvalidToken
+		}
+
+		var suspendedErr *auth.SuspendedError
+		if errors.As(err, &suspendedErr) {
+			child.Status = sentry.SpanStatusPermissionDenied
+			return nil, AccountSuspendedError(suspendedErr)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := a.logger.WithError(err).WithField("err_code", "E_VERIFY_BEARER_TOKEN").WithField("method", method)
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed verifying bearer token")
+		return nil, errInternal
+	}
+	child.Finish()
+
+	return &Principal{
+		UserID:      verificationResult.User.ID,
+		Roles:       verificationResult.User.Roles,
+		Permissions: verificationResult.User.Permissions,
+	}, nil
+}
+
+func (a authorizer) verifyAPIKey(ctx context.Context, span *sentry.Span, method, secret string) (*Principal, error) {
+	child := span.StartChild("verify-api-key")
+	child.Status = sentry.SpanStatusOK
+	key, err := a.keys.Verify(apikey.NewContext(ctx, child), secret)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, apikey.ErrInvalidKey) {
+			child.Status = sentry.SpanStatusUnauthenticated
+			return nil, errInvalidToken
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := a.logger.WithError(err).WithField("err_code", "E_VERIFY_API_KEY").WithField("method", method)
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed verifying api key")
+		return nil, errInternal
+	}
+	child.Finish()
+
+	return &Principal{
+		APIKeyID:    key.ID,
+		Roles:       []string{},
+		Permissions: key.Scopes,
+	}, nil
+}
+
+func readBearerToken(ctx context.Context) (string, bool) {
+	meta, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := meta.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+
+	parts := strings.SplitN(values[0], " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(parts[1])
+	return token, len(token) != 0
+}
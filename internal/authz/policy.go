@@ -0,0 +1,58 @@
+package authz
+
+import (
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+)
+
+type Access int
+
+const (
+	// AccessPublic lets anyone call the method. Methods that carry their own
+	// token in the request body verify it themselves and are public here.
+	AccessPublic Access = iota
+	// AccessAuthenticated requires a valid bearer token or api key.
+	AccessAuthenticated
+	// AccessPermission requires a bearer token whose user holds the rule's
+	// permission, or an api key scoped to it.
+	AccessPermission
+)
+
+type Rule struct {
+	Access     Access
+	Permission rbac.Permission
+}
+
+// Policy maps short RPC method names to their access rule. Methods missing
+// from the policy are denied.
+type Policy map[string]Rule
+
+func DefaultPolicy() Policy {
+	return Policy{
+		"Ping":                      {Access: AccessPublic},
+		"LoginWithEmail":            {Access: AccessPublic},
+		"Register":                  {Access: AccessPublic},
+		"Authenticate":              {Access: AccessPublic},
+		"UpdateProfile":             {Access: AccessPublic},
+		"RequestEmailChange":        {Access: AccessPublic},
+		"ConfirmEmailChange":        {Access: AccessPublic},
+		"DeleteAccount":             {Access: AccessPublic},
+		"ExportUserData":            {Access: AccessPublic},
+		"GetUser":                   {Access: AccessPermission, Permission: rbac.PermissionUsersRead},
+		"BatchGetUsers":             {Access: AccessPermission, Permission: rbac.PermissionUsersRead},
+		"ListUsers":                 {Access: AccessPermission, Permission: rbac.PermissionUsersList},
+		"QueryAuditEvents":          {Access: AccessPermission, Permission: rbac.PermissionAuditEventsRead},
+		"SuspendUser":               {Access: AccessPermission, Permission: rbac.PermissionUsersModerate},
+		"ReactivateUser":            {Access: AccessPermission, Permission: rbac.PermissionUsersModerate},
+		"AdminExportUserData":       {Access: AccessPermission, Permission: rbac.PermissionUsersExport},
+		"GrantRole":                 {Access: AccessPermission, Permission: rbac.PermissionRolesManage},
+		"RevokeRole":                {Access: AccessPermission, Permission: rbac.PermissionRolesManage},
+		"CreateAPIKey":              {Access: AccessPermission, Permission: rbac.PermissionAPIKeysManage},
+		"ListAPIKeys":               {Access: AccessPermission, Permission: rbac.PermissionAPIKeysManage},
+		"RevokeAPIKey":              {Access: AccessPermission, Permission: rbac.PermissionAPIKeysManage},
+		"CreateWebhookSubscription": {Access: AccessPermission, Permission: rbac.PermissionWebhooksManage},
+		"ListWebhookSubscriptions":  {Access: AccessPermission, Permission: rbac.PermissionWebhooksManage},
+		"DeleteWebhookSubscription": {Access: AccessPermission, Permission: rbac.PermissionWebhooksManage},
+		"ListWebhookDeliveries":     {Access: AccessPermission, Permission: rbac.PermissionWebhooksManage},
+		"RetryWebhookDelivery":      {Access: AccessPermission, Permission: rbac.PermissionWebhooksManage},
+	}
+}
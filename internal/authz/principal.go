@@ -0,0 +1,49 @@
+package authz
+
+import (
+	"context"
+)
+
+// Principal is the authenticated caller of an RPC: either a user holding a
+// bearer token, or a service holding an api key. Api keys carry their scopes
+// as permissions and have no user or roles.
+type Principal struct {
+	UserID      string
+	APIKeyID    string
+	Roles       []string
+	Permissions []string
+}
+
+func (p Principal) HasPermission(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+type principalContextKey struct{}
+
+func NewPrincipalContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok
+}
+
+// ActorID returns the id of the authenticated caller, or an empty string for
+// anonymous requests. Api keys are reported as "api_key:<id>".
+func ActorID(ctx context.Context) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		if len(principal.APIKeyID) != 0 {
+			return "api_key:" + principal.APIKeyID
+		}
+		return principal.UserID
+	}
+
+	return ""
+}
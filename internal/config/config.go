@@ -1,17 +1,88 @@
 package config
 
+import (
+	"time"
+)
+
 type ServerConfig struct {
 	Port uint
 	Host string
 }
 
+type DatabaseAuthMechanism = string
+
+const (
+	DatabaseAuthMechanismSCRAMSHA1   DatabaseAuthMechanism = "SCRAM-SHA-1"
+	DatabaseAuthMechanismSCRAMSHA256 DatabaseAuthMechanism = "SCRAM-SHA-256"
+	DatabaseAuthMechanismX509        DatabaseAuthMechanism = "MONGODB-X509"
+	DatabaseAuthMechanismPlain       DatabaseAuthMechanism = "PLAIN"
+)
+
+type DatabaseTLSConfig struct {
+	Enabled  bool
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// DatabaseConfig describes the MongoDB connection. When URI is set, Host and
+// Port are ignored; every other option set here takes precedence over the
+// same option in the URI.
 type DatabaseConfig struct {
-	Port     uint
-	Host     string
-	Name     string
-	Username string
-	Password string
-	UseAuth  bool
+	URI           string
+	Port          uint
+	Host          string
+	Name          string
+	Username      string
+	Password      string
+	UseAuth       bool
+	AuthSource    string
+	AuthMechanism DatabaseAuthMechanism
+	TLS           DatabaseTLSConfig
+
+	ReplicaSet     string
+	ReadPreference string
+	// WriteConcern is either 'majority' or the number of members that must
+	// acknowledge a write.
+	WriteConcern string
+	// AllowNoTransactions lets the service run on a standalone server, which
+	// has no transactions. Writes that go together, like a user and its
+	// outbox event, are then not atomic, so it is meant for local development
+	// only.
+	AllowNoTransactions bool
+
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	MaxConnIdleTime        time.Duration
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	SocketTimeout          time.Duration
+}
+
+type StorageBackend = string
+
+const (
+	StorageBackendMongo    StorageBackend = "mongo"
+	StorageBackendPostgres StorageBackend = "postgres"
+	StorageBackendMemory   StorageBackend = "memory"
+)
+
+type StorageConfig struct {
+	Backend StorageBackend
+}
+
+// PostgresConfig describes the PostgreSQL connection used by the postgres
+// storage backend. URL accepts both the URL and the keyword/value forms.
+type PostgresConfig struct {
+	URL            string
+	MaxConns       uint
+	MinConns       uint
+	ConnectTimeout time.Duration
+}
+
+type MigrationsConfig struct {
+	RunOnStartup bool
+	LockTimeout  time.Duration
 }
 
 type JwtConfig struct {
@@ -24,9 +95,186 @@ type APMConfig struct {
 	Release string
 }
 
+type EnrichmentConfig struct {
+	GeoIPCityDatabasePath string
+	GeoIPASNDatabasePath  string
+	ParseUserAgent        bool
+}
+
+type RateLimitKeySource = string
+
+const (
+	RateLimitKeySourcePeer   RateLimitKeySource = "peer"
+	RateLimitKeySourceIP     RateLimitKeySource = "ip"
+	RateLimitKeySourceHeader RateLimitKeySource = "header"
+)
+
+type RateLimitRule struct {
+	Requests uint
+	Period   time.Duration
+	Burst    uint
+}
+
+type RateLimitConfig struct {
+	Enabled   bool
+	KeySource RateLimitKeySource
+	KeyHeader string
+	Default   RateLimitRule
+	Methods   map[string]RateLimitRule
+}
+
+type ChallengeProvider = string
+
+const (
+	ChallengeProviderNone      ChallengeProvider = "none"
+	ChallengeProviderHashcash  ChallengeProvider = "hashcash"
+	ChallengeProviderHCaptcha  ChallengeProvider = "hcaptcha"
+	ChallengeProviderTurnstile ChallengeProvider = "turnstile"
+)
+
+type ChallengeConfig struct {
+	Provider           ChallengeProvider
+	HashcashDifficulty uint
+	HashcashMaxAge     time.Duration
+	Secret             string
+	VerifyURL          string
+}
+
+type EmailDomainPolicyConfig struct {
+	RejectDisposable      bool
+	DisposableDomainsFile string
+	AllowedDomains        []string
+	DeniedDomains         []string
+}
+
+type UsersConfig struct {
+	BatchGetLimit uint
+}
+
+type MailerDriver = string
+
+const (
+	MailerDriverLog  MailerDriver = "log"
+	MailerDriverSMTP MailerDriver = "smtp"
+)
+
+type MailerConfig struct {
+	Driver       MailerDriver
+	From         string
+	SMTPHost     string
+	SMTPPort     uint
+	SMTPUsername string
+	SMTPPassword string
+}
+
+type EmailChangeConfig struct {
+	TokenTTL time.Duration
+}
+
+type AccountPurgeMode = string
+
+const (
+	AccountPurgeModeDelete    AccountPurgeMode = "delete"
+	AccountPurgeModeAnonymize AccountPurgeMode = "anonymize"
+)
+
+type AccountDeletionConfig struct {
+	GracePeriod   time.Duration
+	PurgeInterval time.Duration
+	PurgeMode     AccountPurgeMode
+}
+
+// LoginRetentionConfig limits how long login records, which carry IP
+// addresses, are kept. A zero Period keeps them forever. With Aggregate set,
+// every completed day is rolled into anonymized counts before its records
+// expire.
+type LoginRetentionConfig struct {
+	Period    time.Duration
+	Aggregate bool
+	Interval  time.Duration
+}
+
+type RolesConfig struct {
+	BootstrapAdminUserID string
+}
+
+type APIKeysConfig struct {
+	HashSecret string
+	MaxTTL     time.Duration
+}
+
+type OutboxPublisher = string
+
+const (
+	OutboxPublisherLog  OutboxPublisher = "log"
+	OutboxPublisherFile OutboxPublisher = "file"
+	OutboxPublisherNATS OutboxPublisher = "nats"
+)
+
+type OutboxConfig struct {
+	Publisher         OutboxPublisher
+	RelayInterval     time.Duration
+	BatchSize         uint
+	FilePath          string
+	NATSURL           string
+	NATSSubjectPrefix string
+}
+
+type WebhooksConfig struct {
+	DeliveryInterval time.Duration
+	Timeout          time.Duration
+	MaxAttempts      uint
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+	BatchSize        uint
+}
+
+type AuthCacheDriver = string
+
+const (
+	AuthCacheDriverNone   AuthCacheDriver = "none"
+	AuthCacheDriverMemory AuthCacheDriver = "memory"
+	AuthCacheDriverRedis  AuthCacheDriver = "redis"
+)
+
+// AuthCacheConfig configures the cache in front of user authentication info
+// lookups. The memory driver is local to each instance, so with more than
+// one instance a change made through another instance may take up to TTL to
+// be seen; the redis driver shares entries and invalidations.
+type AuthCacheConfig struct {
+	Driver         AuthCacheDriver
+	TTL            time.Duration
+	Size           uint
+	RedisURL       string
+	RedisKeyPrefix string
+	StatsInterval  time.Duration
+}
+
+type AuthorizationConfig struct {
+	Enabled bool
+}
+
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Jwt      JwtConfig
-	APM      APMConfig
+	Server      ServerConfig
+	Storage     StorageConfig
+	Database    DatabaseConfig
+	Postgres    PostgresConfig
+	Migrations  MigrationsConfig
+	Jwt         JwtConfig
+	APM         APMConfig
+	Enrichment  EnrichmentConfig
+	RateLimit   RateLimitConfig
+	Authz       AuthorizationConfig
+	Challenge   ChallengeConfig
+	EmailPolicy EmailDomainPolicyConfig
+	Users       UsersConfig
+	Mailer      MailerConfig
+	EmailChange EmailChangeConfig
+	Deletion    AccountDeletionConfig
+	Logins      LoginRetentionConfig
+	Roles       RolesConfig
+	APIKeys     APIKeysConfig
+	Outbox      OutboxConfig
+	Webhooks    WebhooksConfig
+	AuthCache   AuthCacheConfig
 }
@@ -1,21 +1,155 @@
 package config
 
+import (
+	"time"
+)
+
 func getDefaults() Config {
 	return Config{
 		Server: ServerConfig{
 			Port: 50050,
 			Host: "127.0.0.1",
 		},
+		Storage: StorageConfig{
+			Backend: StorageBackendMongo,
+		},
 		Database: DatabaseConfig{
-			Port:     27018,
-			Host:     "users_db",
-			Name:     "users",
-			Username: "",
-			Password: "",
-			UseAuth:  false,
+			URI:           "",
+			Port:          27018,
+			Host:          "users_db",
+			Name:          "users",
+			Username:      "",
+			Password:      "",
+			UseAuth:       false,
+			AuthSource:    "",
+			AuthMechanism: DatabaseAuthMechanismSCRAMSHA256,
+			TLS: DatabaseTLSConfig{
+				Enabled:  false,
+				CAFile:   "",
+				CertFile: "",
+				KeyFile:  "",
+			},
+			ReplicaSet:             "",
+			ReadPreference:         "",
+			WriteConcern:           "",
+			AllowNoTransactions:    false,
+			MaxPoolSize:            0,
+			MinPoolSize:            0,
+			MaxConnIdleTime:        0,
+			ConnectTimeout:         time.Second * 10,
+			ServerSelectionTimeout: time.Second * 10,
+			SocketTimeout:          0,
+		},
+		Postgres: PostgresConfig{
+			URL:            "",
+			MaxConns:       0,
+			MinConns:       0,
+			ConnectTimeout: time.Second * 10,
+		},
+		Migrations: MigrationsConfig{
+			RunOnStartup: false,
+			LockTimeout:  time.Minute * 5,
 		},
 		Jwt: JwtConfig{
 			Secret: "",
 		},
+		Enrichment: EnrichmentConfig{
+			GeoIPCityDatabasePath: "",
+			GeoIPASNDatabasePath:  "",
+			ParseUserAgent:        false,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   true,
+			KeySource: RateLimitKeySourcePeer,
+			KeyHeader: "x-client-id",
+			Default: RateLimitRule{
+				Requests: 0,
+				Period:   time.Second,
+				Burst:    0,
+			},
+			Methods: map[string]RateLimitRule{
+				"Register": {
+					Requests: 5,
+					Period:   time.Minute,
+					Burst:    5,
+				},
+				"LoginWithEmail": {
+					Requests: 10,
+					Period:   time.Minute,
+					Burst:    10,
+				},
+			},
+		},
+		Authz: AuthorizationConfig{
+			Enabled: true,
+		},
+		Challenge: ChallengeConfig{
+			Provider:           ChallengeProviderNone,
+			HashcashDifficulty: 20,
+			HashcashMaxAge:     time.Minute * 10,
+			Secret:             "",
+			VerifyURL:          "",
+		},
+		EmailPolicy: EmailDomainPolicyConfig{
+			RejectDisposable:      true,
+			DisposableDomainsFile: "",
+			AllowedDomains:        []string{},
+			DeniedDomains:         []string{},
+		},
+		Users: UsersConfig{
+			BatchGetLimit: 100,
+		},
+		Mailer: MailerConfig{
+			Driver:       MailerDriverLog,
+			From:         "no-reply@game-sales-analytics.local",
+			SMTPHost:     "",
+			SMTPPort:     587,
+			SMTPUsername: "",
+			SMTPPassword: "",
+		},
+		EmailChange: EmailChangeConfig{
+			TokenTTL: time.Hour * 24,
+		},
+		Deletion: AccountDeletionConfig{
+			GracePeriod:   time.Hour * 24 * 30,
+			PurgeInterval: time.Hour,
+			PurgeMode:     AccountPurgeModeDelete,
+		},
+		Logins: LoginRetentionConfig{
+			Period:    time.Hour * 24 * 90,
+			Aggregate: false,
+			Interval:  time.Hour,
+		},
+		Roles: RolesConfig{
+			BootstrapAdminUserID: "",
+		},
+		APIKeys: APIKeysConfig{
+			HashSecret: "",
+			MaxTTL:     time.Hour * 24 * 365,
+		},
+		Outbox: OutboxConfig{
+			Publisher:         OutboxPublisherLog,
+			RelayInterval:     time.Second * 5,
+			BatchSize:         100,
+			FilePath:          "",
+			NATSURL:           "",
+			NATSSubjectPrefix: "users",
+		},
+		Webhooks: WebhooksConfig{
+			DeliveryInterval: time.Second * 5,
+			Timeout:          time.Second * 10,
+			MaxAttempts:      10,
+			BackoffBase:      time.Second * 30,
+			BackoffMax:       time.Hour * 6,
+			BatchSize:        100,
+		},
+		AuthCache: AuthCacheConfig{
+			Driver:         AuthCacheDriverNone,
+			TTL:            time.Second * 30,
+			Size:           10000,
+			RedisURL:       "",
+			RedisKeyPrefix: "users:auth:",
+			StatsInterval:  time.Minute,
+		},
 	}
 }
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+// parseRateLimitRule parses rules in the form of REQUESTS/PERIOD[:BURST],
+// e.g. '5/1m' or '10/1s:20'. Burst defaults to the number of requests.
+func parseRateLimitRule(raw string) (RateLimitRule, error) {
+	rateAndBurst := strings.SplitN(strings.TrimSpace(raw), ":", 2)
+	requestsAndPeriod := strings.SplitN(rateAndBurst[0], "/", 2)
+	if len(requestsAndPeriod) != 2 {
+		return RateLimitRule{}, fmt.Errorf("rule '%s' must be in the form of REQUESTS/PERIOD[:BURST]", raw)
+	}
+
+	requests, err := strconv.ParseUint(requestsAndPeriod[0], 10, 32)
+	if nil != err {
+		return RateLimitRule{}, fmt.Errorf("invalid requests count in rule '%s': %s", raw, err)
+	}
+
+	period, err := time.ParseDuration(requestsAndPeriod[1])
+	if nil != err {
+		return RateLimitRule{}, fmt.Errorf("invalid period in rule '%s': %s", raw, err)
+	}
+	if period <= 0 {
+		return RateLimitRule{}, fmt.Errorf("period in rule '%s' must be positive", raw)
+	}
+
+	burst := requests
+	if len(rateAndBurst) == 2 {
+		burst, err = strconv.ParseUint(rateAndBurst[1], 10, 32)
+		if nil != err {
+			return RateLimitRule{}, fmt.Errorf("invalid burst in rule '%s': %s", raw, err)
+		}
+	}
+	if requests != 0 && burst == 0 {
+		return RateLimitRule{}, fmt.Errorf("burst in rule '%s' must be positive", raw)
+	}
+
+	return RateLimitRule{
+		Requests: uint(requests),
+		Period:   period,
+		Burst:    uint(burst),
+	}, nil
+}
+
+// parseRateLimitMethodRules parses comma separated METHOD=RULE pairs,
+// e.g. 'Register=5/1m,LoginWithEmail=10/1m:20'.
+func parseRateLimitMethodRules(raw string) (map[string]RateLimitRule, error) {
+	out := make(map[string]RateLimitRule)
+	for _, pair := range strings.Split(raw, ",") {
+		methodAndRule := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(methodAndRule) != 2 || len(methodAndRule[0]) == 0 {
+			return nil, errors.New("method rules must be in the form of METHOD=REQUESTS/PERIOD[:BURST]")
+		}
+
+		rule, err := parseRateLimitRule(methodAndRule[1])
+		if nil != err {
+			return nil, err
+		}
+
+		out[methodAndRule[0]] = rule
+	}
+
+	return out, nil
+}
+
+func parseList(raw string) []string {
+	out := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); len(item) != 0 {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+// validateDatabaseURI checks a MongoDB connection string without connecting.
+// Options of SRV URIs may come from DNS TXT records, so those URIs are only
+// checked for their shape here and fully parsed when connecting.
+func validateDatabaseURI(raw string) error {
+	if strings.HasPrefix(raw, "mongodb+srv://") {
+		host := databaseURIHosts(raw)
+		if len(host) == 0 || strings.ContainsAny(host, ",:") {
+			return errors.New("mongodb+srv URI must contain exactly one host name without a port")
+		}
+
+		return nil
+	}
+	if !strings.HasPrefix(raw, "mongodb://") {
+		return errors.New("URI scheme must be either 'mongodb' or 'mongodb+srv'")
+	}
+
+	_, err := connstring.ParseAndValidate(raw)
+	return err
+}
+
+// validateDatabaseTLSFiles loads the configured certificates once, so broken
+// files fail the startup instead of the first connection attempt.
+func validateDatabaseTLSFiles(cfg *DatabaseTLSConfig) error {
+	if len(cfg.CAFile) != 0 {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if nil != err {
+			return fmt.Errorf("invalid 'DATABASE_TLS_CA_FILE' environment variable is provided: %s", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return errors.New("invalid 'DATABASE_TLS_CA_FILE' environment variable is provided: file does not contain any pem encoded certificate")
+		}
+	}
+
+	if len(cfg.CertFile) != 0 {
+		if _, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile); nil != err {
+			return fmt.Errorf("invalid 'DATABASE_TLS_CERT_FILE' and 'DATABASE_TLS_KEY_FILE' environment variables are provided: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// databaseURIHosts returns the host list of a MongoDB connection string,
+// e.g. 'a:27017,b:27017' for 'mongodb://user:pass@a:27017,b:27017/db'.
+func databaseURIHosts(raw string) string {
+	rest := raw[strings.Index(raw, "://")+3:]
+	if end := strings.IndexAny(rest, "/?"); end != -1 {
+		rest = rest[:end]
+	}
+	if at := strings.LastIndex(rest, "@"); at != -1 {
+		rest = rest[at+1:]
+	}
+
+	return rest
+}
+
+// maskURICredentials hides the credentials of a connection string, such as a
+// MongoDB or NATS URL, so it can be logged.
+func maskURICredentials(raw string) string {
+	schemeEnd := strings.Index(raw, "://")
+	if schemeEnd == -1 {
+		return strings.Repeat("*", len(raw))
+	}
+
+	rest := raw[schemeEnd+3:]
+	authority := rest
+	if end := strings.IndexAny(rest, "/?"); end != -1 {
+		authority = rest[:end]
+	}
+	at := strings.LastIndex(authority, "@")
+	if at == -1 {
+		return raw
+	}
+
+	userInfo := strings.SplitN(authority[:at], ":", 2)
+	for i := range userInfo {
+		userInfo[i] = strings.Repeat("*", len(userInfo[i]))
+	}
+
+	return raw[:schemeEnd+3] + strings.Join(userInfo, ":") + rest[at:]
+}
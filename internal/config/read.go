@@ -3,10 +3,13 @@ package config
 import (
 	"errors"
 	"fmt"
+	"math"
+	"net/mail"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/sirupsen/logrus"
@@ -33,6 +36,26 @@ func readEnvironmetVariablesOrUseDefaults(logger *logrus.Entry) (Config, error)
 		conf.Server.Port = uint(value)
 	}
 
+	if value, exists := os.LookupEnv("STORAGE_BACKEND"); exists && len(value) != 0 {
+		switch value {
+		case StorageBackendMongo, StorageBackendPostgres, StorageBackendMemory:
+		default:
+			return Config{}, fmt.Errorf("invalid 'STORAGE_BACKEND' environment variable is provided: expected one of 'mongo', 'postgres' or 'memory', got '%s'", value)
+		}
+
+		logger.WithField("variable", "STORAGE_BACKEND").WithField("value", value).Debug("using provided environment variable")
+		conf.Storage.Backend = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_URI"); exists && len(value) != 0 {
+		if err := validateDatabaseURI(value); nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_URI' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "DATABASE_URI").WithField("value", maskURICredentials(value)).Debug("using provided environment variable")
+		conf.Database.URI = value
+	}
+
 	if value, exists := os.LookupEnv("DATABASE_HOST"); exists && len(value) != 0 {
 		logger.WithField("variable", "DATABASE_HOST").WithField("value", value).Debug("using provided environment variable")
 		conf.Database.Host = value
@@ -68,6 +91,246 @@ func readEnvironmetVariablesOrUseDefaults(logger *logrus.Entry) (Config, error)
 		conf.Database.Name = value
 	}
 
+	if value, exists := os.LookupEnv("DATABASE_AUTH_SOURCE"); exists && len(value) != 0 {
+		logger.WithField("variable", "DATABASE_AUTH_SOURCE").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.AuthSource = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_AUTH_MECHANISM"); exists && len(value) != 0 {
+		switch value {
+		case DatabaseAuthMechanismSCRAMSHA1, DatabaseAuthMechanismSCRAMSHA256, DatabaseAuthMechanismX509, DatabaseAuthMechanismPlain:
+		default:
+			return Config{}, fmt.Errorf("invalid 'DATABASE_AUTH_MECHANISM' environment variable is provided: expected one of 'SCRAM-SHA-1', 'SCRAM-SHA-256', 'MONGODB-X509' or 'PLAIN', got '%s'", value)
+		}
+
+		logger.WithField("variable", "DATABASE_AUTH_MECHANISM").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.AuthMechanism = value
+	}
+
+	if _, exists := os.LookupEnv("DATABASE_TLS"); exists {
+		logger.WithField("variable", "DATABASE_TLS").Debug("enabling tls in database connection due to existence of environment variable")
+		conf.Database.TLS.Enabled = true
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_TLS_CA_FILE"); exists && len(value) != 0 {
+		if _, err := os.Stat(value); nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_TLS_CA_FILE' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "DATABASE_TLS_CA_FILE").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.TLS.Enabled = true
+		conf.Database.TLS.CAFile = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_TLS_CERT_FILE"); exists && len(value) != 0 {
+		if _, err := os.Stat(value); nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_TLS_CERT_FILE' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "DATABASE_TLS_CERT_FILE").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.TLS.Enabled = true
+		conf.Database.TLS.CertFile = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_TLS_KEY_FILE"); exists && len(value) != 0 {
+		if _, err := os.Stat(value); nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_TLS_KEY_FILE' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "DATABASE_TLS_KEY_FILE").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.TLS.Enabled = true
+		conf.Database.TLS.KeyFile = value
+	}
+
+	if (len(conf.Database.TLS.CertFile) == 0) != (len(conf.Database.TLS.KeyFile) == 0) {
+		return Config{}, errors.New("'DATABASE_TLS_CERT_FILE' and 'DATABASE_TLS_KEY_FILE' environment variables must be provided together")
+	}
+
+	if err := validateDatabaseTLSFiles(&conf.Database.TLS); nil != err {
+		return Config{}, err
+	}
+
+	if conf.Database.UseAuth {
+		if conf.Database.AuthMechanism == DatabaseAuthMechanismX509 {
+			if len(conf.Database.TLS.CertFile) == 0 {
+				return Config{}, errors.New("'DATABASE_TLS_CERT_FILE' environment variable is required for 'MONGODB-X509' database authentication mechanism")
+			}
+		} else if len(conf.Database.Username) == 0 || len(conf.Database.Password) == 0 {
+			return Config{}, fmt.Errorf("'DATABASE_USERNAME' and 'DATABASE_PASSWORD' environment variables are required for '%s' database authentication mechanism", conf.Database.AuthMechanism)
+		}
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_REPLICA_SET"); exists && len(value) != 0 {
+		logger.WithField("variable", "DATABASE_REPLICA_SET").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.ReplicaSet = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_READ_PREFERENCE"); exists && len(value) != 0 {
+		switch value {
+		case "primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest":
+		default:
+			return Config{}, fmt.Errorf("invalid 'DATABASE_READ_PREFERENCE' environment variable is provided: expected one of 'primary', 'primaryPreferred', 'secondary', 'secondaryPreferred' or 'nearest', got '%s'", value)
+		}
+
+		logger.WithField("variable", "DATABASE_READ_PREFERENCE").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.ReadPreference = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_WRITE_CONCERN"); exists && len(value) != 0 {
+		// Unacknowledged writes are rejected: uniqueness is enforced by
+		// indexes and duplicate key errors must reach the service.
+		if value != "majority" {
+			if members, err := strconv.ParseUint(value, 10, 32); nil != err || members == 0 {
+				return Config{}, fmt.Errorf("invalid 'DATABASE_WRITE_CONCERN' environment variable is provided: expected 'majority' or a positive number of members, got '%s'", value)
+			}
+		}
+
+		logger.WithField("variable", "DATABASE_WRITE_CONCERN").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.WriteConcern = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_MAX_POOL_SIZE"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 64)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_MAX_POOL_SIZE' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "DATABASE_MAX_POOL_SIZE").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.MaxPoolSize = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_MIN_POOL_SIZE"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 64)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_MIN_POOL_SIZE' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "DATABASE_MIN_POOL_SIZE").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.MinPoolSize = value
+	}
+
+	if conf.Database.MaxPoolSize != 0 && conf.Database.MinPoolSize > conf.Database.MaxPoolSize {
+		return Config{}, errors.New("invalid 'DATABASE_MIN_POOL_SIZE' environment variable is provided: must not exceed 'DATABASE_MAX_POOL_SIZE'")
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_MAX_CONN_IDLE_TIME"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_MAX_CONN_IDLE_TIME' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'DATABASE_MAX_CONN_IDLE_TIME' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "DATABASE_MAX_CONN_IDLE_TIME").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.MaxConnIdleTime = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_CONNECT_TIMEOUT"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_CONNECT_TIMEOUT' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'DATABASE_CONNECT_TIMEOUT' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "DATABASE_CONNECT_TIMEOUT").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.ConnectTimeout = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_SERVER_SELECTION_TIMEOUT"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_SERVER_SELECTION_TIMEOUT' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'DATABASE_SERVER_SELECTION_TIMEOUT' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "DATABASE_SERVER_SELECTION_TIMEOUT").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.ServerSelectionTimeout = value
+	}
+
+	if value, exists := os.LookupEnv("DATABASE_SOCKET_TIMEOUT"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'DATABASE_SOCKET_TIMEOUT' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'DATABASE_SOCKET_TIMEOUT' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "DATABASE_SOCKET_TIMEOUT").WithField("value", value).Debug("using provided environment variable")
+		conf.Database.SocketTimeout = value
+	}
+
+	if _, exists := os.LookupEnv("DATABASE_ALLOW_NO_TRANSACTIONS"); exists {
+		logger.WithField("variable", "DATABASE_ALLOW_NO_TRANSACTIONS").Warn("allowing database deployments without transaction support due to existence of environment variable. do not use it in production")
+		conf.Database.AllowNoTransactions = true
+	}
+
+	if value, exists := os.LookupEnv("POSTGRES_URL"); exists && len(value) != 0 {
+		logger.WithField("variable", "POSTGRES_URL").WithField("value", maskURICredentials(value)).Debug("using provided environment variable")
+		conf.Postgres.URL = value
+	}
+
+	if value, exists := os.LookupEnv("POSTGRES_MAX_CONNS"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 31)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'POSTGRES_MAX_CONNS' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "POSTGRES_MAX_CONNS").WithField("value", value).Debug("using provided environment variable")
+		conf.Postgres.MaxConns = uint(value)
+	}
+
+	if value, exists := os.LookupEnv("POSTGRES_MIN_CONNS"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 31)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'POSTGRES_MIN_CONNS' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "POSTGRES_MIN_CONNS").WithField("value", value).Debug("using provided environment variable")
+		conf.Postgres.MinConns = uint(value)
+	}
+
+	if conf.Postgres.MaxConns != 0 && conf.Postgres.MinConns > conf.Postgres.MaxConns {
+		return Config{}, errors.New("invalid 'POSTGRES_MIN_CONNS' environment variable is provided: must not exceed 'POSTGRES_MAX_CONNS'")
+	}
+
+	if value, exists := os.LookupEnv("POSTGRES_CONNECT_TIMEOUT"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'POSTGRES_CONNECT_TIMEOUT' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'POSTGRES_CONNECT_TIMEOUT' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "POSTGRES_CONNECT_TIMEOUT").WithField("value", value).Debug("using provided environment variable")
+		conf.Postgres.ConnectTimeout = value
+	}
+
+	if conf.Storage.Backend == StorageBackendPostgres && len(conf.Postgres.URL) == 0 {
+		return Config{}, errors.New("'POSTGRES_URL' environment variable is required for 'postgres' storage backend")
+	}
+
+	if _, exists := os.LookupEnv("MIGRATIONS_RUN_ON_STARTUP"); exists {
+		logger.WithField("variable", "MIGRATIONS_RUN_ON_STARTUP").Debug("enabling pending database migrations at startup due to existence of environment variable")
+		conf.Migrations.RunOnStartup = true
+	}
+
+	if value, exists := os.LookupEnv("MIGRATIONS_LOCK_TIMEOUT"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'MIGRATIONS_LOCK_TIMEOUT' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "MIGRATIONS_LOCK_TIMEOUT").WithField("value", value).Debug("using provided environment variable")
+		conf.Migrations.LockTimeout = value
+	}
+
 	if value, exists := os.LookupEnv("JWT_SECRET"); exists && len(value) != 0 {
 		logger.WithField("variable", "JWT_SECRET").WithField("value", strings.Repeat("*", len(value))).Debug("using provided environment variable")
 		conf.Jwt.Secret = value
@@ -75,6 +338,525 @@ func readEnvironmetVariablesOrUseDefaults(logger *logrus.Entry) (Config, error)
 		return Config{}, errors.New("'JWT_SECRET' environment variable is required")
 	}
 
+	if value, exists := os.LookupEnv("GEOIP_CITY_DATABASE_PATH"); exists && len(value) != 0 {
+		if _, err := os.Stat(value); nil != err {
+			return Config{}, fmt.Errorf("invalid 'GEOIP_CITY_DATABASE_PATH' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "GEOIP_CITY_DATABASE_PATH").WithField("value", value).Debug("using provided environment variable")
+		conf.Enrichment.GeoIPCityDatabasePath = value
+	}
+
+	if value, exists := os.LookupEnv("GEOIP_ASN_DATABASE_PATH"); exists && len(value) != 0 {
+		if _, err := os.Stat(value); nil != err {
+			return Config{}, fmt.Errorf("invalid 'GEOIP_ASN_DATABASE_PATH' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "GEOIP_ASN_DATABASE_PATH").WithField("value", value).Debug("using provided environment variable")
+		conf.Enrichment.GeoIPASNDatabasePath = value
+	}
+
+	if _, exists := os.LookupEnv("PARSE_USER_AGENT"); exists {
+		logger.WithField("variable", "PARSE_USER_AGENT").Debug("enabling user agent parsing of login records due to existence of environment variable")
+		conf.Enrichment.ParseUserAgent = true
+	}
+
+	if _, exists := os.LookupEnv("RATE_LIMIT_DISABLE"); exists {
+		logger.WithField("variable", "RATE_LIMIT_DISABLE").Debug("disabling rate limiting due to existence of environment variable")
+		conf.RateLimit.Enabled = false
+	}
+
+	if value, exists := os.LookupEnv("RATE_LIMIT_KEY_SOURCE"); exists && len(value) != 0 {
+		switch value {
+		case RateLimitKeySourcePeer, RateLimitKeySourceIP, RateLimitKeySourceHeader:
+		default:
+			return Config{}, fmt.Errorf("invalid 'RATE_LIMIT_KEY_SOURCE' environment variable is provided: expected one of 'peer', 'ip' or 'header', got '%s'", value)
+		}
+
+		logger.WithField("variable", "RATE_LIMIT_KEY_SOURCE").WithField("value", value).Debug("using provided environment variable")
+		conf.RateLimit.KeySource = value
+	}
+
+	if value, exists := os.LookupEnv("RATE_LIMIT_KEY_HEADER"); exists && len(value) != 0 {
+		logger.WithField("variable", "RATE_LIMIT_KEY_HEADER").WithField("value", value).Debug("using provided environment variable")
+		conf.RateLimit.KeyHeader = strings.ToLower(value)
+	}
+
+	if value, exists := os.LookupEnv("RATE_LIMIT_DEFAULT"); exists && len(value) != 0 {
+		rule, err := parseRateLimitRule(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'RATE_LIMIT_DEFAULT' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "RATE_LIMIT_DEFAULT").WithField("value", value).Debug("using provided environment variable")
+		conf.RateLimit.Default = rule
+	}
+
+	if value, exists := os.LookupEnv("RATE_LIMIT_METHODS"); exists && len(value) != 0 {
+		rules, err := parseRateLimitMethodRules(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'RATE_LIMIT_METHODS' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "RATE_LIMIT_METHODS").WithField("value", value).Debug("using provided environment variable")
+		conf.RateLimit.Methods = rules
+	}
+
+	if _, exists := os.LookupEnv("AUTHORIZATION_DISABLE"); exists {
+		logger.WithField("variable", "AUTHORIZATION_DISABLE").Warn("disabling authorization interceptor due to existence of environment variable. every rpc is open to any caller")
+		conf.Authz.Enabled = false
+	}
+
+	if value, exists := os.LookupEnv("CHALLENGE_PROVIDER"); exists && len(value) != 0 {
+		switch value {
+		case ChallengeProviderNone, ChallengeProviderHashcash, ChallengeProviderHCaptcha, ChallengeProviderTurnstile:
+		default:
+			return Config{}, fmt.Errorf("invalid 'CHALLENGE_PROVIDER' environment variable is provided: expected one of 'none', 'hashcash', 'hcaptcha' or 'turnstile', got '%s'", value)
+		}
+
+		logger.WithField("variable", "CHALLENGE_PROVIDER").WithField("value", value).Debug("using provided environment variable")
+		conf.Challenge.Provider = value
+	}
+
+	if value, exists := os.LookupEnv("CHALLENGE_HASHCASH_DIFFICULTY"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 8)
+		if nil != err {
+			return Config{}, err
+		}
+		if value == 0 || value > 160 {
+			return Config{}, errors.New("'CHALLENGE_HASHCASH_DIFFICULTY' environment variable must be between 1 and 160")
+		}
+
+		logger.WithField("variable", "CHALLENGE_HASHCASH_DIFFICULTY").WithField("value", value).Debug("using provided environment variable")
+		conf.Challenge.HashcashDifficulty = uint(value)
+	}
+
+	if value, exists := os.LookupEnv("CHALLENGE_HASHCASH_MAX_AGE"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'CHALLENGE_HASHCASH_MAX_AGE' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "CHALLENGE_HASHCASH_MAX_AGE").WithField("value", value).Debug("using provided environment variable")
+		conf.Challenge.HashcashMaxAge = value
+	}
+
+	if value, exists := os.LookupEnv("CHALLENGE_SECRET"); exists && len(value) != 0 {
+		logger.WithField("variable", "CHALLENGE_SECRET").WithField("value", strings.Repeat("*", len(value))).Debug("using provided environment variable")
+		conf.Challenge.Secret = value
+	}
+
+	if value, exists := os.LookupEnv("CHALLENGE_VERIFY_URL"); exists && len(value) != 0 {
+		if _, err := url.ParseRequestURI(value); nil != err {
+			return Config{}, fmt.Errorf("invalid 'CHALLENGE_VERIFY_URL' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "CHALLENGE_VERIFY_URL").WithField("value", value).Debug("using provided environment variable")
+		conf.Challenge.VerifyURL = value
+	}
+
+	if (conf.Challenge.Provider == ChallengeProviderHCaptcha || conf.Challenge.Provider == ChallengeProviderTurnstile) && len(conf.Challenge.Secret) == 0 {
+		return Config{}, fmt.Errorf("'CHALLENGE_SECRET' environment variable is required for '%s' challenge provider", conf.Challenge.Provider)
+	}
+
+	if _, exists := os.LookupEnv("EMAIL_ALLOW_DISPOSABLE"); exists {
+		logger.WithField("variable", "EMAIL_ALLOW_DISPOSABLE").Debug("allowing disposable email domains due to existence of environment variable")
+		conf.EmailPolicy.RejectDisposable = false
+	}
+
+	if value, exists := os.LookupEnv("EMAIL_DISPOSABLE_DOMAINS_FILE"); exists && len(value) != 0 {
+		if _, err := os.Stat(value); nil != err {
+			return Config{}, fmt.Errorf("invalid 'EMAIL_DISPOSABLE_DOMAINS_FILE' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "EMAIL_DISPOSABLE_DOMAINS_FILE").WithField("value", value).Debug("using provided environment variable")
+		conf.EmailPolicy.DisposableDomainsFile = value
+	}
+
+	if value, exists := os.LookupEnv("EMAIL_ALLOWED_DOMAINS"); exists && len(value) != 0 {
+		logger.WithField("variable", "EMAIL_ALLOWED_DOMAINS").WithField("value", value).Debug("using provided environment variable")
+		conf.EmailPolicy.AllowedDomains = parseList(value)
+	}
+
+	if value, exists := os.LookupEnv("EMAIL_DENIED_DOMAINS"); exists && len(value) != 0 {
+		logger.WithField("variable", "EMAIL_DENIED_DOMAINS").WithField("value", value).Debug("using provided environment variable")
+		conf.EmailPolicy.DeniedDomains = parseList(value)
+	}
+
+	if value, exists := os.LookupEnv("USERS_BATCH_GET_LIMIT"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 32)
+		if nil != err {
+			return Config{}, err
+		}
+		if value == 0 {
+			return Config{}, errors.New("'USERS_BATCH_GET_LIMIT' environment variable must be positive")
+		}
+
+		logger.WithField("variable", "USERS_BATCH_GET_LIMIT").WithField("value", value).Debug("using provided environment variable")
+		conf.Users.BatchGetLimit = uint(value)
+	}
+
+	if value, exists := os.LookupEnv("MAILER_DRIVER"); exists && len(value) != 0 {
+		if value != MailerDriverLog && value != MailerDriverSMTP {
+			return Config{}, fmt.Errorf("invalid 'MAILER_DRIVER' environment variable is provided: expected either 'log' or 'smtp', got '%s'", value)
+		}
+
+		logger.WithField("variable", "MAILER_DRIVER").WithField("value", value).Debug("using provided environment variable")
+		conf.Mailer.Driver = value
+	}
+
+	if value, exists := os.LookupEnv("MAILER_FROM"); exists && len(value) != 0 {
+		if _, err := mail.ParseAddress(value); nil != err {
+			return Config{}, fmt.Errorf("invalid 'MAILER_FROM' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "MAILER_FROM").WithField("value", value).Debug("using provided environment variable")
+		conf.Mailer.From = value
+	}
+
+	if value, exists := os.LookupEnv("MAILER_SMTP_HOST"); exists && len(value) != 0 {
+		logger.WithField("variable", "MAILER_SMTP_HOST").WithField("value", value).Debug("using provided environment variable")
+		conf.Mailer.SMTPHost = value
+	}
+
+	if value, exists := os.LookupEnv("MAILER_SMTP_PORT"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 16)
+		if nil != err {
+			return Config{}, err
+		}
+
+		logger.WithField("variable", "MAILER_SMTP_PORT").WithField("value", value).Debug("using provided environment variable")
+		conf.Mailer.SMTPPort = uint(value)
+	}
+
+	if value, exists := os.LookupEnv("MAILER_SMTP_USERNAME"); exists && len(value) != 0 {
+		logger.WithField("variable", "MAILER_SMTP_USERNAME").WithField("value", strings.Repeat("*", len(value))).Debug("using provided environment variable")
+		conf.Mailer.SMTPUsername = value
+	}
+
+	if value, exists := os.LookupEnv("MAILER_SMTP_PASSWORD"); exists && len(value) != 0 {
+		logger.WithField("variable", "MAILER_SMTP_PASSWORD").WithField("value", strings.Repeat("*", len(value))).Debug("using provided environment variable")
+		conf.Mailer.SMTPPassword = value
+	}
+
+	if conf.Mailer.Driver == MailerDriverSMTP && len(conf.Mailer.SMTPHost) == 0 {
+		return Config{}, errors.New("'MAILER_SMTP_HOST' environment variable is required for 'smtp' mailer driver")
+	}
+
+	if value, exists := os.LookupEnv("EMAIL_CHANGE_TOKEN_TTL"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'EMAIL_CHANGE_TOKEN_TTL' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "EMAIL_CHANGE_TOKEN_TTL").WithField("value", value).Debug("using provided environment variable")
+		conf.EmailChange.TokenTTL = value
+	}
+
+	if value, exists := os.LookupEnv("ACCOUNT_DELETION_GRACE_PERIOD"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'ACCOUNT_DELETION_GRACE_PERIOD' environment variable is provided: %s", err)
+		}
+
+		logger.WithField("variable", "ACCOUNT_DELETION_GRACE_PERIOD").WithField("value", value).Debug("using provided environment variable")
+		conf.Deletion.GracePeriod = value
+	}
+
+	if value, exists := os.LookupEnv("ACCOUNT_DELETION_PURGE_INTERVAL"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'ACCOUNT_DELETION_PURGE_INTERVAL' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'ACCOUNT_DELETION_PURGE_INTERVAL' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "ACCOUNT_DELETION_PURGE_INTERVAL").WithField("value", value).Debug("using provided environment variable")
+		conf.Deletion.PurgeInterval = value
+	}
+
+	if value, exists := os.LookupEnv("ACCOUNT_DELETION_PURGE_MODE"); exists && len(value) != 0 {
+		if value != AccountPurgeModeDelete && value != AccountPurgeModeAnonymize {
+			return Config{}, fmt.Errorf("invalid 'ACCOUNT_DELETION_PURGE_MODE' environment variable is provided: expected either 'delete' or 'anonymize', got '%s'", value)
+		}
+
+		logger.WithField("variable", "ACCOUNT_DELETION_PURGE_MODE").WithField("value", value).Debug("using provided environment variable")
+		conf.Deletion.PurgeMode = value
+	}
+
+	if value, exists := os.LookupEnv("USER_LOGINS_RETENTION"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'USER_LOGINS_RETENTION' environment variable is provided: %s", err)
+		}
+		if value < 0 || value.Seconds() > math.MaxInt32 {
+			return Config{}, errors.New("invalid 'USER_LOGINS_RETENTION' environment variable is provided: must be zero or positive and below 68 years")
+		}
+		if value != 0 && value < time.Second {
+			return Config{}, errors.New("invalid 'USER_LOGINS_RETENTION' environment variable is provided: must be at least one second")
+		}
+
+		logger.WithField("variable", "USER_LOGINS_RETENTION").WithField("value", value).Debug("using provided environment variable")
+		conf.Logins.Period = value
+	}
+
+	if _, exists := os.LookupEnv("USER_LOGINS_AGGREGATE"); exists {
+		logger.WithField("variable", "USER_LOGINS_AGGREGATE").Debug("enabling daily user login aggregation due to existence of environment variable")
+		conf.Logins.Aggregate = true
+	}
+
+	// A day is aggregated once it is over, so its records have to outlive it
+	// by at least another day to be aggregated before they expire.
+	if conf.Logins.Aggregate && conf.Logins.Period != 0 && conf.Logins.Period < time.Hour*48 {
+		return Config{}, errors.New("invalid 'USER_LOGINS_RETENTION' environment variable is provided: must be at least 48h when 'USER_LOGINS_AGGREGATE' is set")
+	}
+
+	if value, exists := os.LookupEnv("USER_LOGINS_RETENTION_INTERVAL"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'USER_LOGINS_RETENTION_INTERVAL' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'USER_LOGINS_RETENTION_INTERVAL' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "USER_LOGINS_RETENTION_INTERVAL").WithField("value", value).Debug("using provided environment variable")
+		conf.Logins.Interval = value
+	}
+
+	if value, exists := os.LookupEnv("BOOTSTRAP_ADMIN_USER_ID"); exists && len(value) != 0 {
+		logger.WithField("variable", "BOOTSTRAP_ADMIN_USER_ID").WithField("value", value).Debug("using provided environment variable")
+		conf.Roles.BootstrapAdminUserID = value
+	}
+
+	if value, exists := os.LookupEnv("API_KEY_HASH_SECRET"); exists && len(value) != 0 {
+		logger.WithField("variable", "API_KEY_HASH_SECRET").WithField("value", strings.Repeat("*", len(value))).Debug("using provided environment variable")
+		conf.APIKeys.HashSecret = value
+	} else {
+		logger.WithField("variable", "API_KEY_HASH_SECRET").Warn("environment variable is not provided. hashing api keys with the jwt secret; rotating it will invalidate every api key")
+		conf.APIKeys.HashSecret = conf.Jwt.Secret
+	}
+
+	if value, exists := os.LookupEnv("API_KEY_MAX_TTL"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'API_KEY_MAX_TTL' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'API_KEY_MAX_TTL' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "API_KEY_MAX_TTL").WithField("value", value).Debug("using provided environment variable")
+		conf.APIKeys.MaxTTL = value
+	}
+
+	if value, exists := os.LookupEnv("OUTBOX_PUBLISHER"); exists && len(value) != 0 {
+		switch value {
+		case OutboxPublisherLog, OutboxPublisherFile, OutboxPublisherNATS:
+		default:
+			return Config{}, fmt.Errorf("invalid 'OUTBOX_PUBLISHER' environment variable is provided: expected one of 'log', 'file' or 'nats', got '%s'", value)
+		}
+
+		logger.WithField("variable", "OUTBOX_PUBLISHER").WithField("value", value).Debug("using provided environment variable")
+		conf.Outbox.Publisher = value
+	}
+
+	if value, exists := os.LookupEnv("OUTBOX_RELAY_INTERVAL"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'OUTBOX_RELAY_INTERVAL' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'OUTBOX_RELAY_INTERVAL' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "OUTBOX_RELAY_INTERVAL").WithField("value", value).Debug("using provided environment variable")
+		conf.Outbox.RelayInterval = value
+	}
+
+	if value, exists := os.LookupEnv("OUTBOX_BATCH_SIZE"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 32)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'OUTBOX_BATCH_SIZE' environment variable is provided: %s", err)
+		}
+		if value == 0 {
+			return Config{}, errors.New("invalid 'OUTBOX_BATCH_SIZE' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "OUTBOX_BATCH_SIZE").WithField("value", value).Debug("using provided environment variable")
+		conf.Outbox.BatchSize = uint(value)
+	}
+
+	if value, exists := os.LookupEnv("OUTBOX_FILE_PATH"); exists && len(value) != 0 {
+		logger.WithField("variable", "OUTBOX_FILE_PATH").WithField("value", value).Debug("using provided environment variable")
+		conf.Outbox.FilePath = value
+	}
+
+	if conf.Outbox.Publisher == OutboxPublisherFile && len(conf.Outbox.FilePath) == 0 {
+		return Config{}, errors.New("'OUTBOX_FILE_PATH' environment variable is required for 'file' outbox publisher")
+	}
+
+	if value, exists := os.LookupEnv("OUTBOX_NATS_URL"); exists && len(value) != 0 {
+		logger.WithField("variable", "OUTBOX_NATS_URL").WithField("value", maskURICredentials(value)).Debug("using provided environment variable")
+		conf.Outbox.NATSURL = value
+	}
+
+	if conf.Outbox.Publisher == OutboxPublisherNATS && len(conf.Outbox.NATSURL) == 0 {
+		return Config{}, errors.New("'OUTBOX_NATS_URL' environment variable is required for 'nats' outbox publisher")
+	}
+
+	if value, exists := os.LookupEnv("OUTBOX_NATS_SUBJECT_PREFIX"); exists && len(value) != 0 {
+		logger.WithField("variable", "OUTBOX_NATS_SUBJECT_PREFIX").WithField("value", value).Debug("using provided environment variable")
+		conf.Outbox.NATSSubjectPrefix = value
+	}
+
+	if value, exists := os.LookupEnv("WEBHOOKS_DELIVERY_INTERVAL"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'WEBHOOKS_DELIVERY_INTERVAL' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'WEBHOOKS_DELIVERY_INTERVAL' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "WEBHOOKS_DELIVERY_INTERVAL").WithField("value", value).Debug("using provided environment variable")
+		conf.Webhooks.DeliveryInterval = value
+	}
+
+	if value, exists := os.LookupEnv("WEBHOOKS_TIMEOUT"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'WEBHOOKS_TIMEOUT' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'WEBHOOKS_TIMEOUT' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "WEBHOOKS_TIMEOUT").WithField("value", value).Debug("using provided environment variable")
+		conf.Webhooks.Timeout = value
+	}
+
+	if value, exists := os.LookupEnv("WEBHOOKS_MAX_ATTEMPTS"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 32)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'WEBHOOKS_MAX_ATTEMPTS' environment variable is provided: %s", err)
+		}
+		if value == 0 {
+			return Config{}, errors.New("invalid 'WEBHOOKS_MAX_ATTEMPTS' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "WEBHOOKS_MAX_ATTEMPTS").WithField("value", value).Debug("using provided environment variable")
+		conf.Webhooks.MaxAttempts = uint(value)
+	}
+
+	if value, exists := os.LookupEnv("WEBHOOKS_BACKOFF_BASE"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'WEBHOOKS_BACKOFF_BASE' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'WEBHOOKS_BACKOFF_BASE' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "WEBHOOKS_BACKOFF_BASE").WithField("value", value).Debug("using provided environment variable")
+		conf.Webhooks.BackoffBase = value
+	}
+
+	if value, exists := os.LookupEnv("WEBHOOKS_BACKOFF_MAX"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'WEBHOOKS_BACKOFF_MAX' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'WEBHOOKS_BACKOFF_MAX' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "WEBHOOKS_BACKOFF_MAX").WithField("value", value).Debug("using provided environment variable")
+		conf.Webhooks.BackoffMax = value
+	}
+
+	if conf.Webhooks.BackoffMax < conf.Webhooks.BackoffBase {
+		return Config{}, errors.New("invalid 'WEBHOOKS_BACKOFF_MAX' environment variable is provided: must not be less than 'WEBHOOKS_BACKOFF_BASE'")
+	}
+
+	if value, exists := os.LookupEnv("WEBHOOKS_BATCH_SIZE"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 32)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'WEBHOOKS_BATCH_SIZE' environment variable is provided: %s", err)
+		}
+		if value == 0 {
+			return Config{}, errors.New("invalid 'WEBHOOKS_BATCH_SIZE' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "WEBHOOKS_BATCH_SIZE").WithField("value", value).Debug("using provided environment variable")
+		conf.Webhooks.BatchSize = uint(value)
+	}
+
+	if value, exists := os.LookupEnv("AUTH_CACHE_DRIVER"); exists && len(value) != 0 {
+		switch value {
+		case AuthCacheDriverNone, AuthCacheDriverMemory, AuthCacheDriverRedis:
+		default:
+			return Config{}, fmt.Errorf("invalid 'AUTH_CACHE_DRIVER' environment variable is provided: expected one of 'none', 'memory' or 'redis', got '%s'", value)
+		}
+
+		logger.WithField("variable", "AUTH_CACHE_DRIVER").WithField("value", value).Debug("using provided environment variable")
+		conf.AuthCache.Driver = value
+	}
+
+	if value, exists := os.LookupEnv("AUTH_CACHE_TTL"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'AUTH_CACHE_TTL' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'AUTH_CACHE_TTL' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "AUTH_CACHE_TTL").WithField("value", value).Debug("using provided environment variable")
+		conf.AuthCache.TTL = value
+	}
+
+	if value, exists := os.LookupEnv("AUTH_CACHE_SIZE"); exists && len(value) != 0 {
+		value, err := strconv.ParseUint(value, 10, 32)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'AUTH_CACHE_SIZE' environment variable is provided: %s", err)
+		}
+		if value == 0 {
+			return Config{}, errors.New("invalid 'AUTH_CACHE_SIZE' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "AUTH_CACHE_SIZE").WithField("value", value).Debug("using provided environment variable")
+		conf.AuthCache.Size = uint(value)
+	}
+
+	if value, exists := os.LookupEnv("AUTH_CACHE_REDIS_URL"); exists && len(value) != 0 {
+		logger.WithField("variable", "AUTH_CACHE_REDIS_URL").WithField("value", maskURICredentials(value)).Debug("using provided environment variable")
+		conf.AuthCache.RedisURL = value
+	}
+
+	if conf.AuthCache.Driver == AuthCacheDriverRedis && len(conf.AuthCache.RedisURL) == 0 {
+		return Config{}, errors.New("'AUTH_CACHE_REDIS_URL' environment variable is required for 'redis' auth cache driver")
+	}
+
+	if value, exists := os.LookupEnv("AUTH_CACHE_REDIS_KEY_PREFIX"); exists && len(value) != 0 {
+		logger.WithField("variable", "AUTH_CACHE_REDIS_KEY_PREFIX").WithField("value", value).Debug("using provided environment variable")
+		conf.AuthCache.RedisKeyPrefix = value
+	}
+
+	if value, exists := os.LookupEnv("AUTH_CACHE_STATS_INTERVAL"); exists && len(value) != 0 {
+		value, err := time.ParseDuration(value)
+		if nil != err {
+			return Config{}, fmt.Errorf("invalid 'AUTH_CACHE_STATS_INTERVAL' environment variable is provided: %s", err)
+		}
+		if value <= 0 {
+			return Config{}, errors.New("invalid 'AUTH_CACHE_STATS_INTERVAL' environment variable is provided: must be positive")
+		}
+
+		logger.WithField("variable", "AUTH_CACHE_STATS_INTERVAL").WithField("value", value).Debug("using provided environment variable")
+		conf.AuthCache.StatsInterval = value
+	}
+
 	if value, exists := os.LookupEnv("SENTRY_DSN"); exists && len(value) != 0 {
 		dsn, err := sentry.NewDsn(value)
 		if nil != err {
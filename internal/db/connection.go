@@ -2,13 +2,17 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
+	"strconv"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 
 	"github.com/game-sales-analytics/users-service/internal/config"
 	"github.com/game-sales-analytics/users-service/internal/db/repository"
@@ -27,29 +31,19 @@ func NewConnectContext(ctx context.Context, span *sentry.Span) ConnectContext {
 }
 
 func Connect(ctx ConnectContext, logger *logrus.Entry, cfg *config.DatabaseConfig) (*DB, error) {
-	addr := fmt.Sprintf("mongodb://%s:%d", cfg.Host, cfg.Port)
-	logger.WithField("address", addr).Debug("connecting database using configured address")
-
-	clientOptions := options.
-		Client().
-		ApplyURI(addr).
-		SetConnectTimeout(time.Second * 10).
-		SetServerSelectionTimeout(time.Second * 10)
+	child := ctx.span.StartChild("build-database-client-options")
+	child.Status = sentry.SpanStatusOK
+	clientOptions, err := newClientOptions(logger, cfg)
+	if nil != err {
+		defer child.Finish()
 
-	if cfg.UseAuth {
-		logger.Debug("using provided credentials for database connection authentication")
-		clientOptions = clientOptions.
-			SetAuth(options.Credential{
-				PasswordSet:   true,
-				AuthMechanism: "SCRAM-SHA-256",
-				AuthSource:    cfg.Name,
-				Password:      cfg.Password,
-				Username:      cfg.Username,
-			})
+		child.Status = sentry.SpanStatusInvalidArgument
+		return nil, err
 	}
+	child.Finish()
 
 	logger.Trace("connecting database")
-	child := ctx.span.StartChild("create-database-connection")
+	child = ctx.span.StartChild("create-database-connection")
 	child.Status = sentry.SpanStatusOK
 	client, err := mongo.Connect(ctx, clientOptions)
 	if nil != err {
@@ -71,21 +65,177 @@ func Connect(ctx ConnectContext, logger *logrus.Entry, cfg *config.DatabaseConfi
 	}
 	child.Finish()
 
+	child = ctx.span.StartChild("check-database-transactions-support")
+	child.Status = sentry.SpanStatusOK
+	transactions, err := supportsTransactions(ctx, client)
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		return nil, err
+	}
+	child.Finish()
+	if !transactions {
+		if !cfg.AllowNoTransactions {
+			_ = client.Disconnect(ctx)
+			return nil, errors.New("database deployment is a standalone server which does not support transactions. use a replica set, or set 'DATABASE_ALLOW_NO_TRANSACTIONS' for local development")
+		}
+
+		logger.Warn("database deployment is a standalone server which does not support transactions. writes that belong together, like a user and its outbox event, are not atomic")
+	}
+
 	logger.WithField("database", cfg.Name).Debug("using configured database name")
 	db := client.Database(cfg.Name)
 	return &DB{
-		client: client,
-		logger: logger,
+		client:   client,
+		database: db,
+		logger:   logger,
 		Repo: repository.New(
 			logger.WithField("srv", "repository"),
+			client,
 			repository.Collections{
-				Users:      db.Collection(UsersCollectionName),
-				UserLogins: db.Collection(UserLoginsCollectionName),
+				Users:       db.Collection(UsersCollectionName),
+				UserLogins:  db.Collection(UserLoginsCollectionName),
+				AuditEvents: db.Collection(AuditEventsCollectionName),
+				APIKeys:     db.Collection(APIKeysCollectionName),
+				Outbox:      db.Collection(OutboxCollectionName),
+
+				RoleBootstraps: db.Collection(RoleBootstrapsCollectionName),
+
+				UserLoginDailyStats: db.Collection(UserLoginDailyStatsCollectionName),
+
+				Webhooks:          db.Collection(WebhooksCollectionName),
+				WebhookDeliveries: db.Collection(WebhookDeliveriesCollectionName),
 			},
+			transactions,
 		),
 	}, nil
 }
 
+// supportsTransactions reports whether the deployment is a replica set or a
+// sharded cluster. Standalone servers reject transactions.
+func supportsTransactions(ctx context.Context, client *mongo.Client) (bool, error) {
+	var reply struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&reply); nil != err {
+		return false, err
+	}
+
+	return len(reply.SetName) != 0 || reply.Msg == "isdbgrid", nil
+}
+
+// newClientOptions applies the configured options on top of the connection
+// URI, so options set in the configuration take precedence over the URI.
+func newClientOptions(logger *logrus.Entry, cfg *config.DatabaseConfig) (*options.ClientOptions, error) {
+	uri := cfg.URI
+	if len(uri) == 0 {
+		uri = fmt.Sprintf("mongodb://%s:%d", cfg.Host, cfg.Port)
+	}
+
+	clientOptions := options.
+		Client().
+		ApplyURI(uri).
+		SetConnectTimeout(cfg.ConnectTimeout).
+		SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+	if err := clientOptions.Validate(); nil != err {
+		return nil, fmt.Errorf("invalid database connection URI: %s", err)
+	}
+	logger.WithField("hosts", clientOptions.Hosts).Debug("connecting database using configured address")
+
+	if cfg.UseAuth {
+		logger.WithField("mechanism", cfg.AuthMechanism).Debug("using provided credentials for database connection authentication")
+		clientOptions.SetAuth(newCredential(cfg))
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(&cfg.TLS)
+		if nil != err {
+			return nil, err
+		}
+
+		logger.Debug("using tls for database connection")
+		clientOptions.SetTLSConfig(tlsConfig)
+	}
+
+	if len(cfg.ReplicaSet) != 0 {
+		clientOptions.SetReplicaSet(cfg.ReplicaSet)
+	}
+
+	if len(cfg.ReadPreference) != 0 {
+		mode, err := readpref.ModeFromString(cfg.ReadPreference)
+		if nil != err {
+			return nil, err
+		}
+		readPreference, err := readpref.New(mode)
+		if nil != err {
+			return nil, err
+		}
+
+		clientOptions.SetReadPreference(readPreference)
+	}
+
+	if len(cfg.WriteConcern) != 0 {
+		if cfg.WriteConcern == "majority" {
+			clientOptions.SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+		} else {
+			members, err := strconv.Atoi(cfg.WriteConcern)
+			if nil != err {
+				return nil, fmt.Errorf("invalid database write concern '%s'", cfg.WriteConcern)
+			}
+
+			clientOptions.SetWriteConcern(writeconcern.New(writeconcern.W(members)))
+		}
+	}
+
+	if cfg.MaxPoolSize != 0 {
+		clientOptions.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
+	if cfg.MinPoolSize != 0 {
+		clientOptions.SetMinPoolSize(cfg.MinPoolSize)
+	}
+	if cfg.MaxConnIdleTime != 0 {
+		clientOptions.SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+	}
+	if cfg.SocketTimeout != 0 {
+		clientOptions.SetSocketTimeout(cfg.SocketTimeout)
+	}
+
+	return clientOptions, nil
+}
+
+func newCredential(cfg *config.DatabaseConfig) options.Credential {
+	credential := options.Credential{
+		AuthMechanism: cfg.AuthMechanism,
+		AuthSource:    cfg.AuthSource,
+		Username:      cfg.Username,
+	}
+
+	switch cfg.AuthMechanism {
+	case config.DatabaseAuthMechanismX509:
+		// The user is taken from the client certificate subject when no
+		// username is configured.
+		if len(credential.AuthSource) == 0 {
+			credential.AuthSource = "$external"
+		}
+	case config.DatabaseAuthMechanismPlain:
+		if len(credential.AuthSource) == 0 {
+			credential.AuthSource = "$external"
+		}
+		credential.Password = cfg.Password
+		credential.PasswordSet = true
+	default:
+		if len(credential.AuthSource) == 0 {
+			credential.AuthSource = cfg.Name
+		}
+		credential.Password = cfg.Password
+		credential.PasswordSet = true
+	}
+
+	return credential
+}
+
 func (db *DB) Disconnect() error {
 	db.logger.Trace("closing database connection")
 	return db.client.Disconnect(context.Background())
@@ -4,3 +4,10 @@ type CollectionName = string
 
 const UsersCollectionName CollectionName = "users"
 const UserLoginsCollectionName CollectionName = "user_logins"
+const AuditEventsCollectionName CollectionName = "audit_events"
+const APIKeysCollectionName CollectionName = "api_keys"
+const OutboxCollectionName CollectionName = "outbox"
+const WebhooksCollectionName CollectionName = "webhooks"
+const WebhookDeliveriesCollectionName CollectionName = "webhook_deliveries"
+const UserLoginDailyStatsCollectionName CollectionName = "user_login_daily_stats"
+const RoleBootstrapsCollectionName CollectionName = "role_bootstraps"
@@ -4,11 +4,18 @@ import (
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/migrate"
 	"github.com/game-sales-analytics/users-service/internal/db/repository"
 )
 
 type DB struct {
-	client *mongo.Client
-	logger *logrus.Entry
-	Repo   repository.Repo
+	client   *mongo.Client
+	database *mongo.Database
+	logger   *logrus.Entry
+	Repo     repository.Repo
+}
+
+func (db *DB) Migrator(logger *logrus.Entry, cfg *config.MigrationsConfig) migrate.Migrator {
+	return migrate.New(logger, db.database, migrate.Migrations(), cfg.LockTimeout)
 }
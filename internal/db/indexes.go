@@ -0,0 +1,402 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+// legacyUsersIndexes lists indexes that were replaced. They are dropped
+// before their replacements are created, some of which use the same keys.
+var legacyUsersIndexes = []string{
+	"normalized_email",
+	"names_text",
+}
+
+func usersIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id_unique").SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "normalized_email", Value: 1}},
+			Options: options.Index().
+				SetName(repository.UsersEmailIndex).
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"normalized_email": bson.M{"$type": "string"}}),
+		},
+		{
+			Keys:    bson.D{{Key: "registered_at", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetName("registered_at_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "registered_at", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetName("status_registered_at_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "status_expires_at", Value: 1}},
+			Options: options.Index().SetName("status_status_expires_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "roles", Value: 1}},
+			Options: options.Index().SetName("roles"),
+		},
+		{
+			Keys:    bson.D{{Key: "first_name", Value: 1}},
+			Options: options.Index().SetName("first_name"),
+		},
+		{
+			Keys:    bson.D{{Key: "last_name", Value: 1}},
+			Options: options.Index().SetName("last_name"),
+		},
+	}
+}
+
+func userLoginsIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user.id", Value: 1}},
+			Options: options.Index().SetName("user_id"),
+		},
+	}
+}
+
+// userLoginsRetentionIndex is the TTL index expiring user logins. Unlike the
+// other TTL indexes its expiry is configurable, so it is not part of
+// userLoginsIndexes and is maintained by EnsureUserLoginsRetention.
+const userLoginsRetentionIndex = "logged_in_at_ttl"
+
+func userLoginDailyStatsIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "day", Value: 1}},
+			Options: options.Index().SetName("day_unique").SetUnique(true),
+		},
+	}
+}
+
+func apiKeysIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "prefix", Value: 1}},
+			Options: options.Index().SetName("prefix").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("created_at"),
+		},
+	}
+}
+
+// deliveredOutboxEventsRetention is how long published events are kept
+// around for troubleshooting before MongoDB removes them.
+const deliveredOutboxEventsRetention = time.Hour * 24 * 7
+
+func outboxIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "occurred_at", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetName("status_occurred_at_id"),
+		},
+		{
+			Keys: bson.D{{Key: "delivered_at", Value: 1}},
+			Options: options.Index().
+				SetName("delivered_at_ttl").
+				SetExpireAfterSeconds(int32(deliveredOutboxEventsRetention.Seconds())),
+		},
+	}
+}
+
+func roleBootstrapsIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "role", Value: 1}},
+			Options: options.Index().SetName("role_unique").SetUnique(true),
+		},
+	}
+}
+
+func webhooksIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("created_at"),
+		},
+	}
+}
+
+// deliveredWebhookDeliveriesRetention matches the outbox retention.
+// Dead-lettered deliveries have no delivered_at and are kept until retried or
+// their subscription is deleted.
+const deliveredWebhookDeliveriesRetention = deliveredOutboxEventsRetention
+
+func webhookDeliveriesIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "subscription_id", Value: 1}, {Key: "event_id", Value: 1}},
+			Options: options.Index().SetName("subscription_id_event_id_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+			Options: options.Index().SetName("status_next_attempt_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}},
+			Options: options.Index().SetName("created_at_id"),
+		},
+		{
+			Keys: bson.D{{Key: "delivered_at", Value: 1}},
+			Options: options.Index().
+				SetName("delivered_at_ttl").
+				SetExpireAfterSeconds(int32(deliveredWebhookDeliveriesRetention.Seconds())),
+		},
+	}
+}
+
+func isIndexNotFoundError(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		// NamespaceNotFound is returned on a fresh database, before the
+		// collection exists.
+		return cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound"
+	}
+
+	return false
+}
+
+func (db *DB) EnsureIndexes(ctx ConnectContext) error {
+	db.logger.Trace("dropping legacy users collection indexes")
+	child := ctx.span.StartChild("drop-legacy-users-indexes")
+	child.Status = sentry.SpanStatusOK
+	for _, name := range legacyUsersIndexes {
+		if _, err := db.database.Collection(UsersCollectionName).Indexes().DropOne(ctx, name); nil != err && !isIndexNotFoundError(err) {
+			defer child.Finish()
+
+			child.Status = sentry.SpanStatusInternalError
+			db.logger.WithError(err).WithField("err_code", "E_DROP_LEGACY_USERS_INDEX").WithField("index", name).Error("failed dropping legacy users collection index")
+			return err
+		}
+	}
+	child.Finish()
+
+	db.logger.Trace("ensuring users collection indexes")
+	child = ctx.span.StartChild("ensure-users-indexes")
+	child.Status = sentry.SpanStatusOK
+	if _, err := db.database.Collection(UsersCollectionName).Indexes().CreateMany(ctx, usersIndexes()); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_ENSURE_USERS_INDEXES").Error("failed ensuring users collection indexes")
+		return err
+	}
+	child.Finish()
+
+	db.logger.Trace("ensuring user logins collection indexes")
+	child = ctx.span.StartChild("ensure-user-logins-indexes")
+	child.Status = sentry.SpanStatusOK
+	if _, err := db.database.Collection(UserLoginsCollectionName).Indexes().CreateMany(ctx, userLoginsIndexes()); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_ENSURE_USER_LOGINS_INDEXES").Error("failed ensuring user logins collection indexes")
+		return err
+	}
+	child.Finish()
+
+	db.logger.Trace("ensuring user login daily stats collection indexes")
+	child = ctx.span.StartChild("ensure-user-login-daily-stats-indexes")
+	child.Status = sentry.SpanStatusOK
+	if _, err := db.database.Collection(UserLoginDailyStatsCollectionName).Indexes().CreateMany(ctx, userLoginDailyStatsIndexes()); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_ENSURE_USER_LOGIN_DAILY_STATS_INDEXES").Error("failed ensuring user login daily stats collection indexes")
+		return err
+	}
+	child.Finish()
+
+	db.logger.Trace("ensuring api keys collection indexes")
+	child = ctx.span.StartChild("ensure-api-keys-indexes")
+	child.Status = sentry.SpanStatusOK
+	if _, err := db.database.Collection(APIKeysCollectionName).Indexes().CreateMany(ctx, apiKeysIndexes()); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_ENSURE_API_KEYS_INDEXES").Error("failed ensuring api keys collection indexes")
+		return err
+	}
+	child.Finish()
+
+	db.logger.Trace("ensuring outbox collection indexes")
+	child = ctx.span.StartChild("ensure-outbox-indexes")
+	child.Status = sentry.SpanStatusOK
+	if _, err := db.database.Collection(OutboxCollectionName).Indexes().CreateMany(ctx, outboxIndexes()); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_ENSURE_OUTBOX_INDEXES").Error("failed ensuring outbox collection indexes")
+		return err
+	}
+	child.Finish()
+
+	db.logger.Trace("ensuring role bootstraps collection indexes")
+	child = ctx.span.StartChild("ensure-role-bootstraps-indexes")
+	child.Status = sentry.SpanStatusOK
+	if _, err := db.database.Collection(RoleBootstrapsCollectionName).Indexes().CreateMany(ctx, roleBootstrapsIndexes()); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_ENSURE_ROLE_BOOTSTRAPS_INDEXES").Error("failed ensuring role bootstraps collection indexes")
+		return err
+	}
+	child.Finish()
+
+	db.logger.Trace("ensuring webhooks collection indexes")
+	child = ctx.span.StartChild("ensure-webhooks-indexes")
+	child.Status = sentry.SpanStatusOK
+	if _, err := db.database.Collection(WebhooksCollectionName).Indexes().CreateMany(ctx, webhooksIndexes()); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_ENSURE_WEBHOOKS_INDEXES").Error("failed ensuring webhooks collection indexes")
+		return err
+	}
+	child.Finish()
+
+	db.logger.Trace("ensuring webhook deliveries collection indexes")
+	child = ctx.span.StartChild("ensure-webhook-deliveries-indexes")
+	child.Status = sentry.SpanStatusOK
+	if _, err := db.database.Collection(WebhookDeliveriesCollectionName).Indexes().CreateMany(ctx, webhookDeliveriesIndexes()); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_ENSURE_WEBHOOK_DELIVERIES_INDEXES").Error("failed ensuring webhook deliveries collection indexes")
+		return err
+	}
+	child.Finish()
+
+	return nil
+}
+
+// EnsureUserLoginsRetention makes the TTL index on user logins match the
+// configured retention. An existing index is updated in place, since
+// recreating it would leave logins unexpired meanwhile. A zero retention
+// drops the index and keeps logins forever.
+func (db *DB) EnsureUserLoginsRetention(ctx ConnectContext, retention time.Duration) error {
+	indexes := db.database.Collection(UserLoginsCollectionName).Indexes()
+
+	db.logger.Trace("retrieving user logins collection indexes")
+	child := ctx.span.StartChild("list-user-logins-indexes")
+	child.Status = sentry.SpanStatusOK
+	specs, err := indexes.ListSpecifications(ctx)
+	if nil != err && !isIndexNotFoundError(err) {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_LIST_USER_LOGINS_INDEXES").Error("failed retrieving user logins collection indexes")
+		return err
+	}
+	child.Finish()
+
+	var current *mongo.IndexSpecification
+	for _, spec := range specs {
+		if spec.Name == userLoginsRetentionIndex {
+			current = spec
+		}
+	}
+
+	if retention == 0 {
+		if nil == current {
+			return nil
+		}
+
+		db.logger.Info("dropping user logins retention index as retention is disabled")
+		child = ctx.span.StartChild("drop-user-logins-retention-index")
+		child.Status = sentry.SpanStatusOK
+		if _, err := indexes.DropOne(ctx, userLoginsRetentionIndex); nil != err && !isIndexNotFoundError(err) {
+			defer child.Finish()
+
+			child.Status = sentry.SpanStatusInternalError
+			db.logger.WithError(err).WithField("err_code", "E_DROP_USER_LOGINS_RETENTION_INDEX").Error("failed dropping user logins retention index")
+			return err
+		}
+		child.Finish()
+
+		return nil
+	}
+
+	expireAfterSeconds := int32(retention.Seconds())
+	if nil == current {
+		db.logger.WithField("retention", retention).Debug("creating user logins retention index")
+		child = ctx.span.StartChild("create-user-logins-retention-index")
+		child.Status = sentry.SpanStatusOK
+		model := mongo.IndexModel{
+			Keys: bson.D{{Key: "logged_in_at", Value: 1}},
+			Options: options.Index().
+				SetName(userLoginsRetentionIndex).
+				SetExpireAfterSeconds(expireAfterSeconds),
+		}
+		if _, err := indexes.CreateOne(ctx, model); nil != err {
+			defer child.Finish()
+
+			child.Status = sentry.SpanStatusInternalError
+			db.logger.WithError(err).WithField("err_code", "E_CREATE_USER_LOGINS_RETENTION_INDEX").Error("failed creating user logins retention index")
+			return err
+		}
+		child.Finish()
+
+		return nil
+	}
+
+	if nil != current.ExpireAfterSeconds && *current.ExpireAfterSeconds == expireAfterSeconds {
+		return nil
+	}
+
+	log := db.logger.WithField("retention", retention)
+	if nil != current.ExpireAfterSeconds {
+		log = log.WithField("previous_retention", time.Duration(*current.ExpireAfterSeconds)*time.Second)
+	}
+	log.Info("updating user logins retention index")
+	child = ctx.span.StartChild("update-user-logins-retention-index")
+	child.Status = sentry.SpanStatusOK
+	command := bson.D{
+		{Key: "collMod", Value: UserLoginsCollectionName},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: userLoginsRetentionIndex},
+			{Key: "expireAfterSeconds", Value: expireAfterSeconds},
+		}},
+	}
+	if err := db.database.RunCommand(ctx, command).Err(); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		db.logger.WithError(err).WithField("err_code", "E_UPDATE_USER_LOGINS_RETENTION_INDEX").Error("failed updating user logins retention index")
+		return err
+	}
+	child.Finish()
+
+	return nil
+}
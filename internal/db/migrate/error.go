@@ -0,0 +1,14 @@
+package migrate
+
+import (
+	"errors"
+)
+
+var (
+	ErrLocked            = errors.New("another process holds the migration lock")
+	ErrLockLost          = errors.New("migration lock was taken over by another process")
+	ErrIrreversible      = errors.New("migration cannot be reverted")
+	ErrUnknownMigration  = errors.New("applied migration is unknown to this build")
+	ErrInvalidMigrations = errors.New("migrations must have unique versions in ascending order")
+	ErrInvalidStepCount  = errors.New("step count must be positive")
+)
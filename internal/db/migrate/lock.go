@@ -0,0 +1,187 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/xid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	lockID = "schema_migrations"
+	// lockLease bounds how long a crashed migrator blocks the others. The
+	// holder renews it while migrating, so it need not outlast a migration.
+	lockLease         = time.Minute
+	lockRenewInterval = lockLease / 3
+	lockPollInterval  = time.Second
+)
+
+func lockOwner() string {
+	hostname, err := os.Hostname()
+	if nil != err {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s/%d/%s", hostname, os.Getpid(), xid.New().String())
+}
+
+// tryLock takes the lock when nobody holds it or the holder's lease ran out.
+// A held lock makes the upsert collide with the existing document.
+func (m migrator) tryLock(ctx Context, owner string) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":        lockID,
+		"expires_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"owner":       owner,
+			"acquired_at": now,
+			"expires_at":  now.Add(lockLease),
+		},
+	}
+	opts := options.Update().SetUpsert(true)
+
+	if _, err := m.database.Collection(lockCollectionName).UpdateOne(ctx, filter, update, opts); nil != err {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// lock waits up to the configured timeout for the lock, so replicas started
+// together queue up behind the one that migrates.
+func (m migrator) lock(ctx Context) (string, error) {
+	owner := lockOwner()
+	deadline := time.Now().Add(m.lockTimeout)
+
+	span := ctx.span.StartChild("acquire-migration-lock")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	for {
+		locked, err := m.tryLock(ctx, owner)
+		if nil != err {
+			span.Status = sentry.SpanStatusInternalError
+			m.logger.WithError(err).WithField("err_code", "E_ACQUIRE_MIGRATION_LOCK").Error("failed acquiring migration lock")
+			return "", err
+		}
+		if locked {
+			m.logger.WithField("owner", owner).Debug("acquired migration lock")
+			return owner, nil
+		}
+		if !time.Now().Before(deadline) {
+			span.Status = sentry.SpanStatusDeadlineExceeded
+			return "", ErrLocked
+		}
+
+		m.logger.Debug("migration lock is held by another process. waiting")
+		select {
+		case <-ctx.Done():
+			span.Status = sentry.SpanStatusCanceled
+			return "", ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// renewLock extends the lease of owner. It reports false once the lease ran
+// out and another process took the lock.
+func (m migrator) renewLock(ctx context.Context, owner string) (bool, error) {
+	filter := bson.M{
+		"_id":   lockID,
+		"owner": owner,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"expires_at": time.Now().Add(lockLease),
+		},
+	}
+
+	result, err := m.database.Collection(lockCollectionName).UpdateOne(ctx, filter, update)
+	if nil != err {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// keepLock renews the lease of owner in the background until the returned
+// function is called, so migrations may run longer than one lease.
+func (m migrator) keepLock(ctx Context, owner string) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(lockRenewInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			held, err := m.renewLock(ctx, owner)
+			if nil != err {
+				m.logger.WithError(err).WithField("err_code", "E_RENEW_MIGRATION_LOCK").Warn("failed renewing migration lock. retrying")
+				continue
+			}
+			if !held {
+				m.logger.WithField("owner", owner).WithField("err_code", "E_MIGRATION_LOCK_LOST").Error("migration lock was taken over by another process")
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// checkLock makes sure owner still holds the lock before a migration is
+// recorded, extending its lease on the way.
+func (m migrator) checkLock(ctx Context, owner string) error {
+	held, err := m.renewLock(ctx, owner)
+	if nil != err {
+		return err
+	}
+	if !held {
+		return ErrLockLost
+	}
+
+	return nil
+}
+
+func (m migrator) unlock(ctx Context, owner string) {
+	span := ctx.span.StartChild("release-migration-lock")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	filter := bson.M{
+		"_id":   lockID,
+		"owner": owner,
+	}
+	if _, err := m.database.Collection(lockCollectionName).DeleteOne(ctx, filter); nil != err {
+		span.Status = sentry.SpanStatusInternalError
+		m.logger.WithError(err).WithField("err_code", "E_RELEASE_MIGRATION_LOCK").Warn("failed releasing migration lock. it expires with its lease")
+		return
+	}
+	m.logger.WithField("owner", owner).Debug("released migration lock")
+}
@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	migrationsCollectionName = "schema_migrations"
+	lockCollectionName       = "schema_migrations_lock"
+)
+
+// Migration is one versioned change to the database. Versions are applied in
+// ascending order and must never be reused or renumbered once released. A
+// migration without Down cannot be reverted.
+type Migration struct {
+	Version     uint
+	Description string
+	Up          func(ctx context.Context, database *mongo.Database) error
+	Down        func(ctx context.Context, database *mongo.Database) error
+}
+
+type Status struct {
+	Version     uint
+	Description string
+	Applied     bool
+	AppliedAt   *time.Time
+	// Unknown marks a migration recorded in the database but missing from
+	// this build, usually after rolling back to an older release.
+	Unknown bool
+}
+
+type Migrator interface {
+	// Up applies every pending migration and returns the applied ones.
+	Up(ctx Context) ([]Migration, error)
+	// Down reverts the given number of most recently applied migrations and
+	// returns the reverted ones.
+	Down(ctx Context, steps uint) ([]Migration, error)
+	Status(ctx Context) ([]Status, error)
+}
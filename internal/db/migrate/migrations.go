@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migrations lists every migration in version order. Migrations are frozen
+// once released: they spell out collection and field names instead of using
+// constants from other packages, so later renames do not change them.
+func Migrations() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "backfill status of users registered before account statuses",
+			Up: func(ctx context.Context, database *mongo.Database) error {
+				_, err := database.Collection("users").UpdateMany(
+					ctx,
+					bson.M{"status": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"status": "active"}},
+				)
+				return err
+			},
+			Down: noop,
+		},
+		{
+			Version:     2,
+			Description: "backfill roles of users registered before roles",
+			Up: func(ctx context.Context, database *mongo.Database) error {
+				_, err := database.Collection("users").UpdateMany(
+					ctx,
+					bson.M{"roles": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"roles": bson.A{}}},
+				)
+				return err
+			},
+			Down: noop,
+		},
+		{
+			Version:     3,
+			Description: "backfill version and updated_at of users registered before profile updates",
+			Up: func(ctx context.Context, database *mongo.Database) error {
+				users := database.Collection("users")
+				if _, err := users.UpdateMany(
+					ctx,
+					bson.M{"version": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"version": int64(1)}},
+				); nil != err {
+					return err
+				}
+
+				_, err := users.UpdateMany(
+					ctx,
+					bson.M{"updated_at": bson.M{"$exists": false}},
+					mongo.Pipeline{
+						bson.D{{Key: "$set", Value: bson.M{"updated_at": "$registered_at"}}},
+					},
+				)
+				return err
+			},
+			Down: noop,
+		},
+	}
+}
+
+// noop reverts backfills. They only write the values readers already assume
+// for missing fields, so leaving them in place is indistinguishable from
+// removing them.
+func noop(context.Context, *mongo.Database) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type migrator struct {
+	logger      *logrus.Entry
+	database    *mongo.Database
+	migrations  []Migration
+	lockTimeout time.Duration
+}
+
+func New(logger *logrus.Entry, database *mongo.Database, migrations []Migration, lockTimeout time.Duration) Migrator {
+	return migrator{
+		logger,
+		database,
+		migrations,
+		lockTimeout,
+	}
+}
@@ -0,0 +1,324 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// postgresLockKey identifies the advisory lock every instance takes before
+// changing the schema. The value is arbitrary but must never change.
+const postgresLockKey int64 = 0x75736572736d6967
+
+type postgresMigrator struct {
+	logger      *logrus.Entry
+	pool        *pgxpool.Pool
+	migrations  []PostgresMigration
+	lockTimeout time.Duration
+}
+
+// NewPostgres creates a migrator for the PostgreSQL storage backend. The
+// migrations it reports carry only their version and description.
+func NewPostgres(logger *logrus.Entry, pool *pgxpool.Pool, migrations []PostgresMigration, lockTimeout time.Duration) Migrator {
+	return postgresMigrator{
+		logger,
+		pool,
+		migrations,
+		lockTimeout,
+	}
+}
+
+func (m postgresMigrator) described() []Migration {
+	out := make([]Migration, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		out = append(out, Migration{
+			Version:     migration.Version,
+			Description: migration.Description,
+		})
+	}
+
+	return out
+}
+
+// withLock runs fn on a single connection holding the migration lock. The
+// advisory lock belongs to the session, so it is released even when the
+// process dies halfway.
+func (m postgresMigrator) withLock(ctx Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if nil != err {
+		return err
+	}
+	defer conn.Release()
+
+	span := ctx.span.StartChild("acquire-migration-lock")
+	span.Status = sentry.SpanStatusOK
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		var locked bool
+		if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", postgresLockKey).Scan(&locked); nil != err {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInternalError
+			m.logger.WithError(err).WithField("err_code", "E_ACQUIRE_MIGRATION_LOCK").Error("failed acquiring migration lock")
+			return err
+		}
+		if locked {
+			m.logger.Debug("acquired migration lock")
+			break
+		}
+		if !time.Now().Before(deadline) {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusDeadlineExceeded
+			return ErrLocked
+		}
+
+		m.logger.Debug("migration lock is held by another process. waiting")
+		select {
+		case <-ctx.Done():
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusCanceled
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+	span.Finish()
+
+	defer func() {
+		span := ctx.span.StartChild("release-migration-lock")
+		span.Status = sentry.SpanStatusOK
+		defer span.Finish()
+
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", postgresLockKey); nil != err {
+			span.Status = sentry.SpanStatusInternalError
+			m.logger.WithError(err).WithField("err_code", "E_RELEASE_MIGRATION_LOCK").Warn("failed releasing migration lock. it is released when the connection closes")
+			return
+		}
+		m.logger.Debug("released migration lock")
+	}()
+
+	return fn(conn)
+}
+
+func (m postgresMigrator) ensureMigrationsTable(ctx Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version     bigint      PRIMARY KEY,
+		description text        NOT NULL,
+		applied_at  timestamptz NOT NULL
+	)`)
+	return err
+}
+
+// applied returns the recorded migrations sorted by ascending version. A
+// database that was never migrated has no migrations table yet.
+func (m postgresMigrator) applied(ctx context.Context, querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}) ([]appliedMigration, error) {
+	var exists bool
+	if err := querier.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); nil != err {
+		return nil, err
+	}
+	if !exists {
+		return []appliedMigration{}, nil
+	}
+
+	rows, err := querier.Query(ctx, "SELECT version, description, applied_at FROM schema_migrations ORDER BY version")
+	if nil != err {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []appliedMigration{}
+	for rows.Next() {
+		var record appliedMigration
+		var version int64
+		if err := rows.Scan(&version, &record.Description, &record.AppliedAt); nil != err {
+			return nil, err
+		}
+		record.Version = uint(version)
+		out = append(out, record)
+	}
+
+	return out, rows.Err()
+}
+
+func (m postgresMigrator) queryApplied(ctx Context, querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}) ([]appliedMigration, error) {
+	span := ctx.span.StartChild("query-applied-migrations")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	applied, err := m.applied(ctx, querier)
+	if nil != err {
+		span.Status = sentry.SpanStatusInternalError
+		m.logger.WithError(err).WithField("err_code", "E_RETRIEVE_APPLIED_MIGRATIONS").Error("failed retrieving applied migrations")
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+func (m postgresMigrator) Status(ctx Context) ([]Status, error) {
+	if err := checkMigrations(m.described()); nil != err {
+		return nil, err
+	}
+
+	applied, err := m.queryApplied(ctx, m.pool)
+	if nil != err {
+		return nil, err
+	}
+
+	return buildStatuses(m.described(), applied), nil
+}
+
+// Up applies every migration together with its record in one transaction, so
+// a failed migration leaves no trace behind.
+func (m postgresMigrator) Up(ctx Context) ([]Migration, error) {
+	if err := checkMigrations(m.described()); nil != err {
+		return nil, err
+	}
+
+	out := []Migration{}
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		span := ctx.span.StartChild("ensure-migrations-table")
+		span.Status = sentry.SpanStatusOK
+		if err := m.ensureMigrationsTable(ctx, conn); nil != err {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInternalError
+			m.logger.WithError(err).WithField("err_code", "E_ENSURE_MIGRATIONS_TABLE").Error("failed ensuring migrations table")
+			return err
+		}
+		span.Finish()
+
+		applied, err := m.queryApplied(ctx, conn)
+		if nil != err {
+			return err
+		}
+
+		done := map[uint]struct{}{}
+		for _, record := range applied {
+			done[record.Version] = struct{}{}
+		}
+		if len(applied) != 0 && len(m.migrations) != 0 && applied[len(applied)-1].Version > m.migrations[len(m.migrations)-1].Version {
+			m.logger.WithField("version", applied[len(applied)-1].Version).Warn("database has migrations applied that this build does not know about")
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			log := m.logger.WithField("version", migration.Version).WithField("description", migration.Description)
+			log.Info("applying migration")
+
+			span = ctx.span.StartChild(fmt.Sprintf("apply-migration-%d", migration.Version))
+			span.Status = sentry.SpanStatusOK
+			err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); nil != err {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, description, applied_at) VALUES ($1, $2, $3)", int64(migration.Version), migration.Description, time.Now())
+				return err
+			})
+			if nil != err {
+				defer span.Finish()
+
+				span.Status = sentry.SpanStatusInternalError
+				log.WithError(err).WithField("err_code", "E_APPLY_MIGRATION").Error("failed applying migration")
+				return err
+			}
+			span.Finish()
+
+			out = append(out, Migration{
+				Version:     migration.Version,
+				Description: migration.Description,
+			})
+		}
+
+		return nil
+	})
+
+	return out, err
+}
+
+func (m postgresMigrator) Down(ctx Context, steps uint) ([]Migration, error) {
+	if err := checkMigrations(m.described()); nil != err {
+		return nil, err
+	}
+	if steps == 0 {
+		return nil, ErrInvalidStepCount
+	}
+
+	out := []Migration{}
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.queryApplied(ctx, conn)
+		if nil != err {
+			return err
+		}
+
+		byVersion := map[uint]PostgresMigration{}
+		for _, migration := range m.migrations {
+			byVersion[migration.Version] = migration
+		}
+
+		for i := len(applied) - 1; i >= 0 && uint(len(out)) < steps; i-- {
+			migration, ok := byVersion[applied[i].Version]
+			if !ok {
+				return fmt.Errorf("%w: version %d", ErrUnknownMigration, applied[i].Version)
+			}
+			if len(migration.Down) == 0 {
+				return fmt.Errorf("%w: version %d", ErrIrreversible, migration.Version)
+			}
+
+			log := m.logger.WithField("version", migration.Version).WithField("description", migration.Description)
+			log.Info("reverting migration")
+
+			span := ctx.span.StartChild(fmt.Sprintf("revert-migration-%d", migration.Version))
+			span.Status = sentry.SpanStatusOK
+			err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Down); nil != err {
+					return err
+				}
+
+				tag, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", int64(migration.Version))
+				if nil != err {
+					return err
+				}
+				if tag.RowsAffected() != 1 {
+					return errors.New("reverted migration record was not found")
+				}
+
+				return nil
+			})
+			if nil != err {
+				defer span.Finish()
+
+				span.Status = sentry.SpanStatusInternalError
+				log.WithError(err).WithField("err_code", "E_REVERT_MIGRATION").Error("failed reverting migration")
+				return err
+			}
+			span.Finish()
+
+			out = append(out, Migration{
+				Version:     migration.Version,
+				Description: migration.Description,
+			})
+		}
+
+		return nil
+	})
+
+	return out, err
+}
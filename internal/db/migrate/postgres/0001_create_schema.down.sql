@@ -0,0 +1,7 @@
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
+DROP TABLE outbox;
+DROP TABLE api_keys;
+DROP TABLE audit_events;
+DROP TABLE user_logins;
+DROP TABLE users;
@@ -0,0 +1,134 @@
+-- Record ids use the C collation so they sort byte by byte, the way MongoDB
+-- compares strings. Keyset pagination depends on both stores agreeing.
+
+CREATE TABLE users (
+    id                       text COLLATE "C" PRIMARY KEY,
+    email                    text        NOT NULL,
+    normalized_email         text        NOT NULL,
+    password                 text        NOT NULL,
+    first_name               text        NOT NULL,
+    last_name                text        NOT NULL,
+    registered_at            timestamptz NOT NULL,
+    status                   text        NOT NULL DEFAULT 'active',
+    status_reason            text        NOT NULL DEFAULT '',
+    status_expires_at        timestamptz,
+    status_changed_at        timestamptz,
+    roles                    text[]      NOT NULL DEFAULT '{}',
+    version                  bigint      NOT NULL DEFAULT 1,
+    updated_at               timestamptz NOT NULL,
+    tokens_not_before        timestamptz,
+    pending_email            text,
+    pending_normalized_email text,
+    pending_token_hash       text,
+    pending_requested_at     timestamptz,
+    pending_expires_at       timestamptz
+);
+
+CREATE UNIQUE INDEX users_normalized_email_unique ON users (normalized_email);
+CREATE INDEX users_normalized_email_pattern ON users (normalized_email text_pattern_ops);
+CREATE INDEX users_email ON users (email);
+CREATE INDEX users_registered_at_id ON users (registered_at, id);
+CREATE INDEX users_status_registered_at_id ON users (status, registered_at, id);
+CREATE INDEX users_status_status_expires_at ON users (status, status_expires_at);
+CREATE INDEX users_roles ON users USING gin (roles);
+CREATE INDEX users_name_words ON users USING gin ((regexp_split_to_array(lower(first_name || ' ' || last_name), '[^[:alnum:]]+')));
+CREATE INDEX users_pending_token_hash ON users (pending_token_hash) WHERE pending_token_hash IS NOT NULL;
+CREATE INDEX users_pending_normalized_email ON users (pending_normalized_email) WHERE pending_normalized_email IS NOT NULL;
+
+CREATE TABLE user_logins (
+    id                        text COLLATE "C" PRIMARY KEY,
+    user_id                   text        NOT NULL,
+    logged_in_at              timestamptz NOT NULL,
+    ip                        text        NOT NULL,
+    device_agent              text        NOT NULL,
+    location_country_code     text,
+    location_country_name     text,
+    location_city             text,
+    location_asn              bigint,
+    location_asn_organization text,
+    device_browser            text,
+    device_browser_version    text,
+    device_os                 text,
+    device_class              text
+);
+
+CREATE INDEX user_logins_user_id ON user_logins (user_id);
+CREATE INDEX user_logins_logged_in_at ON user_logins (logged_in_at);
+
+CREATE TABLE audit_events (
+    id          text COLLATE "C" PRIMARY KEY,
+    type        text        NOT NULL,
+    outcome     text        NOT NULL,
+    actor_id    text        NOT NULL DEFAULT '',
+    subject_id  text        NOT NULL DEFAULT '',
+    ip          text        NOT NULL DEFAULT '',
+    trace_id    text        NOT NULL DEFAULT '',
+    occurred_at timestamptz NOT NULL,
+    details     jsonb
+);
+
+CREATE INDEX audit_events_occurred_at_id ON audit_events (occurred_at DESC, id DESC);
+CREATE INDEX audit_events_actor_id ON audit_events (actor_id, occurred_at DESC);
+CREATE INDEX audit_events_subject_id ON audit_events (subject_id, occurred_at DESC);
+
+CREATE TABLE api_keys (
+    id           text        PRIMARY KEY,
+    prefix       text        NOT NULL,
+    secret_hash  text        NOT NULL,
+    name         text        NOT NULL,
+    scopes       text[]      NOT NULL DEFAULT '{}',
+    created_by   text        NOT NULL,
+    created_at   timestamptz NOT NULL,
+    expires_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz
+);
+
+CREATE UNIQUE INDEX api_keys_prefix_unique ON api_keys (prefix);
+CREATE INDEX api_keys_created_at ON api_keys (created_at DESC);
+
+CREATE TABLE outbox (
+    id           text COLLATE "C" PRIMARY KEY,
+    type         text        NOT NULL,
+    subject_id   text        NOT NULL,
+    occurred_at  timestamptz NOT NULL,
+    payload      jsonb       NOT NULL,
+    status       text        NOT NULL,
+    attempts     bigint      NOT NULL DEFAULT 0,
+    last_error   text        NOT NULL DEFAULT '',
+    delivered_at timestamptz
+);
+
+CREATE INDEX outbox_status_occurred_at_id ON outbox (status, occurred_at, id);
+CREATE INDEX outbox_delivered_at ON outbox (delivered_at) WHERE delivered_at IS NOT NULL;
+
+CREATE TABLE webhooks (
+    id         text        PRIMARY KEY,
+    url        text        NOT NULL,
+    events     text[]      NOT NULL DEFAULT '{}',
+    secret     text        NOT NULL,
+    created_by text        NOT NULL,
+    created_at timestamptz NOT NULL
+);
+
+CREATE INDEX webhooks_created_at ON webhooks (created_at DESC);
+
+CREATE TABLE webhook_deliveries (
+    id               text COLLATE "C" PRIMARY KEY,
+    subscription_id  text        NOT NULL,
+    event_id         text        NOT NULL,
+    event_type       text        NOT NULL,
+    body             text        NOT NULL,
+    status           text        NOT NULL,
+    attempts         bigint      NOT NULL DEFAULT 0,
+    next_attempt_at  timestamptz NOT NULL,
+    last_error       text        NOT NULL DEFAULT '',
+    last_status_code integer     NOT NULL DEFAULT 0,
+    created_at       timestamptz NOT NULL,
+    delivered_at     timestamptz
+);
+
+CREATE UNIQUE INDEX webhook_deliveries_subscription_id_event_id_unique ON webhook_deliveries (subscription_id, event_id);
+CREATE INDEX webhook_deliveries_status_next_attempt_at ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX webhook_deliveries_created_at_id ON webhook_deliveries (created_at DESC, id DESC);
+CREATE INDEX webhook_deliveries_delivered_at ON webhook_deliveries (delivered_at) WHERE delivered_at IS NOT NULL;
@@ -0,0 +1,8 @@
+CREATE TABLE user_login_daily_stats (
+    day            timestamptz PRIMARY KEY,
+    logins         bigint      NOT NULL,
+    users          bigint      NOT NULL,
+    countries      jsonb       NOT NULL,
+    device_classes jsonb       NOT NULL,
+    aggregated_at  timestamptz NOT NULL
+);
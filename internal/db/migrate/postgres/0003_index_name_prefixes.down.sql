@@ -0,0 +1,4 @@
+DROP INDEX users_last_name_lower;
+DROP INDEX users_first_name_lower;
+
+CREATE INDEX users_name_words ON users USING gin ((regexp_split_to_array(lower(first_name || ' ' || last_name), '[^[:alnum:]]+')));
@@ -0,0 +1,4 @@
+DROP INDEX users_name_words;
+
+CREATE INDEX users_first_name_lower ON users (lower(first_name) text_pattern_ops);
+CREATE INDEX users_last_name_lower ON users (lower(last_name) text_pattern_ops);
@@ -0,0 +1,7 @@
+-- One row per bootstrapped role. Its primary key lets only one replica
+-- bootstrap a role, and the row outlives every holder of the role.
+CREATE TABLE role_bootstraps (
+    role            text        PRIMARY KEY,
+    user_id         text        NOT NULL DEFAULT '',
+    bootstrapped_at timestamptz NOT NULL
+);
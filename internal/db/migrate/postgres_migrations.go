@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"embed"
+)
+
+//go:embed postgres/*.sql
+var postgresScripts embed.FS
+
+// PostgresMigration is one versioned change to the PostgreSQL schema, with
+// the same versioning rules as Migration. Up and Down are SQL scripts run in
+// a transaction; a migration without Down cannot be reverted.
+type PostgresMigration struct {
+	Version     uint
+	Description string
+	Up          string
+	Down        string
+}
+
+// PostgresMigrations lists every PostgreSQL migration in version order. Their
+// scripts live in the postgres directory and are frozen once released.
+func PostgresMigrations() []PostgresMigration {
+	return []PostgresMigration{
+		{
+			Version:     1,
+			Description: "create users, user logins, audit events, api keys, outbox and webhooks tables",
+			Up:          postgresScript("0001_create_schema.up.sql"),
+			Down:        postgresScript("0001_create_schema.down.sql"),
+		},
+		{
+			Version:     2,
+			Description: "create user login daily stats table",
+			Up:          postgresScript("0002_create_user_login_daily_stats.up.sql"),
+			Down:        postgresScript("0002_create_user_login_daily_stats.down.sql"),
+		},
+		{
+			Version:     3,
+			Description: "replace users name words index with name prefix indexes",
+			Up:          postgresScript("0003_index_name_prefixes.up.sql"),
+			Down:        postgresScript("0003_index_name_prefixes.down.sql"),
+		},
+		{
+			Version:     4,
+			Description: "create role bootstraps table",
+			Up:          postgresScript("0004_create_role_bootstraps.up.sql"),
+			Down:        postgresScript("0004_create_role_bootstraps.down.sql"),
+		},
+	}
+}
+
+func postgresScript(name string) string {
+	script, err := postgresScripts.ReadFile("postgres/" + name)
+	if nil != err {
+		// scripts are embedded at build time, so only a typo gets here
+		panic(err)
+	}
+
+	return string(script)
+}
@@ -0,0 +1,282 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type appliedMigration struct {
+	Version     uint      `bson:"version"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+func checkMigrations(migrations []Migration) error {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			return ErrInvalidMigrations
+		}
+	}
+
+	return nil
+}
+
+func (m migrator) ensureMigrationsIndex(ctx Context) error {
+	model := mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetName("version_unique").SetUnique(true),
+	}
+	_, err := m.database.Collection(migrationsCollectionName).Indexes().CreateOne(ctx, model)
+	return err
+}
+
+// applied returns the recorded migrations sorted by ascending version.
+func (m migrator) applied(ctx Context) ([]appliedMigration, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+	cursor, err := m.database.Collection(migrationsCollectionName).Find(ctx, bson.M{}, opts)
+	if nil != err {
+		return nil, err
+	}
+
+	out := []appliedMigration{}
+	if err := cursor.All(ctx, &out); nil != err {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (m migrator) Status(ctx Context) ([]Status, error) {
+	if err := checkMigrations(m.migrations); nil != err {
+		return nil, err
+	}
+
+	span := ctx.span.StartChild("query-applied-migrations")
+	span.Status = sentry.SpanStatusOK
+	applied, err := m.applied(ctx)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		m.logger.WithError(err).WithField("err_code", "E_RETRIEVE_APPLIED_MIGRATIONS").Error("failed retrieving applied migrations")
+		return nil, err
+	}
+	span.Finish()
+
+	return buildStatuses(m.migrations, applied), nil
+}
+
+// buildStatuses lists every known migration with its recorded state, followed
+// by the recorded migrations this build does not know about.
+func buildStatuses(migrations []Migration, applied []appliedMigration) []Status {
+	byVersion := map[uint]appliedMigration{}
+	for _, record := range applied {
+		byVersion[record.Version] = record
+	}
+
+	out := make([]Status, 0, len(migrations))
+	known := map[uint]struct{}{}
+	for _, migration := range migrations {
+		known[migration.Version] = struct{}{}
+		status := Status{
+			Version:     migration.Version,
+			Description: migration.Description,
+		}
+		if record, ok := byVersion[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		out = append(out, status)
+	}
+	for _, record := range applied {
+		if _, ok := known[record.Version]; ok {
+			continue
+		}
+		appliedAt := record.AppliedAt
+		out = append(out, Status{
+			Version:     record.Version,
+			Description: record.Description,
+			Applied:     true,
+			AppliedAt:   &appliedAt,
+			Unknown:     true,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+
+	return out
+}
+
+func (m migrator) Up(ctx Context) ([]Migration, error) {
+	if err := checkMigrations(m.migrations); nil != err {
+		return nil, err
+	}
+
+	owner, err := m.lock(ctx)
+	if nil != err {
+		return nil, err
+	}
+	defer m.unlock(ctx, owner)
+	defer m.keepLock(ctx, owner)()
+
+	span := ctx.span.StartChild("ensure-migrations-index")
+	span.Status = sentry.SpanStatusOK
+	if err := m.ensureMigrationsIndex(ctx); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		m.logger.WithError(err).WithField("err_code", "E_ENSURE_MIGRATIONS_INDEX").Error("failed ensuring migrations collection index")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("query-applied-migrations")
+	span.Status = sentry.SpanStatusOK
+	applied, err := m.applied(ctx)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		m.logger.WithError(err).WithField("err_code", "E_RETRIEVE_APPLIED_MIGRATIONS").Error("failed retrieving applied migrations")
+		return nil, err
+	}
+	span.Finish()
+
+	done := map[uint]struct{}{}
+	for _, record := range applied {
+		done[record.Version] = struct{}{}
+	}
+	if len(applied) != 0 && len(m.migrations) != 0 && applied[len(applied)-1].Version > m.migrations[len(m.migrations)-1].Version {
+		m.logger.WithField("version", applied[len(applied)-1].Version).Warn("database has migrations applied that this build does not know about")
+	}
+
+	out := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := done[migration.Version]; ok {
+			continue
+		}
+
+		log := m.logger.WithField("version", migration.Version).WithField("description", migration.Description)
+		log.Info("applying migration")
+
+		span = ctx.span.StartChild(fmt.Sprintf("apply-migration-%d", migration.Version))
+		span.Status = sentry.SpanStatusOK
+		if err := migration.Up(ctx, m.database); nil != err {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInternalError
+			log.WithError(err).WithField("err_code", "E_APPLY_MIGRATION").Error("failed applying migration")
+			return out, err
+		}
+
+		if err := m.checkLock(ctx, owner); nil != err {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusAborted
+			log.WithError(err).WithField("err_code", "E_CHECK_MIGRATION_LOCK").Error("not recording applied migration without holding the migration lock")
+			return out, err
+		}
+
+		record := appliedMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		}
+		if _, err := m.database.Collection(migrationsCollectionName).InsertOne(ctx, record); nil != err {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInternalError
+			log.WithError(err).WithField("err_code", "E_RECORD_MIGRATION").Error("failed recording applied migration")
+			return out, err
+		}
+		span.Finish()
+
+		out = append(out, migration)
+	}
+
+	return out, nil
+}
+
+func (m migrator) Down(ctx Context, steps uint) ([]Migration, error) {
+	if err := checkMigrations(m.migrations); nil != err {
+		return nil, err
+	}
+	if steps == 0 {
+		return nil, ErrInvalidStepCount
+	}
+
+	owner, err := m.lock(ctx)
+	if nil != err {
+		return nil, err
+	}
+	defer m.unlock(ctx, owner)
+	defer m.keepLock(ctx, owner)()
+
+	span := ctx.span.StartChild("query-applied-migrations")
+	span.Status = sentry.SpanStatusOK
+	applied, err := m.applied(ctx)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		m.logger.WithError(err).WithField("err_code", "E_RETRIEVE_APPLIED_MIGRATIONS").Error("failed retrieving applied migrations")
+		return nil, err
+	}
+	span.Finish()
+
+	byVersion := map[uint]Migration{}
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	out := []Migration{}
+	for i := len(applied) - 1; i >= 0 && uint(len(out)) < steps; i-- {
+		migration, ok := byVersion[applied[i].Version]
+		if !ok {
+			return out, fmt.Errorf("%w: version %d", ErrUnknownMigration, applied[i].Version)
+		}
+		if nil == migration.Down {
+			return out, fmt.Errorf("%w: version %d", ErrIrreversible, migration.Version)
+		}
+
+		log := m.logger.WithField("version", migration.Version).WithField("description", migration.Description)
+		log.Info("reverting migration")
+
+		span = ctx.span.StartChild(fmt.Sprintf("revert-migration-%d", migration.Version))
+		span.Status = sentry.SpanStatusOK
+		if err := migration.Down(ctx, m.database); nil != err {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInternalError
+			log.WithError(err).WithField("err_code", "E_REVERT_MIGRATION").Error("failed reverting migration")
+			return out, err
+		}
+
+		if err := m.checkLock(ctx, owner); nil != err {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusAborted
+			log.WithError(err).WithField("err_code", "E_CHECK_MIGRATION_LOCK").Error("not removing reverted migration record without holding the migration lock")
+			return out, err
+		}
+
+		if _, err := m.database.Collection(migrationsCollectionName).DeleteOne(ctx, bson.M{"version": migration.Version}); nil != err {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInternalError
+			log.WithError(err).WithField("err_code", "E_UNRECORD_MIGRATION").Error("failed removing reverted migration record")
+			return out, err
+		}
+		span.Finish()
+
+		out = append(out, migration)
+	}
+
+	return out, nil
+}
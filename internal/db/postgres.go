@@ -0,0 +1,82 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/migrate"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type PostgresDB struct {
+	pool   *pgxpool.Pool
+	logger *logrus.Entry
+	Store  *repository.PostgresStore
+}
+
+func ConnectPostgres(ctx ConnectContext, logger *logrus.Entry, cfg *config.PostgresConfig) (*PostgresDB, error) {
+	child := ctx.span.StartChild("build-postgres-pool-config")
+	child.Status = sentry.SpanStatusOK
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInvalidArgument
+		return nil, fmt.Errorf("invalid postgres connection URL: %s", err)
+	}
+	poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	if cfg.MaxConns != 0 {
+		poolConfig.MaxConns = int32(cfg.MaxConns)
+	}
+	if cfg.MinConns != 0 {
+		poolConfig.MinConns = int32(cfg.MinConns)
+	}
+	child.Finish()
+	logger.
+		WithField("host", poolConfig.ConnConfig.Host).
+		WithField("database", poolConfig.ConnConfig.Database).
+		Debug("connecting postgres using configured address")
+
+	logger.Trace("connecting postgres")
+	child = ctx.span.StartChild("create-postgres-pool")
+	child.Status = sentry.SpanStatusOK
+	pool, err := pgxpool.ConnectConfig(ctx, poolConfig)
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		return nil, err
+	}
+	child.Finish()
+
+	logger.Trace("checking postgres connection healthiness")
+	child = ctx.span.StartChild("check-postgres-connection-healthiness")
+	child.Status = sentry.SpanStatusOK
+	if err := pool.Ping(ctx); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusUnavailable
+		pool.Close()
+		return nil, err
+	}
+	child.Finish()
+
+	return &PostgresDB{
+		pool:   pool,
+		logger: logger,
+		Store:  repository.NewPostgresStore(logger.WithField("srv", "repository"), pool),
+	}, nil
+}
+
+func (db *PostgresDB) Migrator(logger *logrus.Entry, cfg *config.MigrationsConfig) migrate.Migrator {
+	return migrate.NewPostgres(logger, db.pool, migrate.PostgresMigrations(), cfg.LockTimeout)
+}
+
+func (db *PostgresDB) Disconnect() {
+	db.logger.Trace("closing postgres connection pool")
+	db.pool.Close()
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+var (
+	ErrAPIKeyNotExists = errors.New("api key does not exist")
+)
+
+type APIKey struct {
+	ID         string
+	Prefix     string
+	Name       string
+	Scopes     []string
+	CreatedBy  string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+type NewAPIKeyToSave struct {
+	APIKey
+	SecretHash string
+}
+
+type APIKeyAuthenticationInfo struct {
+	APIKey
+	SecretHash string
+}
+
+type apiKeyDocument struct {
+	ID         string     `bson:"id"`
+	Prefix     string     `bson:"prefix"`
+	SecretHash string     `bson:"secret_hash"`
+	Name       string     `bson:"name"`
+	Scopes     []string   `bson:"scopes"`
+	CreatedBy  string     `bson:"created_by"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	ExpiresAt  *time.Time `bson:"expires_at"`
+	LastUsedAt *time.Time `bson:"last_used_at"`
+	RevokedAt  *time.Time `bson:"revoked_at"`
+}
+
+func (doc apiKeyDocument) toAPIKey() APIKey {
+	scopes := doc.Scopes
+	if nil == scopes {
+		scopes = []string{}
+	}
+
+	return APIKey{
+		ID:         doc.ID,
+		Prefix:     doc.Prefix,
+		Name:       doc.Name,
+		Scopes:     scopes,
+		CreatedBy:  doc.CreatedBy,
+		CreatedAt:  doc.CreatedAt,
+		ExpiresAt:  doc.ExpiresAt,
+		LastUsedAt: doc.LastUsedAt,
+		RevokedAt:  doc.RevokedAt,
+	}
+}
+
+// apiKeyProjection leaves out the secret hash, which only the key
+// verification ever needs to read.
+var apiKeyProjection = bson.D{
+	bson.E{Key: "_id", Value: 0},
+	bson.E{Key: "secret_hash", Value: 0},
+}
+
+func (r *Repo) SaveNewAPIKey(ctx DBOperationContext, key NewAPIKeyToSave) error {
+	keyDoc := bson.D{
+		{Key: "id", Value: key.ID},
+		{Key: "prefix", Value: key.Prefix},
+		{Key: "secret_hash", Value: key.SecretHash},
+		{Key: "name", Value: key.Name},
+		{Key: "scopes", Value: key.Scopes},
+		{Key: "created_by", Value: key.CreatedBy},
+		{Key: "created_at", Value: key.CreatedAt},
+	}
+	if nil != key.ExpiresAt {
+		keyDoc = append(keyDoc, bson.E{Key: "expires_at", Value: *key.ExpiresAt})
+	}
+
+	span := ctx.span.StartChild("insert-api-key")
+	span.Status = sentry.SpanStatusOK
+	if _, err := r.collections.APIKeys.InsertOne(ctx, keyDoc); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_SAVE_API_KEY")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to save api key to database")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (r *Repo) GetAPIKeyAuthenticationInfo(ctx DBOperationContext, prefix string) (*APIKeyAuthenticationInfo, error) {
+	filter := bson.M{
+		"prefix": prefix,
+	}
+
+	span := ctx.span.StartChild("query-api-key")
+	span.Status = sentry.SpanStatusOK
+	var doc apiKeyDocument
+	if err := r.collections.APIKeys.FindOne(ctx, filter).Decode(&doc); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrAPIKeyNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_API_KEY")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving api key")
+		return nil, err
+	}
+	span.Finish()
+
+	return &APIKeyAuthenticationInfo{
+		APIKey:     doc.toAPIKey(),
+		SecretHash: doc.SecretHash,
+	}, nil
+}
+
+func (r *Repo) ListAPIKeys(ctx DBOperationContext, includeRevoked bool) ([]APIKey, error) {
+	filter := bson.M{}
+	if !includeRevoked {
+		filter["revoked_at"] = bson.M{"$exists": false}
+	}
+	opts := options.
+		Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(apiKeyProjection)
+
+	span := ctx.span.StartChild("query-api-keys")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.APIKeys.Find(ctx, filter, opts)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_API_KEYS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving api keys")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-queried-api-keys")
+	span.Status = sentry.SpanStatusOK
+	docs := []apiKeyDocument{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode api key documents")
+		return nil, err
+	}
+	span.Finish()
+
+	keys := make([]APIKey, 0, len(docs))
+	for _, doc := range docs {
+		keys = append(keys, doc.toAPIKey())
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks the key revoked. Revoking an already revoked key keeps
+// its original revocation time.
+func (r *Repo) RevokeAPIKey(ctx DBOperationContext, keyID string, at time.Time) (*APIKey, error) {
+	filter := bson.M{
+		"id": keyID,
+	}
+	update := bson.M{
+		"$min": bson.M{"revoked_at": at},
+	}
+	opts := options.
+		FindOneAndUpdate().
+		SetProjection(apiKeyProjection).
+		SetReturnDocument(options.After)
+
+	span := ctx.span.StartChild("revoke-api-key")
+	span.Status = sentry.SpanStatusOK
+	var doc apiKeyDocument
+	if err := r.collections.APIKeys.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrAPIKeyNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_REVOKE_API_KEY")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed revoking api key")
+		return nil, err
+	}
+	span.Finish()
+
+	key := doc.toAPIKey()
+	return &key, nil
+}
+
+// TouchAPIKey records a use of the key. The timestamp is only written when the
+// stored one is older than staleBefore, so busy keys do not cost a write on
+// every request.
+func (r *Repo) TouchAPIKey(ctx DBOperationContext, keyID string, at, staleBefore time.Time) error {
+	filter := bson.M{
+		"id": keyID,
+		"$or": bson.A{
+			bson.M{"last_used_at": bson.M{"$exists": false}},
+			bson.M{"last_used_at": bson.M{"$lt": staleBefore}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"last_used_at": at},
+	}
+
+	span := ctx.span.StartChild("touch-api-key")
+	span.Status = sentry.SpanStatusOK
+	if _, err := r.collections.APIKeys.UpdateOne(ctx, filter, update); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_TOUCH_API_KEY")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed updating api key last use time")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+type AuditEvent struct {
+	ID         string
+	Type       string
+	Outcome    string
+	ActorID    string
+	SubjectID  string
+	IPAddress  string
+	TraceID    string
+	OccurredAt time.Time
+	Details    map[string]string
+}
+
+type AuditEventsCursor struct {
+	OccurredAt time.Time
+	ID         string
+}
+
+type AuditEventsFilter struct {
+	Types     []string
+	Outcome   string
+	ActorID   string
+	SubjectID string
+	From      *time.Time
+	To        *time.Time
+	After     *AuditEventsCursor
+	Limit     int64
+}
+
+type auditEventDocument struct {
+	ID         string            `bson:"id"`
+	Type       string            `bson:"type"`
+	Outcome    string            `bson:"outcome"`
+	ActorID    string            `bson:"actor_id,omitempty"`
+	SubjectID  string            `bson:"subject_id,omitempty"`
+	IPAddress  string            `bson:"ip,omitempty"`
+	TraceID    string            `bson:"trace_id,omitempty"`
+	OccurredAt time.Time         `bson:"occurred_at"`
+	Details    map[string]string `bson:"details,omitempty"`
+}
+
+func (r *Repo) SaveAuditEvent(ctx DBOperationContext, event AuditEvent) error {
+	doc := auditEventDocument{
+		ID:         event.ID,
+		Type:       event.Type,
+		Outcome:    event.Outcome,
+		ActorID:    event.ActorID,
+		SubjectID:  event.SubjectID,
+		IPAddress:  event.IPAddress,
+		TraceID:    event.TraceID,
+		OccurredAt: event.OccurredAt,
+		Details:    event.Details,
+	}
+
+	span := ctx.span.StartChild("insert-audit-event")
+	span.Status = sentry.SpanStatusOK
+	if _, err := r.collections.AuditEvents.InsertOne(ctx, doc); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_SAVE_AUDIT_EVENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed saving audit event")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (r *Repo) QueryAuditEvents(ctx DBOperationContext, filter AuditEventsFilter) ([]AuditEvent, error) {
+	conditions := bson.A{}
+	if len(filter.Types) != 0 {
+		conditions = append(conditions, bson.M{"type": bson.M{"$in": filter.Types}})
+	}
+	if len(filter.Outcome) != 0 {
+		conditions = append(conditions, bson.M{"outcome": filter.Outcome})
+	}
+	if len(filter.ActorID) != 0 {
+		conditions = append(conditions, bson.M{"actor_id": filter.ActorID})
+	}
+	if len(filter.SubjectID) != 0 {
+		conditions = append(conditions, bson.M{"subject_id": filter.SubjectID})
+	}
+	if nil != filter.From {
+		conditions = append(conditions, bson.M{"occurred_at": bson.M{"$gte": *filter.From}})
+	}
+	if nil != filter.To {
+		conditions = append(conditions, bson.M{"occurred_at": bson.M{"$lt": *filter.To}})
+	}
+	if nil != filter.After {
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"occurred_at": bson.M{"$lt": filter.After.OccurredAt}},
+			bson.M{"occurred_at": filter.After.OccurredAt, "id": bson.M{"$lt": filter.After.ID}},
+		}})
+	}
+	query := bson.M{}
+	if len(conditions) != 0 {
+		query = bson.M{"$and": conditions}
+	}
+
+	opts := options.
+		Find().
+		SetSort(bson.D{{Key: "occurred_at", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(filter.Limit).
+		SetProjection(bson.D{{Key: "_id", Value: 0}})
+
+	span := ctx.span.StartChild("query-audit-events")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.AuditEvents.Find(ctx, query, opts)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_QUERY_AUDIT_EVENTS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed querying audit events")
+		return nil, errors.New("unable to query audit events")
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-queried-audit-events")
+	span.Status = sentry.SpanStatusOK
+	docs := []auditEventDocument{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode audit event documents")
+		return nil, errors.New("unable to decode queried audit events")
+	}
+	span.Finish()
+
+	out := make([]AuditEvent, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, AuditEvent{
+			ID:         doc.ID,
+			Type:       doc.Type,
+			Outcome:    doc.Outcome,
+			ActorID:    doc.ActorID,
+			SubjectID:  doc.SubjectID,
+			IPAddress:  doc.IPAddress,
+			TraceID:    doc.TraceID,
+			OccurredAt: doc.OccurredAt,
+			Details:    doc.Details,
+		})
+	}
+
+	return out, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+// AuthenticationInfoCache keeps recently looked up user authentication info.
+// A cache failure is never fatal: CachedStore logs it and falls back to the
+// wrapped store.
+type AuthenticationInfoCache interface {
+	Get(ctx context.Context, userID string) (*UserAuthenticationInfo, bool, error)
+	Set(ctx context.Context, userID string, info UserAuthenticationInfo) error
+	Invalidate(ctx context.Context, userID string) error
+}
+
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Errors uint64
+}
+
+// CachedStore reads user authentication info through a cache and drops the
+// cached entry of a user whenever one of its writes may have changed it.
+//
+// A lookup that races with a write can still put the value it read before
+// the write back into the cache, and a cache that is not shared between
+// instances only sees this instance's writes. Either way an entry is stale
+// for at most the cache TTL.
+type CachedStore struct {
+	// counters come first to keep them 64-bit aligned for sync/atomic on
+	// 32-bit platforms
+	hits   uint64
+	misses uint64
+	errors uint64
+
+	Store
+	cache  AuthenticationInfoCache
+	logger *logrus.Entry
+}
+
+func NewCachedStore(store Store, cache AuthenticationInfoCache, logger *logrus.Entry) *CachedStore {
+	return &CachedStore{
+		Store:  store,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+var _ Store = (*CachedStore)(nil)
+
+func (s *CachedStore) Stats() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadUint64(&s.hits),
+		Misses: atomic.LoadUint64(&s.misses),
+		Errors: atomic.LoadUint64(&s.errors),
+	}
+}
+
+func (s *CachedStore) GetUserAuthenticationInfo(ctx DBOperationContext, userID string) (*UserAuthenticationInfo, error) {
+	span := ctx.span.StartChild("get-cached-user-authentication-info")
+	span.Status = sentry.SpanStatusOK
+	info, found, err := s.cache.Get(ctx, userID)
+	if nil != err {
+		span.Status = sentry.SpanStatusUnavailable
+		s.logCacheError(span, err, "E_GET_CACHED_AUTHENTICATION_INFO", "failed reading user authentication info from cache")
+	}
+	if found {
+		defer span.Finish()
+
+		span.SetTag("cache", "hit")
+		atomic.AddUint64(&s.hits, 1)
+		return info, nil
+	}
+	span.SetTag("cache", "miss")
+	atomic.AddUint64(&s.misses, 1)
+	span.Finish()
+
+	info, err = s.Store.GetUserAuthenticationInfo(ctx, userID)
+	if nil != err {
+		return nil, err
+	}
+
+	span = ctx.span.StartChild("cache-user-authentication-info")
+	span.Status = sentry.SpanStatusOK
+	if err := s.cache.Set(ctx, userID, *info); nil != err {
+		span.Status = sentry.SpanStatusUnavailable
+		s.logCacheError(span, err, "E_SET_CACHED_AUTHENTICATION_INFO", "failed writing user authentication info to cache")
+	}
+	span.Finish()
+
+	return info, nil
+}
+
+func (s *CachedStore) UpdateUserProfile(ctx DBOperationContext, userID string, expectedVersion uint64, update UserProfileUpdate) (*User, error) {
+	defer s.invalidate(ctx, userID)
+
+	return s.Store.UpdateUserProfile(ctx, userID, expectedVersion, update)
+}
+
+func (s *CachedStore) ConfirmEmailChange(ctx DBOperationContext, tokenHash string, confirmedAt time.Time) (*ConfirmedEmailChange, error) {
+	change, err := s.Store.ConfirmEmailChange(ctx, tokenHash, confirmedAt)
+	if nil != err {
+		return nil, err
+	}
+
+	s.invalidate(ctx, change.UserID)
+
+	return change, nil
+}
+
+func (s *CachedStore) ChangeUserStatus(ctx DBOperationContext, userID string, allowedFrom []UserStatus, change UserStatusChange) (*User, error) {
+	defer s.invalidate(ctx, userID)
+
+	return s.Store.ChangeUserStatus(ctx, userID, allowedFrom, change)
+}
+
+func (s *CachedStore) AddUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error) {
+	defer s.invalidate(ctx, userID)
+
+	return s.Store.AddUserRole(ctx, userID, role, at)
+}
+
+func (s *CachedStore) RemoveUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error) {
+	defer s.invalidate(ctx, userID)
+
+	return s.Store.RemoveUserRole(ctx, userID, role, at)
+}
+
+func (s *CachedStore) BootstrapRole(ctx DBOperationContext, userID, role string, at time.Time) (bool, error) {
+	defer s.invalidate(ctx, userID)
+
+	return s.Store.BootstrapRole(ctx, userID, role, at)
+}
+
+func (s *CachedStore) DeleteUser(ctx DBOperationContext, userID string, dueBefore time.Time) (*PurgedUser, error) {
+	defer s.invalidate(ctx, userID)
+
+	return s.Store.DeleteUser(ctx, userID, dueBefore)
+}
+
+func (s *CachedStore) AnonymizeUser(ctx DBOperationContext, userID string, dueBefore time.Time, anonymizedAt time.Time) (*PurgedUser, error) {
+	defer s.invalidate(ctx, userID)
+
+	return s.Store.AnonymizeUser(ctx, userID, dueBefore, anonymizedAt)
+}
+
+// invalidate runs after the write whether or not it failed, since a failed
+// write may still have been applied.
+func (s *CachedStore) invalidate(ctx DBOperationContext, userID string) {
+	span := ctx.span.StartChild("invalidate-cached-user-authentication-info")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	if err := s.cache.Invalidate(ctx, userID); nil != err {
+		span.Status = sentry.SpanStatusUnavailable
+		s.logCacheError(span, err, "E_INVALIDATE_CACHED_AUTHENTICATION_INFO", "failed invalidating cached user authentication info. it stays stale until it expires")
+	}
+}
+
+func (s *CachedStore) logCacheError(span *sentry.Span, err error, code, message string) {
+	atomic.AddUint64(&s.errors, 1)
+	log := s.logger.WithError(err).WithField("err_code", code)
+	apm.SetSpanTagsFromLogEntry(span, log)
+	log.Warn(message)
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+var (
+	ErrEmailChangeNotExists = errors.New("no pending email change matches the token")
+)
+
+type PendingEmailChange struct {
+	Email           string
+	NormalizedEmail string
+	TokenHash       string
+	RequestedAt     time.Time
+	ExpiresAt       time.Time
+}
+
+type ConfirmedEmailChange struct {
+	UserID   string
+	OldEmail string
+	NewEmail string
+}
+
+func (r *Repo) SavePendingEmailChange(ctx DBOperationContext, userID string, change PendingEmailChange) error {
+	filter := bson.M{
+		"id": userID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"pending_email_change": pendingEmailChangeDocument{
+				Email:           change.Email,
+				NormalizedEmail: change.NormalizedEmail,
+				TokenHash:       change.TokenHash,
+				RequestedAt:     change.RequestedAt,
+				ExpiresAt:       change.ExpiresAt,
+			},
+		},
+	}
+
+	span := ctx.span.StartChild("update-user-pending-email-change")
+	span.Status = sentry.SpanStatusOK
+	result, err := r.collections.Users.UpdateOne(ctx, filter, update)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_SAVE_PENDING_EMAIL_CHANGE")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed saving pending email change")
+		return err
+	}
+	if result.MatchedCount == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusNotFound
+		return ErrUserNotExists
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (r *Repo) ConfirmEmailChange(ctx DBOperationContext, tokenHash string, confirmedAt time.Time) (*ConfirmedEmailChange, error) {
+	filter := bson.M{
+		"pending_email_change.token_hash": tokenHash,
+		"pending_email_change.expires_at": bson.M{"$gt": confirmedAt},
+	}
+	update := mongo.Pipeline{
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "email", Value: "$pending_email_change.email"},
+			{Key: "normalized_email", Value: "$pending_email_change.normalized_email"},
+			{Key: "updated_at", Value: confirmedAt},
+			{Key: "version", Value: bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", int64(1)}}, int64(1)}}},
+		}}},
+		bson.D{{Key: "$unset", Value: "pending_email_change"}},
+	}
+	projection := bson.D{
+		bson.E{Key: "_id", Value: 0},
+		bson.E{Key: "id", Value: 1},
+		bson.E{Key: "email", Value: 1},
+		bson.E{Key: "pending_email_change.email", Value: 1},
+	}
+	opts := options.
+		FindOneAndUpdate().
+		SetProjection(projection).
+		SetReturnDocument(options.Before)
+
+	span := ctx.span.StartChild("swap-user-email")
+	span.Status = sentry.SpanStatusOK
+	var doc userDocument
+	err := r.inTransaction(ctx, func(ctx context.Context) error {
+		if err := r.collections.Users.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc); nil != err {
+			return err
+		}
+		if nil == doc.PendingEmailChange {
+			return errors.New("previous user document has no pending email change")
+		}
+
+		event, err := userUpdatedEvent(doc.ID, confirmedAt, map[string]string{
+			"email": doc.PendingEmailChange.Email,
+		})
+		if nil != err {
+			return err
+		}
+
+		_, err = r.collections.Outbox.InsertOne(ctx, newOutboxEventDocument(event))
+		return err
+	})
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrEmailChangeNotExists
+		}
+		if isDuplicateKeyErrorOn(err, UsersEmailIndex) {
+			span.Status = sentry.SpanStatusAlreadyExists
+			return nil, ErrEmailTaken
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_CONFIRM_EMAIL_CHANGE")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed swapping user email address")
+		return nil, err
+	}
+	span.Finish()
+
+	return &ConfirmedEmailChange{
+		UserID:   doc.ID,
+		OldEmail: doc.Email,
+		NewEmail: doc.PendingEmailChange.Email,
+	}, nil
+}
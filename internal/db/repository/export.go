@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+// UserDataExport holds every stored record about a single user, converted to
+// plain values that encode to JSON without any driver specific types.
+type UserDataExport struct {
+	User        map[string]interface{}
+	Logins      []map[string]interface{}
+	AuditEvents []map[string]interface{}
+}
+
+func (r *Repo) ExportUserData(ctx DBOperationContext, userID string) (*UserDataExport, error) {
+	userOpts := options.FindOne().SetProjection(bson.D{
+		bson.E{Key: "_id", Value: 0},
+		bson.E{Key: "password", Value: 0},
+		bson.E{Key: "pending_email_change.token_hash", Value: 0},
+	})
+
+	span := ctx.span.StartChild("query-user")
+	span.Status = sentry.SpanStatusOK
+	result := r.collections.Users.FindOne(ctx, bson.M{"id": userID}, userOpts)
+	if err := result.Err(); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrUserNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_USER_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving user document")
+		return nil, err
+	}
+	userDoc := bson.M{}
+	if err := result.Decode(&userDoc); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode user document")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("query-user-logins")
+	span.Status = sentry.SpanStatusOK
+	logins, err := r.findPlainDocuments(NewDBOperationContext(ctx, span), r.collections.UserLogins, bson.M{"user.id": userID}, "logged_in_at")
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_USER_LOGIN_DOCUMENTS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving user login documents")
+		return nil, err
+	}
+	span.Finish()
+
+	auditFilter := bson.M{
+		"$or": bson.A{
+			bson.M{"actor_id": userID},
+			bson.M{"subject_id": userID},
+		},
+	}
+	span = ctx.span.StartChild("query-user-audit-events")
+	span.Status = sentry.SpanStatusOK
+	auditEvents, err := r.findPlainDocuments(NewDBOperationContext(ctx, span), r.collections.AuditEvents, auditFilter, "occurred_at")
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_AUDIT_EVENT_DOCUMENTS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving user audit event documents")
+		return nil, err
+	}
+	span.Finish()
+
+	for i, event := range auditEvents {
+		auditEvents[i] = exportedAuditEvent(event, userID)
+	}
+
+	return &UserDataExport{
+		User:        plainDocument(userDoc),
+		Logins:      logins,
+		AuditEvents: auditEvents,
+	}, nil
+}
+
+// foreignActorAuditFields identify whoever acted in an audit event. They are
+// left out of the exported events another user, e.g. an administrator or an
+// api key, acted in on the exported user.
+var foreignActorAuditFields = []string{
+	"actor_id",
+	"ip",
+	"trace_id",
+}
+
+func exportedAuditEvent(event map[string]interface{}, userID string) map[string]interface{} {
+	if actorID, _ := event["actor_id"].(string); actorID == userID {
+		return event
+	}
+
+	for _, field := range foreignActorAuditFields {
+		delete(event, field)
+	}
+
+	return event
+}
+
+func (r *Repo) findPlainDocuments(ctx DBOperationContext, collection *mongo.Collection, filter bson.M, sortKey string) ([]map[string]interface{}, error) {
+	opts := options.
+		Find().
+		SetSort(bson.D{{Key: sortKey, Value: 1}}).
+		SetProjection(bson.D{{Key: "_id", Value: 0}})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if nil != err {
+		return nil, err
+	}
+
+	docs := []bson.M{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		return nil, err
+	}
+
+	out := make([]map[string]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, plainDocument(doc))
+	}
+
+	return out, nil
+}
+
+func plainDocument(doc bson.M) map[string]interface{} {
+	out := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		out[key] = plainValue(value)
+	}
+
+	return out
+}
+
+func plainValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case bson.M:
+		return plainDocument(value)
+	case bson.D:
+		return plainDocument(value.Map())
+	case bson.A:
+		out := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			out = append(out, plainValue(item))
+		}
+		return out
+	case primitive.DateTime:
+		return value.Time().UTC()
+	case primitive.ObjectID:
+		return value.Hex()
+	default:
+		return value
+	}
+}
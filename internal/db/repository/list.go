@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+type UsersCursor struct {
+	RegisteredAt time.Time
+	ID           string
+}
+
+type UsersFilter struct {
+	RegisteredFrom *time.Time
+	RegisteredTo   *time.Time
+	Status         UserStatus
+	EmailDomain    string
+	EmailPrefix    string
+	NameQuery      string
+	After          *UsersCursor
+	Limit          int64
+}
+
+// statusFilter matches users by their effective status, so suspensions that
+// have already expired are listed as active.
+func statusFilter(status UserStatus, now time.Time) bson.M {
+	switch status {
+	case UserStatusActive:
+		return bson.M{"$or": bson.A{
+			bson.M{"status": status},
+			bson.M{"status": bson.M{"$exists": false}},
+			bson.M{"status": UserStatusSuspended, "status_expires_at": bson.M{"$lte": now}},
+		}}
+	case UserStatusSuspended:
+		return bson.M{"status": status, "status_expires_at": bson.M{"$not": bson.M{"$lte": now}}}
+	default:
+		return bson.M{"status": status}
+	}
+}
+
+func (r *Repo) ListUsers(ctx DBOperationContext, filter UsersFilter) ([]User, error) {
+	conditions := bson.A{}
+	if nil != filter.RegisteredFrom {
+		conditions = append(conditions, bson.M{"registered_at": bson.M{"$gte": *filter.RegisteredFrom}})
+	}
+	if nil != filter.RegisteredTo {
+		conditions = append(conditions, bson.M{"registered_at": bson.M{"$lt": *filter.RegisteredTo}})
+	}
+	if len(filter.Status) != 0 {
+		conditions = append(conditions, statusFilter(filter.Status, time.Now()))
+	}
+	if len(filter.EmailPrefix) != 0 {
+		conditions = append(conditions, bson.M{"normalized_email": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.ToLower(filter.EmailPrefix))}})
+	}
+	if len(filter.EmailDomain) != 0 {
+		conditions = append(conditions, bson.M{"normalized_email": primitive.Regex{Pattern: "@" + regexp.QuoteMeta(strings.ToLower(filter.EmailDomain)) + "$"}})
+	}
+	if nameQuery := strings.TrimSpace(filter.NameQuery); len(nameQuery) != 0 {
+		prefix := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(nameQuery), Options: "i"}
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"first_name": prefix},
+			bson.M{"last_name": prefix},
+		}})
+	}
+	if nil != filter.After {
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"registered_at": bson.M{"$gt": filter.After.RegisteredAt}},
+			bson.M{"registered_at": filter.After.RegisteredAt, "id": bson.M{"$gt": filter.After.ID}},
+		}})
+	}
+	query := bson.M{}
+	if len(conditions) != 0 {
+		query = bson.M{"$and": conditions}
+	}
+
+	opts := options.
+		Find().
+		SetSort(bson.D{{Key: "registered_at", Value: 1}, {Key: "id", Value: 1}}).
+		SetLimit(filter.Limit).
+		SetProjection(userSummaryProjection)
+
+	span := ctx.span.StartChild("query-users")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.Users.Find(ctx, query, opts)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_LIST_USERS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed listing users")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-queried-users")
+	span.Status = sentry.SpanStatusOK
+	docs := []userDocument{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode user documents")
+		return nil, err
+	}
+	span.Finish()
+
+	out := make([]User, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, doc.toUser())
+	}
+
+	return out, nil
+}
@@ -1,36 +1,118 @@
 package repository
 
 import (
+	"context"
 	"time"
 
 	"github.com/getsentry/sentry-go"
-	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/game-sales-analytics/users-service/internal/apm"
 )
 
+type UserLoginLocation struct {
+	CountryCode     string
+	CountryName     string
+	City            string
+	ASN             uint
+	ASNOrganization string
+}
+
+type UserLoginDevice struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	Class          string
+}
+
 type NewUserLoginToSave struct {
 	ID                  string
 	UserID              string
 	LoggedInAt          time.Time
 	UserIPAddress       string
 	UserDeviceUserAgent string
+	Location            *UserLoginLocation
+	Device              *UserLoginDevice
+}
+
+// userLoginDocument is a document of the user_logins collection. Details
+// about the user at login time are nested under "user".
+type userLoginDocument struct {
+	ID         string                `bson:"id"`
+	LoggedInAt time.Time             `bson:"logged_in_at"`
+	User       userLoginUserDocument `bson:"user"`
+}
+
+type userLoginUserDocument struct {
+	ID          string                     `bson:"id"`
+	IPAddress   string                     `bson:"ip"`
+	DeviceAgent string                     `bson:"device_agent"`
+	Location    *userLoginLocationDocument `bson:"location,omitempty"`
+	Device      *userLoginDeviceDocument   `bson:"device,omitempty"`
+}
+
+type userLoginLocationDocument struct {
+	CountryCode     string `bson:"country_code"`
+	CountryName     string `bson:"country_name"`
+	City            string `bson:"city"`
+	ASN             int64  `bson:"asn"`
+	ASNOrganization string `bson:"asn_organization"`
+}
+
+type userLoginDeviceDocument struct {
+	Browser        string `bson:"browser"`
+	BrowserVersion string `bson:"browser_version"`
+	OS             string `bson:"os"`
+	Class          string `bson:"class"`
+}
+
+func newUserLoginDocument(userLogin NewUserLoginToSave) userLoginDocument {
+	doc := userLoginDocument{
+		ID:         userLogin.ID,
+		LoggedInAt: userLogin.LoggedInAt,
+		User: userLoginUserDocument{
+			ID:          userLogin.UserID,
+			IPAddress:   userLogin.UserIPAddress,
+			DeviceAgent: userLogin.UserDeviceUserAgent,
+		},
+	}
+	if nil != userLogin.Location {
+		doc.User.Location = &userLoginLocationDocument{
+			CountryCode:     userLogin.Location.CountryCode,
+			CountryName:     userLogin.Location.CountryName,
+			City:            userLogin.Location.City,
+			ASN:             int64(userLogin.Location.ASN),
+			ASNOrganization: userLogin.Location.ASNOrganization,
+		}
+	}
+	if nil != userLogin.Device {
+		doc.User.Device = &userLoginDeviceDocument{
+			Browser:        userLogin.Device.Browser,
+			BrowserVersion: userLogin.Device.BrowserVersion,
+			OS:             userLogin.Device.OS,
+			Class:          userLogin.Device.Class,
+		}
+	}
+
+	return doc
 }
 
 func (r *Repo) SaveNewUserLogin(ctx DBOperationContext, userLogin NewUserLoginToSave) error {
-	doc := bson.D{
-		{Key: "id", Value: userLogin.ID},
-		{Key: "logged_in_at", Value: userLogin.LoggedInAt},
-		{Key: "user", Value: bson.D{
-			{Key: "id", Value: userLogin.UserID},
-			{Key: "ip", Value: userLogin.UserIPAddress},
-			{Key: "device_agent", Value: userLogin.UserDeviceUserAgent},
-		}},
+	doc := newUserLoginDocument(userLogin)
+	event, err := userLoggedInEvent(userLogin)
+	if nil != err {
+		return err
 	}
 
 	span := ctx.span.StartChild("insert-user-login-info")
 	span.Status = sentry.SpanStatusOK
-	_, err := r.collections.UserLogins.InsertOne(ctx, doc)
+	err = r.inTransaction(ctx, func(ctx context.Context) error {
+		if _, err := r.collections.UserLogins.InsertOne(ctx, doc); nil != err {
+			return err
+		}
+
+		_, err := r.collections.Outbox.InsertOne(ctx, newOutboxEventDocument(event))
+		return err
+	})
 	if nil != err {
 		defer span.Finish()
 
@@ -40,6 +122,7 @@ func (r *Repo) SaveNewUserLogin(ctx DBOperationContext, userLogin NewUserLoginTo
 		log.Error("failed saving user login attempt record")
 		return err
 	}
+	span.Finish()
 
 	return nil
 }
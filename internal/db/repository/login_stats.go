@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+// UnknownLoginStatsKey counts logins without a resolved country or device
+// class.
+const UnknownLoginStatsKey = "unknown"
+
+// UserLoginDailyStats is the anonymized summary of the logins of one UTC day.
+// It outlives the login records it was computed from.
+type UserLoginDailyStats struct {
+	Day           time.Time
+	Logins        int64
+	Users         int64
+	Countries     map[string]int64
+	DeviceClasses map[string]int64
+	AggregatedAt  time.Time
+}
+
+type userLoginDailyStatsDocument struct {
+	Day           time.Time        `bson:"day"`
+	Logins        int64            `bson:"logins"`
+	Users         int64            `bson:"users"`
+	Countries     map[string]int64 `bson:"countries"`
+	DeviceClasses map[string]int64 `bson:"device_classes"`
+	AggregatedAt  time.Time        `bson:"aggregated_at"`
+}
+
+type loginStatsBucket struct {
+	Key    *string `bson:"_id"`
+	Logins int64   `bson:"logins"`
+}
+
+// addLoginStatsBucket adds a count under its key, folding missing and empty
+// keys into UnknownLoginStatsKey.
+func addLoginStatsBucket(counts map[string]int64, key *string, logins int64) {
+	if nil == key || len(*key) == 0 {
+		counts[UnknownLoginStatsKey] += logins
+		return
+	}
+
+	counts[*key] += logins
+}
+
+func (r *Repo) ComputeUserLoginDailyStats(ctx DBOperationContext, day time.Time) (*UserLoginDailyStats, error) {
+	day = storedTime(day)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"logged_in_at": bson.M{"$gte": day, "$lt": day.Add(time.Hour * 24)}}}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{
+				bson.M{"$group": bson.M{"_id": "$user.id", "logins": bson.M{"$sum": 1}}},
+				bson.M{"$group": bson.M{"_id": nil, "users": bson.M{"$sum": 1}, "logins": bson.M{"$sum": "$logins"}}},
+			},
+			"countries": bson.A{
+				bson.M{"$group": bson.M{"_id": "$user.location.country_code", "logins": bson.M{"$sum": 1}}},
+			},
+			"device_classes": bson.A{
+				bson.M{"$group": bson.M{"_id": "$user.device.class", "logins": bson.M{"$sum": 1}}},
+			},
+		}}},
+	}
+
+	span := ctx.span.StartChild("aggregate-user-logins-of-day")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.UserLogins.Aggregate(ctx, pipeline)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_AGGREGATE_USER_LOGINS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed aggregating user login documents")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-aggregated-user-logins")
+	span.Status = sentry.SpanStatusOK
+	docs := []struct {
+		Totals []struct {
+			Users  int64 `bson:"users"`
+			Logins int64 `bson:"logins"`
+		} `bson:"totals"`
+		Countries     []loginStatsBucket `bson:"countries"`
+		DeviceClasses []loginStatsBucket `bson:"device_classes"`
+	}{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode aggregated user logins")
+		return nil, err
+	}
+	span.Finish()
+
+	stats := &UserLoginDailyStats{
+		Day:           day,
+		Countries:     map[string]int64{},
+		DeviceClasses: map[string]int64{},
+	}
+	if len(docs) != 0 {
+		if len(docs[0].Totals) != 0 {
+			stats.Logins = docs[0].Totals[0].Logins
+			stats.Users = docs[0].Totals[0].Users
+		}
+		for _, bucket := range docs[0].Countries {
+			addLoginStatsBucket(stats.Countries, bucket.Key, bucket.Logins)
+		}
+		for _, bucket := range docs[0].DeviceClasses {
+			addLoginStatsBucket(stats.DeviceClasses, bucket.Key, bucket.Logins)
+		}
+	}
+
+	return stats, nil
+}
+
+// SaveUserLoginDailyStats stores the stats unless the day was already
+// aggregated, and reports whether they were stored. Stats of a day are never
+// overwritten, since its login records may be gone by now.
+func (r *Repo) SaveUserLoginDailyStats(ctx DBOperationContext, stats UserLoginDailyStats) (bool, error) {
+	doc := userLoginDailyStatsDocument{
+		Day:           storedTime(stats.Day),
+		Logins:        stats.Logins,
+		Users:         stats.Users,
+		Countries:     stats.Countries,
+		DeviceClasses: stats.DeviceClasses,
+		AggregatedAt:  storedTime(stats.AggregatedAt),
+	}
+	opts := options.Update().SetUpsert(true)
+
+	span := ctx.span.StartChild("insert-user-login-daily-stats")
+	span.Status = sentry.SpanStatusOK
+	result, err := r.collections.UserLoginDailyStats.UpdateOne(ctx, bson.M{"day": doc.Day}, bson.M{"$setOnInsert": doc}, opts)
+	if nil != err {
+		defer span.Finish()
+
+		if mongo.IsDuplicateKeyError(err) {
+			span.Status = sentry.SpanStatusAlreadyExists
+			return false, nil
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_SAVE_USER_LOGIN_DAILY_STATS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed saving user login daily stats")
+		return false, err
+	}
+	span.Finish()
+
+	return result.UpsertedCount != 0, nil
+}
+
+func (r *Repo) LatestUserLoginDailyStatsDay(ctx DBOperationContext) (*time.Time, error) {
+	opts := options.
+		FindOne().
+		SetSort(bson.D{{Key: "day", Value: -1}}).
+		SetProjection(bson.D{
+			bson.E{Key: "_id", Value: 0},
+			bson.E{Key: "day", Value: 1},
+		})
+
+	span := ctx.span.StartChild("query-latest-user-login-daily-stats")
+	span.Status = sentry.SpanStatusOK
+	var doc struct {
+		Day time.Time `bson:"day"`
+	}
+	if err := r.collections.UserLoginDailyStats.FindOne(ctx, bson.M{}, opts).Decode(&doc); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, nil
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_USER_LOGIN_DAILY_STATS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving latest user login daily stats")
+		return nil, err
+	}
+	span.Finish()
+
+	day := doc.Day.UTC()
+	return &day, nil
+}
+
+func (r *Repo) EarliestUserLoginTime(ctx DBOperationContext) (*time.Time, error) {
+	opts := options.
+		FindOne().
+		SetSort(bson.D{{Key: "logged_in_at", Value: 1}}).
+		SetProjection(bson.D{
+			bson.E{Key: "_id", Value: 0},
+			bson.E{Key: "logged_in_at", Value: 1},
+		})
+
+	span := ctx.span.StartChild("query-earliest-user-login")
+	span.Status = sentry.SpanStatusOK
+	var doc struct {
+		LoggedInAt time.Time `bson:"logged_in_at"`
+	}
+	if err := r.collections.UserLogins.FindOne(ctx, bson.M{}, opts).Decode(&doc); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, nil
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_USER_LOGIN_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving earliest user login")
+		return nil, err
+	}
+	span.Finish()
+
+	loggedInAt := doc.LoggedInAt.UTC()
+	return &loggedInAt, nil
+}
+
+// DeleteUserLoginsBefore removes at most limit logins older than the given
+// time. MongoDB expires logins through a TTL index instead, so this is only
+// needed to catch up without waiting for the TTL monitor.
+func (r *Repo) DeleteUserLoginsBefore(ctx DBOperationContext, before time.Time, limit int64) (int64, error) {
+	filter := bson.M{
+		"logged_in_at": bson.M{"$lt": before},
+	}
+	opts := options.
+		Find().
+		SetLimit(limit).
+		SetProjection(bson.D{
+			bson.E{Key: "_id", Value: 1},
+		})
+
+	span := ctx.span.StartChild("query-expired-user-logins")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.UserLogins.Find(ctx, filter, opts)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_USER_LOGIN_DOCUMENTS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving expired user logins")
+		return 0, err
+	}
+	docs := []struct {
+		ID interface{} `bson:"_id"`
+	}{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode user login documents")
+		return 0, err
+	}
+	span.Finish()
+
+	if len(docs) == 0 {
+		return 0, nil
+	}
+	ids := make(bson.A, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+
+	span = ctx.span.StartChild("delete-expired-user-logins")
+	span.Status = sentry.SpanStatusOK
+	result, err := r.collections.UserLogins.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DELETE_USER_LOGINS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed deleting expired user login documents")
+		return 0, err
+	}
+	span.Finish()
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+type User struct {
+	ID           string
+	FirstName    string
+	LastName     string
+	Email        string
+	RegisteredAt time.Time
+	Status       UserStatus
+	StatusReason string
+	StatusUntil  *time.Time
+	Roles        []string
+	Version      uint64
+	UpdatedAt    time.Time
+}
+
+// userSummaryProjection loads the userDocument fields User is built from.
+var userSummaryProjection = bson.D{
+	bson.E{Key: "_id", Value: 0},
+	bson.E{Key: "id", Value: 1},
+	bson.E{Key: "first_name", Value: 1},
+	bson.E{Key: "last_name", Value: 1},
+	bson.E{Key: "email", Value: 1},
+	bson.E{Key: "registered_at", Value: 1},
+	bson.E{Key: "status", Value: 1},
+	bson.E{Key: "status_reason", Value: 1},
+	bson.E{Key: "status_expires_at", Value: 1},
+	bson.E{Key: "roles", Value: 1},
+	bson.E{Key: "version", Value: 1},
+	bson.E{Key: "updated_at", Value: 1},
+}
+
+func (r *Repo) GetUser(ctx DBOperationContext, userID string) (*User, error) {
+	filter := bson.M{
+		"id": userID,
+	}
+	opts := options.FindOne().SetProjection(userSummaryProjection)
+
+	span := ctx.span.StartChild("query-user")
+	span.Status = sentry.SpanStatusOK
+	result := r.collections.Users.FindOne(ctx, filter, opts)
+	if err := result.Err(); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrUserNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_USER_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving user document")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-queried-user")
+	span.Status = sentry.SpanStatusOK
+	var doc userDocument
+	if err := result.Decode(&doc); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode user document")
+		return nil, err
+	}
+	span.Finish()
+
+	user := doc.toUser()
+	return &user, nil
+}
+
+func (r *Repo) GetUsersByIDs(ctx DBOperationContext, userIDs []string) ([]User, error) {
+	filter := bson.M{
+		"id": bson.M{"$in": userIDs},
+	}
+	opts := options.Find().SetProjection(userSummaryProjection)
+
+	span := ctx.span.StartChild("query-users-by-ids")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.Users.Find(ctx, filter, opts)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_USER_DOCUMENTS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving user documents")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-queried-users")
+	span.Status = sentry.SpanStatusOK
+	docs := []userDocument{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode user documents")
+		return nil, err
+	}
+	span.Finish()
+
+	out := make([]User, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, doc.toUser())
+	}
+
+	return out, nil
+}
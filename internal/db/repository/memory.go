@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	errMemoryDuplicateKey = errors.New("record with the same unique key already exists")
+)
+
+type memoryUser struct {
+	ID                 string
+	Email              string
+	NormalizedEmail    string
+	Password           string
+	FirstName          string
+	LastName           string
+	RegisteredAt       time.Time
+	Status             UserStatus
+	StatusReason       string
+	StatusExpiresAt    *time.Time
+	StatusChangedAt    *time.Time
+	Roles              []string
+	Version            uint64
+	UpdatedAt          time.Time
+	TokensNotBefore    *time.Time
+	PendingEmailChange *PendingEmailChange
+}
+
+func (u *memoryUser) statusInfo() UserStatusInfo {
+	return UserStatusInfo{
+		Status:    u.Status,
+		Reason:    u.StatusReason,
+		ExpiresAt: copyTime(u.StatusExpiresAt),
+	}
+}
+
+func (u *memoryUser) toUser() User {
+	return userDocument{
+		ID:              u.ID,
+		FirstName:       u.FirstName,
+		LastName:        u.LastName,
+		Email:           u.Email,
+		RegisteredAt:    u.RegisteredAt,
+		Status:          u.Status,
+		StatusReason:    u.StatusReason,
+		StatusExpiresAt: copyTime(u.StatusExpiresAt),
+		Roles:           copyStrings(u.Roles),
+		Version:         int64(u.Version),
+		UpdatedAt:       u.UpdatedAt,
+	}.toUser()
+}
+
+type memoryUserLogin struct {
+	NewUserLoginToSave
+}
+
+type memoryAPIKey struct {
+	APIKey
+	SecretHash string
+}
+
+// MemoryStore keeps every record in process memory. It is meant for tests and
+// local development and follows the semantics of Repo, including not-found
+// errors and the unique constraints enforced by the MongoDB indexes.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	users map[string]*memoryUser
+	// userOrder keeps insertion order, which is the order MongoDB returns
+	// unsorted results in.
+	userOrder   []string
+	userLogins  []memoryUserLogin
+	auditEvents []AuditEvent
+	apiKeys     []*memoryAPIKey
+	outbox      []*OutboxEvent
+
+	// roleBootstraps maps every bootstrapped role to the user it was granted
+	// to, or to an empty string when it was found held already.
+	roleBootstraps map[string]string
+
+	// userLoginDailyStats is keyed by the Unix time of the day.
+	userLoginDailyStats map[int64]UserLoginDailyStats
+
+	webhooks          []WebhookSubscription
+	webhookDeliveries []*WebhookDelivery
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:       map[string]*memoryUser{},
+		userOrder:   []string{},
+		userLogins:  []memoryUserLogin{},
+		auditEvents: []AuditEvent{},
+		apiKeys:     []*memoryAPIKey{},
+		outbox:      []*OutboxEvent{},
+
+		roleBootstraps: map[string]string{},
+
+		userLoginDailyStats: map[int64]UserLoginDailyStats{},
+
+		webhooks:          []WebhookSubscription{},
+		webhookDeliveries: []*WebhookDelivery{},
+	}
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// storedTime drops the precision MongoDB does not keep, so both stores hand
+// back identical timestamps.
+func storedTime(t time.Time) time.Time {
+	return t.Truncate(time.Millisecond).UTC()
+}
+
+func storedTimePtr(t *time.Time) *time.Time {
+	if nil == t {
+		return nil
+	}
+
+	stored := storedTime(*t)
+	return &stored
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if nil == t {
+		return nil
+	}
+
+	copied := *t
+	return &copied
+}
+
+func copyStrings(values []string) []string {
+	if nil == values {
+		return nil
+	}
+
+	return append([]string{}, values...)
+}
+
+func copyDetails(details map[string]string) map[string]string {
+	if nil == details {
+		return nil
+	}
+
+	out := make(map[string]string, len(details))
+	for key, value := range details {
+		out[key] = value
+	}
+
+	return out
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
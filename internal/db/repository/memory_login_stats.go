@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"time"
+)
+
+func (s *MemoryStore) ComputeUserLoginDailyStats(ctx DBOperationContext, day time.Time) (*UserLoginDailyStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	day = storedTime(day)
+	end := day.Add(time.Hour * 24)
+	stats := &UserLoginDailyStats{
+		Day:           day,
+		Countries:     map[string]int64{},
+		DeviceClasses: map[string]int64{},
+	}
+	users := map[string]struct{}{}
+	for _, login := range s.userLogins {
+		if login.LoggedInAt.Before(day) || !login.LoggedInAt.Before(end) {
+			continue
+		}
+
+		stats.Logins++
+		users[login.UserID] = struct{}{}
+
+		var country, class *string
+		if nil != login.Location {
+			country = &login.Location.CountryCode
+		}
+		if nil != login.Device {
+			class = &login.Device.Class
+		}
+		addLoginStatsBucket(stats.Countries, country, 1)
+		addLoginStatsBucket(stats.DeviceClasses, class, 1)
+	}
+	stats.Users = int64(len(users))
+
+	return stats, nil
+}
+
+func (s *MemoryStore) SaveUserLoginDailyStats(ctx DBOperationContext, stats UserLoginDailyStats) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats.Day = storedTime(stats.Day)
+	if _, exists := s.userLoginDailyStats[stats.Day.Unix()]; exists {
+		return false, nil
+	}
+
+	stats.AggregatedAt = storedTime(stats.AggregatedAt)
+	stats.Countries = copyCounts(stats.Countries)
+	stats.DeviceClasses = copyCounts(stats.DeviceClasses)
+	s.userLoginDailyStats[stats.Day.Unix()] = stats
+
+	return true, nil
+}
+
+func (s *MemoryStore) LatestUserLoginDailyStatsDay(ctx DBOperationContext) (*time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *time.Time
+	for _, stats := range s.userLoginDailyStats {
+		if nil == latest || stats.Day.After(*latest) {
+			day := stats.Day
+			latest = &day
+		}
+	}
+
+	return latest, nil
+}
+
+func (s *MemoryStore) EarliestUserLoginTime(ctx DBOperationContext) (*time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var earliest *time.Time
+	for _, login := range s.userLogins {
+		if nil == earliest || login.LoggedInAt.Before(*earliest) {
+			loggedInAt := login.LoggedInAt
+			earliest = &loggedInAt
+		}
+	}
+
+	return earliest, nil
+}
+
+func (s *MemoryStore) DeleteUserLoginsBefore(ctx DBOperationContext, before time.Time, limit int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := make([]memoryUserLogin, 0, len(s.userLogins))
+	deleted := int64(0)
+	for _, login := range s.userLogins {
+		if deleted < limit && login.LoggedInAt.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, login)
+	}
+	s.userLogins = kept
+
+	return deleted, nil
+}
+
+func copyCounts(counts map[string]int64) map[string]int64 {
+	out := make(map[string]int64, len(counts))
+	for key, count := range counts {
+		out[key] = count
+	}
+
+	return out
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"sort"
+	"time"
+)
+
+// appendOutboxEvent must be called with the write lock held, alongside the
+// change the event describes.
+func (s *MemoryStore) appendOutboxEvent(event OutboxEvent) {
+	event.OccurredAt = storedTime(event.OccurredAt)
+	event.Payload = copyDetails(event.Payload)
+	s.outbox = append(s.outbox, &event)
+}
+
+func (s *MemoryStore) ListPendingOutboxEvents(ctx DBOperationContext, limit int64) ([]OutboxEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []OutboxEvent{}
+	for _, event := range s.outbox {
+		if nil != event.DeliveredAt {
+			continue
+		}
+		pending := *event
+		pending.Payload = copyDetails(event.Payload)
+		out = append(out, pending)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].OccurredAt.Equal(out[j].OccurredAt) {
+			return out[i].OccurredAt.Before(out[j].OccurredAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	if limit > 0 && int64(len(out)) > limit {
+		out = out[:limit]
+	}
+
+	return out, nil
+}
+
+func (s *MemoryStore) MarkOutboxEventDelivered(ctx DBOperationContext, eventID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range s.outbox {
+		if event.ID == eventID {
+			event.DeliveredAt = storedTimePtr(&at)
+			event.Attempts++
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) MarkOutboxEventFailed(ctx DBOperationContext, eventID string, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range s.outbox {
+		if event.ID == eventID && nil == event.DeliveredAt {
+			event.LastError = reason
+			event.Attempts++
+			return nil
+		}
+	}
+
+	return nil
+}
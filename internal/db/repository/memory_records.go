@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"sort"
+	"time"
+)
+
+func (s *MemoryStore) SaveNewUserLogin(ctx DBOperationContext, userLogin NewUserLoginToSave) error {
+	event, err := userLoggedInEvent(userLogin)
+	if nil != err {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userLogin.LoggedInAt = storedTime(userLogin.LoggedInAt)
+	if nil != userLogin.Location {
+		location := *userLogin.Location
+		userLogin.Location = &location
+	}
+	if nil != userLogin.Device {
+		device := *userLogin.Device
+		userLogin.Device = &device
+	}
+	s.userLogins = append(s.userLogins, memoryUserLogin{userLogin})
+	s.appendOutboxEvent(event)
+
+	return nil
+}
+
+// deleteUserLogins expects the caller to hold the write lock.
+func (s *MemoryStore) deleteUserLogins(userID string) int64 {
+	kept := make([]memoryUserLogin, 0, len(s.userLogins))
+	for _, login := range s.userLogins {
+		if login.UserID != userID {
+			kept = append(kept, login)
+		}
+	}
+	deleted := int64(len(s.userLogins) - len(kept))
+	s.userLogins = kept
+
+	return deleted
+}
+
+func (s *MemoryStore) SaveAuditEvent(ctx DBOperationContext, event AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.OccurredAt = storedTime(event.OccurredAt)
+	event.Details = copyDetails(event.Details)
+	s.auditEvents = append(s.auditEvents, event)
+
+	return nil
+}
+
+func (s *MemoryStore) QueryAuditEvents(ctx DBOperationContext, filter AuditEventsFilter) ([]AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := []AuditEvent{}
+	for _, event := range s.auditEvents {
+		if len(filter.Types) != 0 && !containsString(filter.Types, event.Type) {
+			continue
+		}
+		if len(filter.Outcome) != 0 && event.Outcome != filter.Outcome {
+			continue
+		}
+		if len(filter.ActorID) != 0 && event.ActorID != filter.ActorID {
+			continue
+		}
+		if len(filter.SubjectID) != 0 && event.SubjectID != filter.SubjectID {
+			continue
+		}
+		if nil != filter.From && event.OccurredAt.Before(*filter.From) {
+			continue
+		}
+		if nil != filter.To && !event.OccurredAt.Before(*filter.To) {
+			continue
+		}
+		if nil != filter.After {
+			before := event.OccurredAt.Before(filter.After.OccurredAt) ||
+				(event.OccurredAt.Equal(filter.After.OccurredAt) && event.ID < filter.After.ID)
+			if !before {
+				continue
+			}
+		}
+		event.Details = copyDetails(event.Details)
+		matched = append(matched, event)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].OccurredAt.Equal(matched[j].OccurredAt) {
+			return matched[i].OccurredAt.After(matched[j].OccurredAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+	if filter.Limit > 0 && int64(len(matched)) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+
+	return matched, nil
+}
+
+// ExportUserData builds the same plain documents Repo does, with the field
+// names used in MongoDB.
+func (s *MemoryStore) ExportUserData(ctx DBOperationContext, userID string) (*UserDataExport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotExists
+	}
+
+	logins := []memoryUserLogin{}
+	for _, login := range s.userLogins {
+		if login.UserID == userID {
+			logins = append(logins, login)
+		}
+	}
+	sort.SliceStable(logins, func(i, j int) bool {
+		return logins[i].LoggedInAt.Before(logins[j].LoggedInAt)
+	})
+
+	events := []AuditEvent{}
+	for _, event := range s.auditEvents {
+		if event.ActorID == userID || event.SubjectID == userID {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+
+	out := UserDataExport{
+		User:        user.plainDocument(),
+		Logins:      make([]map[string]interface{}, 0, len(logins)),
+		AuditEvents: make([]map[string]interface{}, 0, len(events)),
+	}
+	for _, login := range logins {
+		out.Logins = append(out.Logins, login.plainDocument())
+	}
+	for _, event := range events {
+		out.AuditEvents = append(out.AuditEvents, exportedAuditEvent(plainAuditEvent(event), userID))
+	}
+
+	return &out, nil
+}
+
+func (u *memoryUser) plainDocument() map[string]interface{} {
+	roles := make([]interface{}, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		roles = append(roles, role)
+	}
+	doc := map[string]interface{}{
+		"id":               u.ID,
+		"registered_at":    u.RegisteredAt,
+		"email":            u.Email,
+		"normalized_email": u.NormalizedEmail,
+		"first_name":       u.FirstName,
+		"last_name":        u.LastName,
+		"status":           u.Status,
+		"roles":            roles,
+		"version":          int64(u.Version),
+		"updated_at":       u.UpdatedAt,
+	}
+	if len(u.StatusReason) != 0 {
+		doc["status_reason"] = u.StatusReason
+	}
+	if nil != u.StatusExpiresAt {
+		doc["status_expires_at"] = *u.StatusExpiresAt
+	}
+	if nil != u.StatusChangedAt {
+		doc["status_changed_at"] = *u.StatusChangedAt
+	}
+	if nil != u.TokensNotBefore {
+		doc["tokens_not_before"] = *u.TokensNotBefore
+	}
+	if change := u.PendingEmailChange; nil != change {
+		doc["pending_email_change"] = map[string]interface{}{
+			"email":            change.Email,
+			"normalized_email": change.NormalizedEmail,
+			"requested_at":     change.RequestedAt,
+			"expires_at":       change.ExpiresAt,
+		}
+	}
+
+	return doc
+}
+
+func (l memoryUserLogin) plainDocument() map[string]interface{} {
+	user := map[string]interface{}{
+		"id":           l.UserID,
+		"ip":           l.UserIPAddress,
+		"device_agent": l.UserDeviceUserAgent,
+	}
+	if nil != l.Location {
+		user["location"] = map[string]interface{}{
+			"country_code":     l.Location.CountryCode,
+			"country_name":     l.Location.CountryName,
+			"city":             l.Location.City,
+			"asn":              int64(l.Location.ASN),
+			"asn_organization": l.Location.ASNOrganization,
+		}
+	}
+	if nil != l.Device {
+		user["device"] = map[string]interface{}{
+			"browser":         l.Device.Browser,
+			"browser_version": l.Device.BrowserVersion,
+			"os":              l.Device.OS,
+			"class":           l.Device.Class,
+		}
+	}
+
+	return map[string]interface{}{
+		"id":           l.ID,
+		"logged_in_at": l.LoggedInAt,
+		"user":         user,
+	}
+}
+
+func plainAuditEvent(event AuditEvent) map[string]interface{} {
+	doc := map[string]interface{}{
+		"id":          event.ID,
+		"type":        event.Type,
+		"outcome":     event.Outcome,
+		"occurred_at": event.OccurredAt,
+	}
+	optional := map[string]string{
+		"actor_id":   event.ActorID,
+		"subject_id": event.SubjectID,
+		"ip":         event.IPAddress,
+		"trace_id":   event.TraceID,
+	}
+	for key, value := range optional {
+		if len(value) != 0 {
+			doc[key] = value
+		}
+	}
+	if len(event.Details) != 0 {
+		details := make(map[string]interface{}, len(event.Details))
+		for key, value := range event.Details {
+			details[key] = value
+		}
+		doc["details"] = details
+	}
+
+	return doc
+}
+
+func (k *memoryAPIKey) toAPIKey() APIKey {
+	key := k.APIKey
+	key.Scopes = copyStrings(k.Scopes)
+	key.ExpiresAt = copyTime(k.ExpiresAt)
+	key.LastUsedAt = copyTime(k.LastUsedAt)
+	key.RevokedAt = copyTime(k.RevokedAt)
+	if nil == key.Scopes {
+		key.Scopes = []string{}
+	}
+
+	return key
+}
+
+func (s *MemoryStore) SaveNewAPIKey(ctx DBOperationContext, key NewAPIKeyToSave) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.apiKeys {
+		if existing.ID == key.ID || existing.Prefix == key.Prefix {
+			return errMemoryDuplicateKey
+		}
+	}
+
+	stored := memoryAPIKey{
+		APIKey:     key.APIKey,
+		SecretHash: key.SecretHash,
+	}
+	stored.Scopes = copyStrings(key.Scopes)
+	stored.CreatedAt = storedTime(key.CreatedAt)
+	stored.ExpiresAt = storedTimePtr(key.ExpiresAt)
+	stored.LastUsedAt = nil
+	stored.RevokedAt = nil
+	s.apiKeys = append(s.apiKeys, &stored)
+
+	return nil
+}
+
+func (s *MemoryStore) GetAPIKeyAuthenticationInfo(ctx DBOperationContext, prefix string) (*APIKeyAuthenticationInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.apiKeys {
+		if key.Prefix == prefix {
+			return &APIKeyAuthenticationInfo{
+				APIKey:     key.toAPIKey(),
+				SecretHash: key.SecretHash,
+			}, nil
+		}
+	}
+
+	return nil, ErrAPIKeyNotExists
+}
+
+func (s *MemoryStore) ListAPIKeys(ctx DBOperationContext, includeRevoked bool) ([]APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []APIKey{}
+	for _, key := range s.apiKeys {
+		if !includeRevoked && nil != key.RevokedAt {
+			continue
+		}
+		out = append(out, key.toAPIKey())
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+
+	return out, nil
+}
+
+func (s *MemoryStore) RevokeAPIKey(ctx DBOperationContext, keyID string, at time.Time) (*APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.apiKeys {
+		if key.ID != keyID {
+			continue
+		}
+		revokedAt := storedTime(at)
+		if nil == key.RevokedAt || revokedAt.Before(*key.RevokedAt) {
+			key.RevokedAt = &revokedAt
+		}
+
+		out := key.toAPIKey()
+		return &out, nil
+	}
+
+	return nil, ErrAPIKeyNotExists
+}
+
+func (s *MemoryStore) TouchAPIKey(ctx DBOperationContext, keyID string, at, staleBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.apiKeys {
+		if key.ID != keyID {
+			continue
+		}
+		if nil == key.LastUsedAt || key.LastUsedAt.Before(staleBefore) {
+			lastUsedAt := storedTime(at)
+			key.LastUsedAt = &lastUsedAt
+		}
+		return nil
+	}
+
+	return nil
+}
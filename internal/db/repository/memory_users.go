@@ -0,0 +1,526 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *MemoryStore) userByNormalizedEmail(normalizedEmail string) *memoryUser {
+	for _, userID := range s.userOrder {
+		if user := s.users[userID]; user.NormalizedEmail == normalizedEmail {
+			return user
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) SaveNewUser(ctx DBOperationContext, user NewUserToSave) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[user.ID]; exists {
+		return errMemoryDuplicateKey
+	}
+	if nil != s.userByNormalizedEmail(user.NormalizedEmail) {
+		return ErrEmailTaken
+	}
+	event, err := userRegisteredEvent(user)
+	if nil != err {
+		return err
+	}
+
+	registeredAt := storedTime(user.RegisteredAt)
+	s.users[user.ID] = &memoryUser{
+		ID:              user.ID,
+		Email:           user.Email,
+		NormalizedEmail: user.NormalizedEmail,
+		Password:        user.Password,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		RegisteredAt:    registeredAt,
+		Status:          UserStatusActive,
+		Roles:           []string{},
+		Version:         1,
+		UpdatedAt:       registeredAt,
+	}
+	s.userOrder = append(s.userOrder, user.ID)
+	s.appendOutboxEvent(event)
+
+	return nil
+}
+
+func (s *MemoryStore) GetUserLoginInfo(ctx DBOperationContext, email string) (*UserLoginInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, userID := range s.userOrder {
+		if user := s.users[userID]; user.Email == email {
+			return user.loginInfo(), nil
+		}
+	}
+
+	return nil, ErrUserNotExists
+}
+
+func (s *MemoryStore) GetUserLoginInfoByID(ctx DBOperationContext, userID string) (*UserLoginInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotExists
+	}
+
+	return user.loginInfo(), nil
+}
+
+func (u *memoryUser) loginInfo() *UserLoginInfo {
+	return &UserLoginInfo{
+		ID:       u.ID,
+		Password: u.Password,
+		Status:   u.statusInfo(),
+		Roles:    copyStrings(u.Roles),
+	}
+}
+
+func (s *MemoryStore) NormalizedEmailExists(ctx DBOperationContext, normalizedEmail, exceptPendingOfUserID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	for _, user := range s.users {
+		if user.NormalizedEmail == normalizedEmail {
+			return true, nil
+		}
+		if user.ID == exceptPendingOfUserID {
+			continue
+		}
+		if change := user.PendingEmailChange; nil != change && change.NormalizedEmail == normalizedEmail && change.ExpiresAt.After(now) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *MemoryStore) UserWithIDExists(ctx DBOperationContext, userID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.users[userID]
+	return exists, nil
+}
+
+func (s *MemoryStore) GetUserAuthenticationInfo(ctx DBOperationContext, userID string) (*UserAuthenticationInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotExists
+	}
+
+	return &UserAuthenticationInfo{
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Version:         user.Version,
+		Status:          user.statusInfo(),
+		TokensNotBefore: copyTime(user.TokensNotBefore),
+		Roles:           copyStrings(user.Roles),
+	}, nil
+}
+
+func (s *MemoryStore) GetUser(ctx DBOperationContext, userID string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotExists
+	}
+
+	out := user.toUser()
+	return &out, nil
+}
+
+func (s *MemoryStore) GetUsersByIDs(ctx DBOperationContext, userIDs []string) ([]User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []User{}
+	for _, userID := range s.userOrder {
+		if containsString(userIDs, userID) {
+			out = append(out, s.users[userID].toUser())
+		}
+	}
+
+	return out, nil
+}
+
+// matchesNameQuery matches either name starting with the query, ignoring
+// case, like the anchored case-insensitive regex of Repo.
+func matchesNameQuery(user *memoryUser, query string) bool {
+	prefix := strings.ToLower(strings.TrimSpace(query))
+	if len(prefix) == 0 {
+		return true
+	}
+
+	return strings.HasPrefix(strings.ToLower(user.FirstName), prefix) || strings.HasPrefix(strings.ToLower(user.LastName), prefix)
+}
+
+func (s *MemoryStore) ListUsers(ctx DBOperationContext, filter UsersFilter) ([]User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	matched := []*memoryUser{}
+	for _, userID := range s.userOrder {
+		user := s.users[userID]
+		if nil != filter.RegisteredFrom && user.RegisteredAt.Before(*filter.RegisteredFrom) {
+			continue
+		}
+		if nil != filter.RegisteredTo && !user.RegisteredAt.Before(*filter.RegisteredTo) {
+			continue
+		}
+		if len(filter.Status) != 0 && user.statusInfo().Effective(now) != filter.Status {
+			continue
+		}
+		if len(filter.EmailPrefix) != 0 && !strings.HasPrefix(user.NormalizedEmail, strings.ToLower(filter.EmailPrefix)) {
+			continue
+		}
+		if len(filter.EmailDomain) != 0 && !strings.HasSuffix(user.NormalizedEmail, "@"+strings.ToLower(filter.EmailDomain)) {
+			continue
+		}
+		if len(filter.NameQuery) != 0 && !matchesNameQuery(user, filter.NameQuery) {
+			continue
+		}
+		if nil != filter.After {
+			after := user.RegisteredAt.After(filter.After.RegisteredAt) ||
+				(user.RegisteredAt.Equal(filter.After.RegisteredAt) && user.ID > filter.After.ID)
+			if !after {
+				continue
+			}
+		}
+		matched = append(matched, user)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].RegisteredAt.Equal(matched[j].RegisteredAt) {
+			return matched[i].RegisteredAt.Before(matched[j].RegisteredAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+	if filter.Limit > 0 && int64(len(matched)) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+
+	out := make([]User, 0, len(matched))
+	for _, user := range matched {
+		out = append(out, user.toUser())
+	}
+
+	return out, nil
+}
+
+func (s *MemoryStore) UpdateUserProfile(ctx DBOperationContext, userID string, expectedVersion uint64, update UserProfileUpdate) (*User, error) {
+	changed := map[string]string{
+		"version": strconv.FormatUint(expectedVersion+1, 10),
+	}
+	if nil != update.FirstName {
+		changed["first_name"] = *update.FirstName
+	}
+	if nil != update.LastName {
+		changed["last_name"] = *update.LastName
+	}
+	event, err := userUpdatedEvent(userID, update.UpdatedAt, changed)
+	if nil != err {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotExists
+	}
+	if user.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+
+	user.Version = expectedVersion + 1
+	user.UpdatedAt = storedTime(update.UpdatedAt)
+	if nil != update.FirstName {
+		user.FirstName = *update.FirstName
+	}
+	if nil != update.LastName {
+		user.LastName = *update.LastName
+	}
+	s.appendOutboxEvent(event)
+
+	out := user.toUser()
+	return &out, nil
+}
+
+func (s *MemoryStore) SavePendingEmailChange(ctx DBOperationContext, userID string, change PendingEmailChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrUserNotExists
+	}
+
+	change.RequestedAt = storedTime(change.RequestedAt)
+	change.ExpiresAt = storedTime(change.ExpiresAt)
+	user.PendingEmailChange = &change
+
+	return nil
+}
+
+func (s *MemoryStore) ConfirmEmailChange(ctx DBOperationContext, tokenHash string, confirmedAt time.Time) (*ConfirmedEmailChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userID := range s.userOrder {
+		user := s.users[userID]
+		change := user.PendingEmailChange
+		if nil == change || change.TokenHash != tokenHash || !change.ExpiresAt.After(confirmedAt) {
+			continue
+		}
+		if holder := s.userByNormalizedEmail(change.NormalizedEmail); nil != holder && holder.ID != user.ID {
+			return nil, ErrEmailTaken
+		}
+		event, err := userUpdatedEvent(user.ID, confirmedAt, map[string]string{
+			"email": change.Email,
+		})
+		if nil != err {
+			return nil, err
+		}
+
+		confirmed := ConfirmedEmailChange{
+			UserID:   user.ID,
+			OldEmail: user.Email,
+			NewEmail: change.Email,
+		}
+		user.Email = change.Email
+		user.NormalizedEmail = change.NormalizedEmail
+		user.UpdatedAt = storedTime(confirmedAt)
+		user.Version++
+		user.PendingEmailChange = nil
+		s.appendOutboxEvent(event)
+
+		return &confirmed, nil
+	}
+
+	return nil, ErrEmailChangeNotExists
+}
+
+// statusIn reports whether the stored status is one of the given statuses,
+// counting a suspension that expired by now as active.
+func (u *memoryUser) statusIn(statuses []UserStatus, now time.Time) bool {
+	if containsString(statuses, u.Status) {
+		return true
+	}
+
+	return containsString(statuses, UserStatusActive) && u.statusInfo().Effective(now) == UserStatusActive
+}
+
+func (s *MemoryStore) ChangeUserStatus(ctx DBOperationContext, userID string, allowedFrom []UserStatus, change UserStatusChange) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotExists
+	}
+	if !user.statusIn(allowedFrom, change.ChangedAt) {
+		return nil, ErrUserStatusTransitionNotAllowed
+	}
+
+	changedAt := storedTime(change.ChangedAt)
+	user.Status = change.Status
+	user.StatusReason = change.Reason
+	user.StatusExpiresAt = storedTimePtr(change.ExpiresAt)
+	user.StatusChangedAt = &changedAt
+	user.UpdatedAt = changedAt
+	if change.RevokeTokens {
+		user.TokensNotBefore = &changedAt
+	}
+
+	out := user.toUser()
+	return &out, nil
+}
+
+func (s *MemoryStore) AddUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotExists
+	}
+	if !containsString(user.Roles, role) {
+		user.Roles = append(user.Roles, role)
+	}
+	user.UpdatedAt = storedTime(at)
+
+	out := user.toUser()
+	return &out, nil
+}
+
+func (s *MemoryStore) RemoveUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotExists
+	}
+	roles := make([]string, 0, len(user.Roles))
+	for _, held := range user.Roles {
+		if held != role {
+			roles = append(roles, held)
+		}
+	}
+	user.Roles = roles
+	user.UpdatedAt = storedTime(at)
+
+	out := user.toUser()
+	return &out, nil
+}
+
+func (s *MemoryStore) countUsersWithRole(role string) int64 {
+	var count int64
+	for _, user := range s.users {
+		if containsString(user.Roles, role) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (s *MemoryStore) CountUsersWithRole(ctx DBOperationContext, role string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.countUsersWithRole(role), nil
+}
+
+func (s *MemoryStore) BootstrapRole(ctx DBOperationContext, userID, role string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, bootstrapped := s.roleBootstraps[role]; bootstrapped {
+		return false, nil
+	}
+	if s.countUsersWithRole(role) > 0 {
+		s.roleBootstraps[role] = ""
+		return false, nil
+	}
+	user, exists := s.users[userID]
+	if !exists || user.Status != UserStatusActive {
+		return false, nil
+	}
+	user.Roles = append(user.Roles, role)
+	user.UpdatedAt = storedTime(at)
+	s.roleBootstraps[role] = userID
+
+	return true, nil
+}
+
+func (s *MemoryStore) isDueForPurge(user *memoryUser, dueBefore time.Time) bool {
+	return user.Status == UserStatusPendingDeletion && nil != user.StatusExpiresAt && !user.StatusExpiresAt.After(dueBefore)
+}
+
+func (s *MemoryStore) ListUsersDueForPurge(ctx DBOperationContext, dueBefore time.Time, limit int64) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	due := []*memoryUser{}
+	for _, userID := range s.userOrder {
+		if user := s.users[userID]; s.isDueForPurge(user, dueBefore) {
+			due = append(due, user)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].StatusExpiresAt.Before(*due[j].StatusExpiresAt)
+	})
+	if limit > 0 && int64(len(due)) > limit {
+		due = due[:limit]
+	}
+
+	out := make([]string, 0, len(due))
+	for _, user := range due {
+		out = append(out, user.ID)
+	}
+
+	return out, nil
+}
+
+func (s *MemoryStore) DeleteUser(ctx DBOperationContext, userID string, dueBefore time.Time) (*PurgedUser, error) {
+	event, err := userDeletedEvent(userID, time.Now(), "delete")
+	if nil != err {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists || !s.isDueForPurge(user, dueBefore) {
+		return nil, nil
+	}
+
+	delete(s.users, userID)
+	order := make([]string, 0, len(s.userOrder))
+	for _, id := range s.userOrder {
+		if id != userID {
+			order = append(order, id)
+		}
+	}
+	s.userOrder = order
+	deletedLogins := s.deleteUserLogins(userID)
+	s.appendOutboxEvent(event)
+
+	return &PurgedUser{DeletedLogins: deletedLogins}, nil
+}
+
+func (s *MemoryStore) AnonymizeUser(ctx DBOperationContext, userID string, dueBefore time.Time, anonymizedAt time.Time) (*PurgedUser, error) {
+	event, err := userDeletedEvent(userID, anonymizedAt, "anonymize")
+	if nil != err {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists || !s.isDueForPurge(user, dueBefore) {
+		return nil, nil
+	}
+
+	placeholderEmail := fmt.Sprintf("deleted-%s@users.invalid", userID)
+	at := storedTime(anonymizedAt)
+	user.Email = placeholderEmail
+	user.NormalizedEmail = placeholderEmail
+	user.Password = ""
+	user.FirstName = ""
+	user.LastName = ""
+	user.Roles = []string{}
+	user.Status = UserStatusDeleted
+	user.StatusChangedAt = &at
+	user.UpdatedAt = at
+	user.StatusReason = ""
+	user.StatusExpiresAt = nil
+	user.PendingEmailChange = nil
+	deletedLogins := s.deleteUserLogins(userID)
+	s.appendOutboxEvent(event)
+
+	return &PurgedUser{DeletedLogins: deletedLogins}, nil
+}
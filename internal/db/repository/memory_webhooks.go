@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"sort"
+	"time"
+)
+
+func (sub WebhookSubscription) copied() WebhookSubscription {
+	sub.Events = copyStrings(sub.Events)
+	if nil == sub.Events {
+		sub.Events = []string{}
+	}
+
+	return sub
+}
+
+func (s *MemoryStore) SaveNewWebhookSubscription(ctx DBOperationContext, subscription WebhookSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.webhooks {
+		if existing.ID == subscription.ID {
+			return errMemoryDuplicateKey
+		}
+	}
+
+	subscription = subscription.copied()
+	subscription.CreatedAt = storedTime(subscription.CreatedAt)
+	s.webhooks = append(s.webhooks, subscription)
+
+	return nil
+}
+
+func (s *MemoryStore) GetWebhookSubscription(ctx DBOperationContext, subscriptionID string) (*WebhookSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, subscription := range s.webhooks {
+		if subscription.ID == subscriptionID {
+			out := subscription.copied()
+			return &out, nil
+		}
+	}
+
+	return nil, ErrWebhookSubscriptionNotExists
+}
+
+func (s *MemoryStore) ListWebhookSubscriptions(ctx DBOperationContext) ([]WebhookSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]WebhookSubscription, 0, len(s.webhooks))
+	for _, subscription := range s.webhooks {
+		out = append(out, subscription.copied())
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+
+	return out, nil
+}
+
+func (s *MemoryStore) DeleteWebhookSubscription(ctx DBOperationContext, subscriptionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := make([]WebhookSubscription, 0, len(s.webhooks))
+	for _, subscription := range s.webhooks {
+		if subscription.ID != subscriptionID {
+			kept = append(kept, subscription)
+		}
+	}
+	if len(kept) == len(s.webhooks) {
+		return ErrWebhookSubscriptionNotExists
+	}
+	s.webhooks = kept
+
+	deliveries := make([]*WebhookDelivery, 0, len(s.webhookDeliveries))
+	for _, delivery := range s.webhookDeliveries {
+		if delivery.SubscriptionID != subscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	s.webhookDeliveries = deliveries
+
+	return nil
+}
+
+func (s *MemoryStore) SaveNewWebhookDeliveries(ctx DBOperationContext, deliveries []WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+next:
+	for _, delivery := range deliveries {
+		for _, existing := range s.webhookDeliveries {
+			if existing.ID == delivery.ID || (existing.SubscriptionID == delivery.SubscriptionID && existing.EventID == delivery.EventID) {
+				continue next
+			}
+		}
+
+		stored := delivery
+		stored.Status = WebhookDeliveryStatusPending
+		stored.Attempts = 0
+		stored.LastError = ""
+		stored.LastStatusCode = 0
+		stored.DeliveredAt = nil
+		stored.NextAttemptAt = storedTime(delivery.NextAttemptAt)
+		stored.CreatedAt = storedTime(delivery.CreatedAt)
+		s.webhookDeliveries = append(s.webhookDeliveries, &stored)
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) ClaimDueWebhookDelivery(ctx DBOperationContext, now, leaseUntil time.Time) (*WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due *WebhookDelivery
+	for _, delivery := range s.webhookDeliveries {
+		if delivery.Status != WebhookDeliveryStatusPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if nil == due || delivery.NextAttemptAt.Before(due.NextAttemptAt) {
+			due = delivery
+		}
+	}
+	if nil == due {
+		return nil, ErrWebhookDeliveryNotExists
+	}
+
+	due.NextAttemptAt = storedTime(leaseUntil)
+	out := *due
+	return &out, nil
+}
+
+func (s *MemoryStore) MarkWebhookDeliveryDelivered(ctx DBOperationContext, deliveryID string, statusCode int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range s.webhookDeliveries {
+		if delivery.ID == deliveryID {
+			delivery.Status = WebhookDeliveryStatusDelivered
+			delivery.LastStatusCode = statusCode
+			delivery.LastError = ""
+			delivery.DeliveredAt = storedTimePtr(&at)
+			delivery.Attempts++
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) MarkWebhookDeliveryFailed(ctx DBOperationContext, deliveryID string, failure WebhookDeliveryFailure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range s.webhookDeliveries {
+		if delivery.ID != deliveryID || delivery.Status != WebhookDeliveryStatusPending {
+			continue
+		}
+		delivery.LastError = failure.Reason
+		delivery.LastStatusCode = failure.StatusCode
+		if nil == failure.NextAttemptAt {
+			delivery.Status = WebhookDeliveryStatusDeadLetter
+		} else {
+			delivery.NextAttemptAt = storedTime(*failure.NextAttemptAt)
+		}
+		delivery.Attempts++
+		return nil
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) ListWebhookDeliveries(ctx DBOperationContext, filter WebhookDeliveriesFilter) ([]WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []WebhookDelivery{}
+	for _, delivery := range s.webhookDeliveries {
+		if len(filter.SubscriptionID) != 0 && delivery.SubscriptionID != filter.SubscriptionID {
+			continue
+		}
+		if len(filter.Status) != 0 && delivery.Status != filter.Status {
+			continue
+		}
+		out = append(out, *delivery)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	if filter.Limit > 0 && int64(len(out)) > filter.Limit {
+		out = out[:filter.Limit]
+	}
+
+	return out, nil
+}
+
+func (s *MemoryStore) RetryWebhookDelivery(ctx DBOperationContext, deliveryID string, at time.Time) (*WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range s.webhookDeliveries {
+		if delivery.ID != deliveryID {
+			continue
+		}
+		if delivery.Status != WebhookDeliveryStatusDeadLetter {
+			return nil, ErrWebhookDeliveryNotDeadLettered
+		}
+
+		delivery.Status = WebhookDeliveryStatusPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = storedTime(at)
+		out := *delivery
+		return &out, nil
+	}
+
+	return nil, ErrWebhookDeliveryNotExists
+}
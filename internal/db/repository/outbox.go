@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/id"
+)
+
+type OutboxEventType = string
+
+const (
+	OutboxEventTypeUserRegistered OutboxEventType = "user.registered"
+	OutboxEventTypeUserLoggedIn   OutboxEventType = "user.logged_in"
+	OutboxEventTypeUserUpdated    OutboxEventType = "user.updated"
+	OutboxEventTypeUserDeleted    OutboxEventType = "user.deleted"
+)
+
+type OutboxEventStatus = string
+
+const (
+	OutboxEventStatusPending   OutboxEventStatus = "pending"
+	OutboxEventStatusDelivered OutboxEventStatus = "delivered"
+)
+
+// OutboxEvent is written in the same transaction as the change it describes
+// and stays pending until the relay has published it.
+type OutboxEvent struct {
+	ID          string
+	Type        OutboxEventType
+	SubjectID   string
+	OccurredAt  time.Time
+	Payload     map[string]string
+	Attempts    uint
+	LastError   string
+	DeliveredAt *time.Time
+}
+
+type outboxEventDocument struct {
+	ID          string            `bson:"id"`
+	Type        string            `bson:"type"`
+	SubjectID   string            `bson:"subject_id"`
+	OccurredAt  time.Time         `bson:"occurred_at"`
+	Payload     map[string]string `bson:"payload"`
+	Status      string            `bson:"status"`
+	Attempts    int64             `bson:"attempts"`
+	LastError   string            `bson:"last_error,omitempty"`
+	DeliveredAt *time.Time        `bson:"delivered_at,omitempty"`
+}
+
+func (doc outboxEventDocument) toOutboxEvent() OutboxEvent {
+	return OutboxEvent{
+		ID:          doc.ID,
+		Type:        doc.Type,
+		SubjectID:   doc.SubjectID,
+		OccurredAt:  doc.OccurredAt,
+		Payload:     doc.Payload,
+		Attempts:    uint(doc.Attempts),
+		LastError:   doc.LastError,
+		DeliveredAt: doc.DeliveredAt,
+	}
+}
+
+func newOutboxEvent(eventType OutboxEventType, subjectID string, occurredAt time.Time, payload map[string]string) (OutboxEvent, error) {
+	eventID, err := id.GenerateOutboxEventID()
+	if nil != err {
+		return OutboxEvent{}, err
+	}
+
+	return OutboxEvent{
+		ID:         eventID,
+		Type:       eventType,
+		SubjectID:  subjectID,
+		OccurredAt: occurredAt,
+		Payload:    payload,
+	}, nil
+}
+
+func newOutboxEventDocument(event OutboxEvent) outboxEventDocument {
+	return outboxEventDocument{
+		ID:         event.ID,
+		Type:       event.Type,
+		SubjectID:  event.SubjectID,
+		OccurredAt: event.OccurredAt,
+		Payload:    event.Payload,
+		Status:     OutboxEventStatusPending,
+		Attempts:   0,
+	}
+}
+
+func userRegisteredEvent(user NewUserToSave) (OutboxEvent, error) {
+	return newOutboxEvent(OutboxEventTypeUserRegistered, user.ID, user.RegisteredAt, map[string]string{
+		"user_id":       user.ID,
+		"email":         user.Email,
+		"first_name":    user.FirstName,
+		"last_name":     user.LastName,
+		"registered_at": user.RegisteredAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func userLoggedInEvent(userLogin NewUserLoginToSave) (OutboxEvent, error) {
+	payload := map[string]string{
+		"user_id":      userLogin.UserID,
+		"login_id":     userLogin.ID,
+		"ip":           userLogin.UserIPAddress,
+		"logged_in_at": userLogin.LoggedInAt.UTC().Format(time.RFC3339Nano),
+	}
+	if nil != userLogin.Location {
+		payload["country_code"] = userLogin.Location.CountryCode
+		payload["city"] = userLogin.Location.City
+		payload["asn"] = strconv.FormatUint(uint64(userLogin.Location.ASN), 10)
+	}
+	if nil != userLogin.Device {
+		payload["device_class"] = userLogin.Device.Class
+	}
+
+	return newOutboxEvent(OutboxEventTypeUserLoggedIn, userLogin.UserID, userLogin.LoggedInAt, payload)
+}
+
+// userUpdatedEvent carries only the fields the update changed, next to the
+// user id and the update time.
+func userUpdatedEvent(userID string, updatedAt time.Time, changed map[string]string) (OutboxEvent, error) {
+	payload := map[string]string{
+		"user_id":    userID,
+		"updated_at": updatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	for key, value := range changed {
+		payload[key] = value
+	}
+
+	return newOutboxEvent(OutboxEventTypeUserUpdated, userID, updatedAt, payload)
+}
+
+// userDeletedEvent records how the account was purged: "delete" removes the
+// document, "anonymize" keeps a stripped one behind.
+func userDeletedEvent(userID string, deletedAt time.Time, mode string) (OutboxEvent, error) {
+	return newOutboxEvent(OutboxEventTypeUserDeleted, userID, deletedAt, map[string]string{
+		"user_id":    userID,
+		"mode":       mode,
+		"deleted_at": deletedAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// ListPendingOutboxEvents returns pending events oldest first, so events of
+// the same user are published in the order they happened.
+func (r *Repo) ListPendingOutboxEvents(ctx DBOperationContext, limit int64) ([]OutboxEvent, error) {
+	filter := bson.M{
+		"status": OutboxEventStatusPending,
+	}
+	opts := options.
+		Find().
+		SetSort(bson.D{{Key: "occurred_at", Value: 1}, {Key: "id", Value: 1}}).
+		SetLimit(limit).
+		SetProjection(bson.D{{Key: "_id", Value: 0}})
+
+	span := ctx.span.StartChild("query-pending-outbox-events")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.Outbox.Find(ctx, filter, opts)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_QUERY_OUTBOX_EVENTS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed querying pending outbox events")
+		return nil, errors.New("unable to query pending outbox events")
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-queried-outbox-events")
+	span.Status = sentry.SpanStatusOK
+	docs := []outboxEventDocument{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode outbox event documents")
+		return nil, errors.New("unable to decode queried outbox events")
+	}
+	span.Finish()
+
+	out := make([]OutboxEvent, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, doc.toOutboxEvent())
+	}
+
+	return out, nil
+}
+
+func (r *Repo) MarkOutboxEventDelivered(ctx DBOperationContext, eventID string, at time.Time) error {
+	filter := bson.M{
+		"id": eventID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       OutboxEventStatusDelivered,
+			"delivered_at": at,
+		},
+		"$inc": bson.M{"attempts": int64(1)},
+	}
+
+	span := ctx.span.StartChild("mark-outbox-event-delivered")
+	span.Status = sentry.SpanStatusOK
+	if _, err := r.collections.Outbox.UpdateOne(ctx, filter, update); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_MARK_OUTBOX_EVENT_DELIVERED")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed marking outbox event as delivered")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (r *Repo) MarkOutboxEventFailed(ctx DBOperationContext, eventID string, reason string) error {
+	filter := bson.M{
+		"id":     eventID,
+		"status": OutboxEventStatusPending,
+	}
+	update := bson.M{
+		"$set": bson.M{"last_error": reason},
+		"$inc": bson.M{"attempts": int64(1)},
+	}
+
+	span := ctx.span.StartChild("mark-outbox-event-failed")
+	span.Status = sentry.SpanStatusOK
+	if _, err := r.collections.Outbox.UpdateOne(ctx, filter, update); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_MARK_OUTBOX_EVENT_FAILED")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed recording outbox event publishing failure")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
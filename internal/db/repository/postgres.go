@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+// postgresUniqueViolation is the SQLSTATE of a unique constraint violation.
+const postgresUniqueViolation = "23505"
+
+// postgresUsersEmailIndex is the unique index on users.normalized_email.
+// Violations of other unique constraints, e.g. a colliding id, are not an
+// email being taken.
+const postgresUsersEmailIndex = "users_normalized_email_unique"
+
+// postgresRoleBootstrapsKey is the primary key of role_bootstraps.
+const postgresRoleBootstrapsKey = "role_bootstraps_pkey"
+
+// postgresDeliveredRetention is how long delivered outbox events and webhook
+// deliveries are kept, matching the TTL indexes of the MongoDB collections.
+// PostgreSQL has no TTL, so they are pruned while marking new ones delivered.
+const postgresDeliveredRetention = time.Hour * 24 * 7
+
+// postgresPruneBatchSize bounds how many delivered records one prune removes,
+// so a large backlog is worked off gradually instead of in one long delete.
+const postgresPruneBatchSize = 100
+
+// PostgresStore implements Store on PostgreSQL. Tables and columns mirror the
+// MongoDB collections and fields, and times are stored with the millisecond
+// precision MongoDB keeps, so both stores hand back identical records.
+type PostgresStore struct {
+	pool   *pgxpool.Pool
+	logger *logrus.Entry
+}
+
+func NewPostgresStore(logger *logrus.Entry, pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{
+		pool,
+		logger,
+	}
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+type postgresQuerier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+func (s *PostgresStore) inTransaction(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return s.pool.BeginFunc(ctx, fn)
+}
+
+// logError logs a failed query and tags the span with it, like every Repo
+// operation does.
+func (s *PostgresStore) logError(span *sentry.Span, err error, code, message string) {
+	span.Status = sentry.SpanStatusInternalError
+	log := s.logger.WithError(err).WithField("err_code", code)
+	apm.SetSpanTagsFromLogEntry(span, log)
+	log.Error(message)
+}
+
+func isPostgresUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != postgresUniqueViolation {
+		return false
+	}
+
+	return pgErr.ConstraintName == constraint
+}
+
+// postgresArgs collects query arguments while a query is being built and
+// hands out their placeholders.
+type postgresArgs []interface{}
+
+func (a *postgresArgs) add(value interface{}) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+func postgresWhere(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+func postgresLimit(args *postgresArgs, limit int64) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	return " LIMIT " + args.add(limit)
+}
+
+// escapeLikePattern escapes the LIKE wildcards in value, so it only matches
+// literally.
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func postgresTimePtr(t *time.Time) *time.Time {
+	if nil == t {
+		return nil
+	}
+
+	utc := t.UTC()
+	return &utc
+}
+
+func insertOutboxEvent(ctx context.Context, querier postgresQuerier, event OutboxEvent) error {
+	_, err := querier.Exec(
+		ctx,
+		"INSERT INTO outbox (id, type, subject_id, occurred_at, payload, status, attempts) VALUES ($1, $2, $3, $4, $5, $6, 0)",
+		event.ID,
+		event.Type,
+		event.SubjectID,
+		storedTime(event.OccurredAt),
+		event.Payload,
+		OutboxEventStatusPending,
+	)
+	return err
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func (s *PostgresStore) ComputeUserLoginDailyStats(ctx DBOperationContext, day time.Time) (*UserLoginDailyStats, error) {
+	day = storedTime(day)
+	end := day.Add(time.Hour * 24)
+	stats := &UserLoginDailyStats{
+		Day:           day,
+		Countries:     map[string]int64{},
+		DeviceClasses: map[string]int64{},
+	}
+
+	span := ctx.span.StartChild("aggregate-user-logins-of-day")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	err := s.pool.QueryRow(
+		ctx,
+		"SELECT count(*), count(DISTINCT user_id) FROM user_logins WHERE logged_in_at >= $1 AND logged_in_at < $2",
+		day,
+		end,
+	).Scan(&stats.Logins, &stats.Users)
+	if nil != err {
+		s.logError(span, err, "E_AGGREGATE_USER_LOGINS", "failed aggregating user login records")
+		return nil, errors.New("unable to aggregate user logins")
+	}
+
+	if err := s.countUserLoginsBy(ctx, "location_country_code", day, end, stats.Countries); nil != err {
+		s.logError(span, err, "E_AGGREGATE_USER_LOGINS", "failed aggregating user login records")
+		return nil, errors.New("unable to aggregate user logins")
+	}
+	if err := s.countUserLoginsBy(ctx, "device_class", day, end, stats.DeviceClasses); nil != err {
+		s.logError(span, err, "E_AGGREGATE_USER_LOGINS", "failed aggregating user login records")
+		return nil, errors.New("unable to aggregate user logins")
+	}
+
+	return stats, nil
+}
+
+// countUserLoginsBy adds the number of logins in [from, to) per value of the
+// column to counts.
+func (s *PostgresStore) countUserLoginsBy(ctx DBOperationContext, column string, from, to time.Time, counts map[string]int64) error {
+	rows, err := s.pool.Query(
+		ctx,
+		"SELECT "+column+", count(*) FROM user_logins WHERE logged_in_at >= $1 AND logged_in_at < $2 GROUP BY "+column,
+		from,
+		to,
+	)
+	if nil != err {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key *string
+		var logins int64
+		if err := rows.Scan(&key, &logins); nil != err {
+			return err
+		}
+		addLoginStatsBucket(counts, key, logins)
+	}
+
+	return rows.Err()
+}
+
+// SaveUserLoginDailyStats stores the stats unless the day was already
+// aggregated, and reports whether they were stored.
+func (s *PostgresStore) SaveUserLoginDailyStats(ctx DBOperationContext, stats UserLoginDailyStats) (bool, error) {
+	span := ctx.span.StartChild("insert-user-login-daily-stats")
+	span.Status = sentry.SpanStatusOK
+	tag, err := s.pool.Exec(
+		ctx,
+		"INSERT INTO user_login_daily_stats (day, logins, users, countries, device_classes, aggregated_at) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (day) DO NOTHING",
+		storedTime(stats.Day),
+		stats.Logins,
+		stats.Users,
+		stats.Countries,
+		stats.DeviceClasses,
+		storedTime(stats.AggregatedAt),
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_SAVE_USER_LOGIN_DAILY_STATS", "failed saving user login daily stats")
+		return false, err
+	}
+	span.Finish()
+
+	return tag.RowsAffected() != 0, nil
+}
+
+func (s *PostgresStore) LatestUserLoginDailyStatsDay(ctx DBOperationContext) (*time.Time, error) {
+	span := ctx.span.StartChild("query-latest-user-login-daily-stats")
+	span.Status = sentry.SpanStatusOK
+	var day *time.Time
+	if err := s.pool.QueryRow(ctx, "SELECT max(day) FROM user_login_daily_stats").Scan(&day); nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_RETRIEVE_USER_LOGIN_DAILY_STATS", "failed retrieving latest user login daily stats")
+		return nil, err
+	}
+	span.Finish()
+
+	return postgresTimePtr(day), nil
+}
+
+func (s *PostgresStore) EarliestUserLoginTime(ctx DBOperationContext) (*time.Time, error) {
+	span := ctx.span.StartChild("query-earliest-user-login")
+	span.Status = sentry.SpanStatusOK
+	var loggedInAt *time.Time
+	if err := s.pool.QueryRow(ctx, "SELECT min(logged_in_at) FROM user_logins").Scan(&loggedInAt); nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_RETRIEVE_USER_LOGIN", "failed retrieving earliest user login")
+		return nil, err
+	}
+	span.Finish()
+
+	return postgresTimePtr(loggedInAt), nil
+}
+
+// DeleteUserLoginsBefore removes at most limit logins older than the given
+// time. PostgreSQL has no TTL, so expired logins are removed this way.
+func (s *PostgresStore) DeleteUserLoginsBefore(ctx DBOperationContext, before time.Time, limit int64) (int64, error) {
+	span := ctx.span.StartChild("delete-expired-user-logins")
+	span.Status = sentry.SpanStatusOK
+	tag, err := s.pool.Exec(
+		ctx,
+		"DELETE FROM user_logins WHERE id IN (SELECT id FROM user_logins WHERE logged_in_at < $1 LIMIT $2)",
+		before,
+		limit,
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_DELETE_USER_LOGINS", "failed deleting expired user login records")
+		return 0, err
+	}
+	span.Finish()
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ListPendingOutboxEvents returns pending events oldest first, so events of
+// the same user are published in the order they happened.
+func (s *PostgresStore) ListPendingOutboxEvents(ctx DBOperationContext, limit int64) ([]OutboxEvent, error) {
+	args := postgresArgs{OutboxEventStatusPending}
+	sql := "SELECT id, type, subject_id, occurred_at, payload, attempts, last_error, delivered_at FROM outbox WHERE status = $1 ORDER BY occurred_at, id" +
+		postgresLimit(&args, limit)
+
+	span := ctx.span.StartChild("query-pending-outbox-events")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if nil != err {
+		s.logError(span, err, "E_QUERY_OUTBOX_EVENTS", "failed querying pending outbox events")
+		return nil, errors.New("unable to query pending outbox events")
+	}
+	defer rows.Close()
+
+	out := []OutboxEvent{}
+	for rows.Next() {
+		var event OutboxEvent
+		var attempts int64
+		if err := rows.Scan(&event.ID, &event.Type, &event.SubjectID, &event.OccurredAt, &event.Payload, &attempts, &event.LastError, &event.DeliveredAt); nil != err {
+			s.logError(span, err, "E_DECODE_DOCUMENT", "unable to decode outbox event rows")
+			return nil, errors.New("unable to decode queried outbox events")
+		}
+		event.Attempts = uint(attempts)
+		event.OccurredAt = event.OccurredAt.UTC()
+		event.DeliveredAt = postgresTimePtr(event.DeliveredAt)
+		out = append(out, event)
+	}
+	if err := rows.Err(); nil != err {
+		s.logError(span, err, "E_QUERY_OUTBOX_EVENTS", "failed querying pending outbox events")
+		return nil, errors.New("unable to query pending outbox events")
+	}
+
+	return out, nil
+}
+
+func (s *PostgresStore) MarkOutboxEventDelivered(ctx DBOperationContext, eventID string, at time.Time) error {
+	span := ctx.span.StartChild("mark-outbox-event-delivered")
+	span.Status = sentry.SpanStatusOK
+	_, err := s.pool.Exec(
+		ctx,
+		"UPDATE outbox SET status = $2, delivered_at = $3, attempts = attempts + 1 WHERE id = $1",
+		eventID,
+		OutboxEventStatusDelivered,
+		storedTime(at),
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_MARK_OUTBOX_EVENT_DELIVERED", "failed marking outbox event as delivered")
+		return err
+	}
+	span.Finish()
+
+	s.pruneDelivered(ctx, "outbox", at)
+
+	return nil
+}
+
+func (s *PostgresStore) MarkOutboxEventFailed(ctx DBOperationContext, eventID string, reason string) error {
+	span := ctx.span.StartChild("mark-outbox-event-failed")
+	span.Status = sentry.SpanStatusOK
+	_, err := s.pool.Exec(
+		ctx,
+		"UPDATE outbox SET last_error = $3, attempts = attempts + 1 WHERE id = $1 AND status = $2",
+		eventID,
+		OutboxEventStatusPending,
+		reason,
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_MARK_OUTBOX_EVENT_FAILED", "failed recording outbox event publishing failure")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+// pruneDelivered removes a batch of records of the table that were delivered
+// longer than the retention ago. Failing to prune only delays it to the next
+// delivery, so the error is logged and otherwise ignored.
+func (s *PostgresStore) pruneDelivered(ctx DBOperationContext, table string, now time.Time) {
+	span := ctx.span.StartChild("prune-delivered-" + table)
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	_, err := s.pool.Exec(
+		ctx,
+		"DELETE FROM "+table+" WHERE id IN (SELECT id FROM "+table+" WHERE delivered_at < $1 LIMIT $2)",
+		now.Add(-postgresDeliveredRetention),
+		postgresPruneBatchSize,
+	)
+	if nil != err {
+		span.Status = sentry.SpanStatusInternalError
+		s.logger.WithError(err).WithField("err_code", "E_PRUNE_DELIVERED").WithField("table", table).Warn("failed pruning delivered records")
+	}
+}
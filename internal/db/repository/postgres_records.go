@@ -0,0 +1,523 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v4"
+)
+
+func (s *PostgresStore) SaveNewUserLogin(ctx DBOperationContext, userLogin NewUserLoginToSave) error {
+	event, err := userLoggedInEvent(userLogin)
+	if nil != err {
+		return err
+	}
+
+	args := []interface{}{
+		userLogin.ID,
+		userLogin.UserID,
+		storedTime(userLogin.LoggedInAt),
+		userLogin.UserIPAddress,
+		userLogin.UserDeviceUserAgent,
+		nil, nil, nil, nil, nil,
+		nil, nil, nil, nil,
+	}
+	if location := userLogin.Location; nil != location {
+		args[5], args[6], args[7], args[8], args[9] = location.CountryCode, location.CountryName, location.City, int64(location.ASN), location.ASNOrganization
+	}
+	if device := userLogin.Device; nil != device {
+		args[10], args[11], args[12], args[13] = device.Browser, device.BrowserVersion, device.OS, device.Class
+	}
+
+	span := ctx.span.StartChild("insert-user-login-info")
+	span.Status = sentry.SpanStatusOK
+	err = s.inTransaction(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(
+			ctx,
+			`INSERT INTO user_logins (
+				id, user_id, logged_in_at, ip, device_agent,
+				location_country_code, location_country_name, location_city, location_asn, location_asn_organization,
+				device_browser, device_browser_version, device_os, device_class
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+			args...,
+		)
+		if nil != err {
+			return err
+		}
+
+		return insertOutboxEvent(ctx, tx, event)
+	})
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_SAVE_USER_LOGIN_ATTEMPT", "failed saving user login attempt record")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (s *PostgresStore) SaveAuditEvent(ctx DBOperationContext, event AuditEvent) error {
+	var details interface{}
+	if len(event.Details) != 0 {
+		details = event.Details
+	}
+
+	span := ctx.span.StartChild("insert-audit-event")
+	span.Status = sentry.SpanStatusOK
+	_, err := s.pool.Exec(
+		ctx,
+		"INSERT INTO audit_events (id, type, outcome, actor_id, subject_id, ip, trace_id, occurred_at, details) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		event.ID,
+		event.Type,
+		event.Outcome,
+		event.ActorID,
+		event.SubjectID,
+		event.IPAddress,
+		event.TraceID,
+		storedTime(event.OccurredAt),
+		details,
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_SAVE_AUDIT_EVENT", "failed saving audit event")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (s *PostgresStore) QueryAuditEvents(ctx DBOperationContext, filter AuditEventsFilter) ([]AuditEvent, error) {
+	args := postgresArgs{}
+	conditions := []string{}
+	if len(filter.Types) != 0 {
+		conditions = append(conditions, "type = ANY("+args.add(filter.Types)+")")
+	}
+	if len(filter.Outcome) != 0 {
+		conditions = append(conditions, "outcome = "+args.add(filter.Outcome))
+	}
+	if len(filter.ActorID) != 0 {
+		conditions = append(conditions, "actor_id = "+args.add(filter.ActorID))
+	}
+	if len(filter.SubjectID) != 0 {
+		conditions = append(conditions, "subject_id = "+args.add(filter.SubjectID))
+	}
+	if nil != filter.From {
+		conditions = append(conditions, "occurred_at >= "+args.add(*filter.From))
+	}
+	if nil != filter.To {
+		conditions = append(conditions, "occurred_at < "+args.add(*filter.To))
+	}
+	if nil != filter.After {
+		occurredAt := args.add(filter.After.OccurredAt)
+		conditions = append(conditions, fmt.Sprintf("(occurred_at < %s OR (occurred_at = %s AND id < %s))", occurredAt, occurredAt, args.add(filter.After.ID)))
+	}
+
+	sql := "SELECT id, type, outcome, actor_id, subject_id, ip, trace_id, occurred_at, details FROM audit_events" +
+		postgresWhere(conditions) +
+		" ORDER BY occurred_at DESC, id DESC" +
+		postgresLimit(&args, filter.Limit)
+
+	span := ctx.span.StartChild("query-audit-events")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	events, err := s.queryAuditEvents(ctx, sql, args...)
+	if nil != err {
+		s.logError(span, err, "E_QUERY_AUDIT_EVENTS", "failed querying audit events")
+		return nil, errors.New("unable to query audit events")
+	}
+
+	return events, nil
+}
+
+func (s *PostgresStore) queryAuditEvents(ctx DBOperationContext, sql string, args ...interface{}) ([]AuditEvent, error) {
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if nil != err {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []AuditEvent{}
+	for rows.Next() {
+		var event AuditEvent
+		if err := rows.Scan(&event.ID, &event.Type, &event.Outcome, &event.ActorID, &event.SubjectID, &event.IPAddress, &event.TraceID, &event.OccurredAt, &event.Details); nil != err {
+			return nil, err
+		}
+		event.OccurredAt = event.OccurredAt.UTC()
+		out = append(out, event)
+	}
+
+	return out, rows.Err()
+}
+
+// ExportUserData builds the same plain documents Repo does, with the field
+// names used in MongoDB.
+func (s *PostgresStore) ExportUserData(ctx DBOperationContext, userID string) (*UserDataExport, error) {
+	span := ctx.span.StartChild("query-user")
+	span.Status = sentry.SpanStatusOK
+	user, err := s.exportedUser(ctx, userID)
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrUserNotExists
+		}
+
+		s.logError(span, err, "E_RETRIEVE_USER_DOCUMENT", "failed retrieving user")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("query-user-logins")
+	span.Status = sentry.SpanStatusOK
+	logins, err := s.exportedUserLogins(ctx, userID)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_RETRIEVE_USER_LOGIN_DOCUMENTS", "failed retrieving user login records")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("query-user-audit-events")
+	span.Status = sentry.SpanStatusOK
+	events, err := s.queryAuditEvents(
+		NewDBOperationContext(ctx, span),
+		"SELECT id, type, outcome, actor_id, subject_id, ip, trace_id, occurred_at, details FROM audit_events WHERE actor_id = $1 OR subject_id = $1 ORDER BY occurred_at",
+		userID,
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_RETRIEVE_AUDIT_EVENT_DOCUMENTS", "failed retrieving user audit events")
+		return nil, err
+	}
+	span.Finish()
+
+	out := UserDataExport{
+		User:        user.plainDocument(),
+		Logins:      make([]map[string]interface{}, 0, len(logins)),
+		AuditEvents: make([]map[string]interface{}, 0, len(events)),
+	}
+	for _, login := range logins {
+		out.Logins = append(out.Logins, login.plainDocument())
+	}
+	for _, event := range events {
+		out.AuditEvents = append(out.AuditEvents, exportedAuditEvent(plainAuditEvent(event), userID))
+	}
+
+	return &out, nil
+}
+
+// exportedUser loads the user into a memoryUser, whose plain document leaves
+// out the password and the pending email change token hash.
+func (s *PostgresStore) exportedUser(ctx DBOperationContext, userID string) (*memoryUser, error) {
+	var user memoryUser
+	var version int64
+	var pendingEmail, pendingNormalizedEmail *string
+	var pendingRequestedAt, pendingExpiresAt *time.Time
+	err := s.pool.QueryRow(
+		ctx,
+		`SELECT id, email, normalized_email, first_name, last_name, registered_at, status, status_reason, status_expires_at, status_changed_at,
+			roles, version, updated_at, tokens_not_before, pending_email, pending_normalized_email, pending_requested_at, pending_expires_at
+		FROM users WHERE id = $1`,
+		userID,
+	).Scan(
+		&user.ID,
+		&user.Email,
+		&user.NormalizedEmail,
+		&user.FirstName,
+		&user.LastName,
+		&user.RegisteredAt,
+		&user.Status,
+		&user.StatusReason,
+		&user.StatusExpiresAt,
+		&user.StatusChangedAt,
+		&user.Roles,
+		&version,
+		&user.UpdatedAt,
+		&user.TokensNotBefore,
+		&pendingEmail,
+		&pendingNormalizedEmail,
+		&pendingRequestedAt,
+		&pendingExpiresAt,
+	)
+	if nil != err {
+		return nil, err
+	}
+
+	user.Version = uint64(version)
+	user.RegisteredAt = user.RegisteredAt.UTC()
+	user.UpdatedAt = user.UpdatedAt.UTC()
+	user.StatusExpiresAt = postgresTimePtr(user.StatusExpiresAt)
+	user.StatusChangedAt = postgresTimePtr(user.StatusChangedAt)
+	user.TokensNotBefore = postgresTimePtr(user.TokensNotBefore)
+	if nil != pendingEmail && nil != pendingNormalizedEmail && nil != pendingRequestedAt && nil != pendingExpiresAt {
+		user.PendingEmailChange = &PendingEmailChange{
+			Email:           *pendingEmail,
+			NormalizedEmail: *pendingNormalizedEmail,
+			RequestedAt:     pendingRequestedAt.UTC(),
+			ExpiresAt:       pendingExpiresAt.UTC(),
+		}
+	}
+
+	return &user, nil
+}
+
+// exportedUserLogins loads the logins of the user oldest first. A login has a
+// location exactly when its country code is set, and a device exactly when
+// its class is set.
+func (s *PostgresStore) exportedUserLogins(ctx DBOperationContext, userID string) ([]memoryUserLogin, error) {
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT id, user_id, logged_in_at, ip, device_agent,
+			location_country_code, location_country_name, location_city, location_asn, location_asn_organization,
+			device_browser, device_browser_version, device_os, device_class
+		FROM user_logins WHERE user_id = $1 ORDER BY logged_in_at`,
+		userID,
+	)
+	if nil != err {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []memoryUserLogin{}
+	for rows.Next() {
+		var login memoryUserLogin
+		var countryCode, countryName, city, asnOrganization *string
+		var asn *int64
+		var browser, browserVersion, os, class *string
+		err := rows.Scan(
+			&login.ID,
+			&login.UserID,
+			&login.LoggedInAt,
+			&login.UserIPAddress,
+			&login.UserDeviceUserAgent,
+			&countryCode,
+			&countryName,
+			&city,
+			&asn,
+			&asnOrganization,
+			&browser,
+			&browserVersion,
+			&os,
+			&class,
+		)
+		if nil != err {
+			return nil, err
+		}
+
+		login.LoggedInAt = login.LoggedInAt.UTC()
+		if nil != countryCode {
+			login.Location = &UserLoginLocation{
+				CountryCode:     *countryCode,
+				CountryName:     stringValue(countryName),
+				City:            stringValue(city),
+				ASNOrganization: stringValue(asnOrganization),
+			}
+			if nil != asn {
+				login.Location.ASN = uint(*asn)
+			}
+		}
+		if nil != class {
+			login.Device = &UserLoginDevice{
+				Browser:        stringValue(browser),
+				BrowserVersion: stringValue(browserVersion),
+				OS:             stringValue(os),
+				Class:          *class,
+			}
+		}
+		out = append(out, login)
+	}
+
+	return out, rows.Err()
+}
+
+func stringValue(value *string) string {
+	if nil == value {
+		return ""
+	}
+
+	return *value
+}
+
+// postgresAPIKeyColumns leaves out the secret hash, which only the key
+// verification ever needs to read.
+const postgresAPIKeyColumns = "id, prefix, name, scopes, created_by, created_at, expires_at, last_used_at, revoked_at"
+
+func scanPostgresAPIKey(row pgx.Row, extra ...interface{}) (APIKey, error) {
+	var key APIKey
+	dest := append([]interface{}{
+		&key.ID,
+		&key.Prefix,
+		&key.Name,
+		&key.Scopes,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	}, extra...)
+	if err := row.Scan(dest...); nil != err {
+		return APIKey{}, err
+	}
+
+	if nil == key.Scopes {
+		key.Scopes = []string{}
+	}
+	key.CreatedAt = key.CreatedAt.UTC()
+	key.ExpiresAt = postgresTimePtr(key.ExpiresAt)
+	key.LastUsedAt = postgresTimePtr(key.LastUsedAt)
+	key.RevokedAt = postgresTimePtr(key.RevokedAt)
+
+	return key, nil
+}
+
+func (s *PostgresStore) SaveNewAPIKey(ctx DBOperationContext, key NewAPIKeyToSave) error {
+	scopes := key.Scopes
+	if nil == scopes {
+		scopes = []string{}
+	}
+
+	span := ctx.span.StartChild("insert-api-key")
+	span.Status = sentry.SpanStatusOK
+	_, err := s.pool.Exec(
+		ctx,
+		"INSERT INTO api_keys (id, prefix, secret_hash, name, scopes, created_by, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		key.ID,
+		key.Prefix,
+		key.SecretHash,
+		key.Name,
+		scopes,
+		key.CreatedBy,
+		storedTime(key.CreatedAt),
+		storedTimePtr(key.ExpiresAt),
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_SAVE_API_KEY", "unable to save api key to database")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (s *PostgresStore) GetAPIKeyAuthenticationInfo(ctx DBOperationContext, prefix string) (*APIKeyAuthenticationInfo, error) {
+	var secretHash string
+
+	span := ctx.span.StartChild("query-api-key")
+	span.Status = sentry.SpanStatusOK
+	key, err := scanPostgresAPIKey(s.pool.QueryRow(ctx, "SELECT "+postgresAPIKeyColumns+", secret_hash FROM api_keys WHERE prefix = $1", prefix), &secretHash)
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrAPIKeyNotExists
+		}
+
+		s.logError(span, err, "E_RETRIEVE_API_KEY", "failed retrieving api key")
+		return nil, err
+	}
+	span.Finish()
+
+	return &APIKeyAuthenticationInfo{
+		APIKey:     key,
+		SecretHash: secretHash,
+	}, nil
+}
+
+func (s *PostgresStore) ListAPIKeys(ctx DBOperationContext, includeRevoked bool) ([]APIKey, error) {
+	sql := "SELECT " + postgresAPIKeyColumns + " FROM api_keys"
+	if !includeRevoked {
+		sql += " WHERE revoked_at IS NULL"
+	}
+	sql += " ORDER BY created_at DESC"
+
+	span := ctx.span.StartChild("query-api-keys")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	rows, err := s.pool.Query(ctx, sql)
+	if nil != err {
+		s.logError(span, err, "E_RETRIEVE_API_KEYS", "failed retrieving api keys")
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanPostgresAPIKey(rows)
+		if nil != err {
+			s.logError(span, err, "E_DECODE_DOCUMENT", "unable to decode api key rows")
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); nil != err {
+		s.logError(span, err, "E_RETRIEVE_API_KEYS", "failed retrieving api keys")
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks the key revoked. Revoking an already revoked key keeps
+// its original revocation time.
+func (s *PostgresStore) RevokeAPIKey(ctx DBOperationContext, keyID string, at time.Time) (*APIKey, error) {
+	span := ctx.span.StartChild("revoke-api-key")
+	span.Status = sentry.SpanStatusOK
+	key, err := scanPostgresAPIKey(s.pool.QueryRow(
+		ctx,
+		"UPDATE api_keys SET revoked_at = LEAST(revoked_at, $2) WHERE id = $1 RETURNING "+postgresAPIKeyColumns,
+		keyID,
+		storedTime(at),
+	))
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrAPIKeyNotExists
+		}
+
+		s.logError(span, err, "E_REVOKE_API_KEY", "failed revoking api key")
+		return nil, err
+	}
+	span.Finish()
+
+	return &key, nil
+}
+
+// TouchAPIKey records a use of the key. The timestamp is only written when the
+// stored one is older than staleBefore, so busy keys do not cost a write on
+// every request.
+func (s *PostgresStore) TouchAPIKey(ctx DBOperationContext, keyID string, at, staleBefore time.Time) error {
+	span := ctx.span.StartChild("touch-api-key")
+	span.Status = sentry.SpanStatusOK
+	_, err := s.pool.Exec(
+		ctx,
+		"UPDATE api_keys SET last_used_at = $2 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)",
+		keyID,
+		storedTime(at),
+		staleBefore,
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_TOUCH_API_KEY", "failed updating api key last use time")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
@@ -0,0 +1,743 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v4"
+)
+
+// postgresUserSummaryColumns are the users columns User is built from, in the
+// order scanPostgresUserSummary reads them.
+const postgresUserSummaryColumns = "id, first_name, last_name, email, registered_at, status, status_reason, status_expires_at, roles, version, updated_at"
+
+func scanPostgresUserSummary(row pgx.Row) (userDocument, error) {
+	var doc userDocument
+	err := row.Scan(
+		&doc.ID,
+		&doc.FirstName,
+		&doc.LastName,
+		&doc.Email,
+		&doc.RegisteredAt,
+		&doc.Status,
+		&doc.StatusReason,
+		&doc.StatusExpiresAt,
+		&doc.Roles,
+		&doc.Version,
+		&doc.UpdatedAt,
+	)
+	doc.RegisteredAt = doc.RegisteredAt.UTC()
+	doc.StatusExpiresAt = postgresTimePtr(doc.StatusExpiresAt)
+	doc.UpdatedAt = doc.UpdatedAt.UTC()
+
+	return doc, err
+}
+
+func (s *PostgresStore) queryUsers(ctx DBOperationContext, sql string, args ...interface{}) ([]User, error) {
+	span := ctx.span.StartChild("query-users")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if nil != err {
+		s.logError(span, err, "E_LIST_USERS", "failed listing users")
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []User{}
+	for rows.Next() {
+		doc, err := scanPostgresUserSummary(rows)
+		if nil != err {
+			s.logError(span, err, "E_DECODE_DOCUMENT", "unable to decode user rows")
+			return nil, err
+		}
+		out = append(out, doc.toUser())
+	}
+	if err := rows.Err(); nil != err {
+		s.logError(span, err, "E_LIST_USERS", "failed listing users")
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *PostgresStore) SaveNewUser(ctx DBOperationContext, user NewUserToSave) error {
+	event, err := userRegisteredEvent(user)
+	if nil != err {
+		return err
+	}
+
+	registeredAt := storedTime(user.RegisteredAt)
+
+	span := ctx.span.StartChild("insert-user")
+	span.Status = sentry.SpanStatusOK
+	err = s.inTransaction(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(
+			ctx,
+			`INSERT INTO users (id, email, normalized_email, password, first_name, last_name, registered_at, status, roles, version, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, '{}', 1, $7)`,
+			user.ID,
+			user.Email,
+			user.NormalizedEmail,
+			user.Password,
+			user.FirstName,
+			user.LastName,
+			registeredAt,
+			UserStatusActive,
+		)
+		if nil != err {
+			return err
+		}
+
+		return insertOutboxEvent(ctx, tx, event)
+	})
+	if nil != err {
+		defer span.Finish()
+
+		// The availability check during validation races with concurrent
+		// registrations; the unique index on normalized_email settles it.
+		if isPostgresUniqueViolation(err, postgresUsersEmailIndex) {
+			span.Status = sentry.SpanStatusAlreadyExists
+			return ErrEmailTaken
+		}
+
+		s.logError(span, err, "E_SAVE_USER", "unable to save user to database")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (s *PostgresStore) GetUserLoginInfo(ctx DBOperationContext, email string) (*UserLoginInfo, error) {
+	return s.getUserLoginInfo(ctx, "email", email)
+}
+
+func (s *PostgresStore) GetUserLoginInfoByID(ctx DBOperationContext, userID string) (*UserLoginInfo, error) {
+	return s.getUserLoginInfo(ctx, "id", userID)
+}
+
+func (s *PostgresStore) getUserLoginInfo(ctx DBOperationContext, column, value string) (*UserLoginInfo, error) {
+	var user userDocument
+
+	span := ctx.span.StartChild("query-user-login-info")
+	span.Status = sentry.SpanStatusOK
+	err := s.pool.
+		QueryRow(ctx, "SELECT id, password, roles, status, status_reason, status_expires_at FROM users WHERE "+column+" = $1 LIMIT 1", value).
+		Scan(&user.ID, &user.Password, &user.Roles, &user.Status, &user.StatusReason, &user.StatusExpiresAt)
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrUserNotExists
+		}
+
+		s.logError(span, err, "E_FIND_USER_LOGIN_INFO", "unable to retrieve user login information")
+		return nil, errors.New("unable to retrieve user login information")
+	}
+	span.Finish()
+	user.StatusExpiresAt = postgresTimePtr(user.StatusExpiresAt)
+
+	return &UserLoginInfo{
+		Password: user.Password,
+		ID:       user.ID,
+		Status:   user.statusInfo(),
+		Roles:    user.roles(),
+	}, nil
+}
+
+func (s *PostgresStore) NormalizedEmailExists(ctx DBOperationContext, normalizedEmail, exceptPendingOfUserID string) (bool, error) {
+	return s.userExists(
+		ctx,
+		"check-user-with-email-existence",
+		"normalized_email = $1 OR (pending_normalized_email = $1 AND pending_expires_at > $2 AND id <> $3)",
+		normalizedEmail,
+		time.Now(),
+		exceptPendingOfUserID,
+	)
+}
+
+func (s *PostgresStore) UserWithIDExists(ctx DBOperationContext, userID string) (bool, error) {
+	return s.userExists(ctx, "check-user-with-id-existence", "id = $1", userID)
+}
+
+func (s *PostgresStore) userExists(ctx DBOperationContext, operation, condition string, args ...interface{}) (bool, error) {
+	var exists bool
+
+	span := ctx.span.StartChild(operation)
+	span.Status = sentry.SpanStatusOK
+	if err := s.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE "+condition+")", args...).Scan(&exists); nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_RETRIEVE_USER_DOCUMENT", "failed retrieving user document")
+		return false, err
+	}
+	span.Finish()
+
+	return exists, nil
+}
+
+func (s *PostgresStore) GetUserAuthenticationInfo(ctx DBOperationContext, userID string) (*UserAuthenticationInfo, error) {
+	var user userDocument
+
+	span := ctx.span.StartChild("query-user-authentication-info")
+	span.Status = sentry.SpanStatusOK
+	err := s.pool.
+		QueryRow(ctx, "SELECT first_name, last_name, version, tokens_not_before, roles, status, status_reason, status_expires_at FROM users WHERE id = $1", userID).
+		Scan(&user.FirstName, &user.LastName, &user.Version, &user.TokensNotBefore, &user.Roles, &user.Status, &user.StatusReason, &user.StatusExpiresAt)
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrUserNotExists
+		}
+
+		s.logError(span, err, "E_RETRIEVE_USER_DOCUMENT", "failed retrieving user authentication information")
+		return nil, err
+	}
+	span.Finish()
+	user.StatusExpiresAt = postgresTimePtr(user.StatusExpiresAt)
+
+	return &UserAuthenticationInfo{
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Version:         user.version(),
+		Status:          user.statusInfo(),
+		TokensNotBefore: postgresTimePtr(user.TokensNotBefore),
+		Roles:           user.roles(),
+	}, nil
+}
+
+func (s *PostgresStore) GetUser(ctx DBOperationContext, userID string) (*User, error) {
+	span := ctx.span.StartChild("query-user")
+	span.Status = sentry.SpanStatusOK
+	doc, err := scanPostgresUserSummary(s.pool.QueryRow(ctx, "SELECT "+postgresUserSummaryColumns+" FROM users WHERE id = $1", userID))
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrUserNotExists
+		}
+
+		s.logError(span, err, "E_RETRIEVE_USER_DOCUMENT", "failed retrieving user")
+		return nil, err
+	}
+	span.Finish()
+
+	user := doc.toUser()
+	return &user, nil
+}
+
+func (s *PostgresStore) GetUsersByIDs(ctx DBOperationContext, userIDs []string) ([]User, error) {
+	return s.queryUsers(ctx, "SELECT "+postgresUserSummaryColumns+" FROM users WHERE id = ANY($1)", userIDs)
+}
+
+// postgresStatusCondition matches users by their effective status, so
+// suspensions that have already expired are listed as active.
+func postgresStatusCondition(args *postgresArgs, status UserStatus, now time.Time) string {
+	switch status {
+	case UserStatusActive:
+		return fmt.Sprintf("(status = %s OR (status = %s AND status_expires_at <= %s))", args.add(status), args.add(UserStatusSuspended), args.add(now))
+	case UserStatusSuspended:
+		return fmt.Sprintf("(status = %s AND (status_expires_at IS NULL OR status_expires_at > %s))", args.add(status), args.add(now))
+	default:
+		return "status = " + args.add(status)
+	}
+}
+
+func (s *PostgresStore) ListUsers(ctx DBOperationContext, filter UsersFilter) ([]User, error) {
+	args := postgresArgs{}
+	conditions := []string{}
+	if nil != filter.RegisteredFrom {
+		conditions = append(conditions, "registered_at >= "+args.add(*filter.RegisteredFrom))
+	}
+	if nil != filter.RegisteredTo {
+		conditions = append(conditions, "registered_at < "+args.add(*filter.RegisteredTo))
+	}
+	if len(filter.Status) != 0 {
+		conditions = append(conditions, postgresStatusCondition(&args, filter.Status, time.Now()))
+	}
+	if len(filter.EmailPrefix) != 0 {
+		conditions = append(conditions, "normalized_email LIKE "+args.add(escapeLikePattern(strings.ToLower(filter.EmailPrefix))+"%"))
+	}
+	if len(filter.EmailDomain) != 0 {
+		conditions = append(conditions, "normalized_email LIKE "+args.add("%@"+escapeLikePattern(strings.ToLower(filter.EmailDomain))))
+	}
+	if nameQuery := strings.TrimSpace(filter.NameQuery); len(nameQuery) != 0 {
+		// matches the expressions of the users_first_name_lower and
+		// users_last_name_lower indexes
+		prefix := args.add(escapeLikePattern(strings.ToLower(nameQuery)) + "%")
+		conditions = append(conditions, fmt.Sprintf("(lower(first_name) LIKE %s OR lower(last_name) LIKE %s)", prefix, prefix))
+	}
+	if nil != filter.After {
+		registeredAt := args.add(filter.After.RegisteredAt)
+		conditions = append(conditions, fmt.Sprintf("(registered_at > %s OR (registered_at = %s AND id > %s))", registeredAt, registeredAt, args.add(filter.After.ID)))
+	}
+
+	sql := "SELECT " + postgresUserSummaryColumns + " FROM users" + postgresWhere(conditions) + " ORDER BY registered_at, id" + postgresLimit(&args, filter.Limit)
+
+	return s.queryUsers(ctx, sql, args...)
+}
+
+func (s *PostgresStore) UpdateUserProfile(ctx DBOperationContext, userID string, expectedVersion uint64, update UserProfileUpdate) (*User, error) {
+	changed := map[string]string{
+		"version": strconv.FormatUint(expectedVersion+1, 10),
+	}
+	if nil != update.FirstName {
+		changed["first_name"] = *update.FirstName
+	}
+	if nil != update.LastName {
+		changed["last_name"] = *update.LastName
+	}
+	event, err := userUpdatedEvent(userID, update.UpdatedAt, changed)
+	if nil != err {
+		return nil, err
+	}
+
+	span := ctx.span.StartChild("update-user-profile")
+	span.Status = sentry.SpanStatusOK
+	var doc userDocument
+	err = s.inTransaction(ctx, func(tx pgx.Tx) error {
+		row := tx.QueryRow(
+			ctx,
+			`UPDATE users
+			SET version = $3, updated_at = $4, first_name = COALESCE($5, first_name), last_name = COALESCE($6, last_name)
+			WHERE id = $1 AND version = $2
+			RETURNING `+postgresUserSummaryColumns,
+			userID,
+			int64(expectedVersion),
+			int64(expectedVersion+1),
+			storedTime(update.UpdatedAt),
+			update.FirstName,
+			update.LastName,
+		)
+		var err error
+		if doc, err = scanPostgresUserSummary(row); nil != err {
+			return err
+		}
+
+		return insertOutboxEvent(ctx, tx, event)
+	})
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusAborted
+			exists, err := s.UserWithIDExists(NewDBOperationContext(ctx, span), userID)
+			if nil != err {
+				return nil, err
+			}
+			if !exists {
+				span.Status = sentry.SpanStatusNotFound
+				return nil, ErrUserNotExists
+			}
+
+			return nil, ErrVersionConflict
+		}
+
+		s.logError(span, err, "E_UPDATE_USER_PROFILE", "failed updating user profile")
+		return nil, err
+	}
+	span.Finish()
+
+	user := doc.toUser()
+	return &user, nil
+}
+
+func (s *PostgresStore) SavePendingEmailChange(ctx DBOperationContext, userID string, change PendingEmailChange) error {
+	span := ctx.span.StartChild("update-user-pending-email-change")
+	span.Status = sentry.SpanStatusOK
+	tag, err := s.pool.Exec(
+		ctx,
+		`UPDATE users
+		SET pending_email = $2, pending_normalized_email = $3, pending_token_hash = $4, pending_requested_at = $5, pending_expires_at = $6
+		WHERE id = $1`,
+		userID,
+		change.Email,
+		change.NormalizedEmail,
+		change.TokenHash,
+		storedTime(change.RequestedAt),
+		storedTime(change.ExpiresAt),
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_SAVE_PENDING_EMAIL_CHANGE", "failed saving pending email change")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusNotFound
+		return ErrUserNotExists
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (s *PostgresStore) ConfirmEmailChange(ctx DBOperationContext, tokenHash string, confirmedAt time.Time) (*ConfirmedEmailChange, error) {
+	var confirmed ConfirmedEmailChange
+
+	span := ctx.span.StartChild("swap-user-email")
+	span.Status = sentry.SpanStatusOK
+	err := s.inTransaction(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(
+			ctx,
+			`UPDATE users
+			SET email = users.pending_email, normalized_email = users.pending_normalized_email, updated_at = $2, version = users.version + 1,
+				pending_email = NULL, pending_normalized_email = NULL, pending_token_hash = NULL, pending_requested_at = NULL, pending_expires_at = NULL
+			FROM (SELECT id, email FROM users WHERE pending_token_hash = $1 AND pending_expires_at > $2 LIMIT 1 FOR UPDATE) AS previous
+			WHERE users.id = previous.id
+			RETURNING users.id, previous.email, users.email`,
+			tokenHash,
+			storedTime(confirmedAt),
+		).Scan(&confirmed.UserID, &confirmed.OldEmail, &confirmed.NewEmail)
+		if nil != err {
+			return err
+		}
+
+		event, err := userUpdatedEvent(confirmed.UserID, confirmedAt, map[string]string{
+			"email": confirmed.NewEmail,
+		})
+		if nil != err {
+			return err
+		}
+
+		return insertOutboxEvent(ctx, tx, event)
+	})
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrEmailChangeNotExists
+		}
+		if isPostgresUniqueViolation(err, postgresUsersEmailIndex) {
+			span.Status = sentry.SpanStatusAlreadyExists
+			return nil, ErrEmailTaken
+		}
+
+		s.logError(span, err, "E_CONFIRM_EMAIL_CHANGE", "failed swapping user email address")
+		return nil, err
+	}
+	span.Finish()
+
+	return &confirmed, nil
+}
+
+// postgresStatusInCondition matches rows whose stored status is one of the
+// given statuses, counting suspensions that expired by now as active.
+func postgresStatusInCondition(args *postgresArgs, statuses []UserStatus, now time.Time) string {
+	condition := "status = ANY(" + args.add(statuses) + ")"
+	if containsString(statuses, UserStatusActive) {
+		condition = fmt.Sprintf("(%s OR (status = %s AND status_expires_at <= %s))", condition, args.add(UserStatusSuspended), args.add(now))
+	}
+
+	return condition
+}
+
+func (s *PostgresStore) ChangeUserStatus(ctx DBOperationContext, userID string, allowedFrom []UserStatus, change UserStatusChange) (*User, error) {
+	changedAt := storedTime(change.ChangedAt)
+	args := postgresArgs{}
+	set := []string{
+		"status = " + args.add(change.Status),
+		"status_reason = " + args.add(change.Reason),
+		"status_expires_at = " + args.add(storedTimePtr(change.ExpiresAt)),
+		"status_changed_at = " + args.add(changedAt),
+		"updated_at = " + args.add(changedAt),
+	}
+	if change.RevokeTokens {
+		set = append(set, "tokens_not_before = "+args.add(changedAt))
+	}
+	sql := "UPDATE users SET " + strings.Join(set, ", ") +
+		" WHERE id = " + args.add(userID) + " AND " + postgresStatusInCondition(&args, allowedFrom, changedAt) +
+		" RETURNING " + postgresUserSummaryColumns
+
+	span := ctx.span.StartChild("update-user-status")
+	span.Status = sentry.SpanStatusOK
+	doc, err := scanPostgresUserSummary(s.pool.QueryRow(ctx, sql, args...))
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusFailedPrecondition
+			exists, err := s.UserWithIDExists(NewDBOperationContext(ctx, span), userID)
+			if nil != err {
+				return nil, err
+			}
+			if !exists {
+				span.Status = sentry.SpanStatusNotFound
+				return nil, ErrUserNotExists
+			}
+
+			return nil, ErrUserStatusTransitionNotAllowed
+		}
+
+		s.logError(span, err, "E_UPDATE_USER_STATUS", "failed updating user status")
+		return nil, err
+	}
+	span.Finish()
+
+	user := doc.toUser()
+	return &user, nil
+}
+
+func (s *PostgresStore) AddUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error) {
+	return s.updateUserRoles(ctx, "CASE WHEN roles @> ARRAY[$2::text] THEN roles ELSE array_append(roles, $2::text) END", userID, role, at)
+}
+
+func (s *PostgresStore) RemoveUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error) {
+	return s.updateUserRoles(ctx, "array_remove(roles, $2::text)", userID, role, at)
+}
+
+func (s *PostgresStore) updateUserRoles(ctx DBOperationContext, roles, userID, role string, at time.Time) (*User, error) {
+	span := ctx.span.StartChild("update-user-roles")
+	span.Status = sentry.SpanStatusOK
+	doc, err := scanPostgresUserSummary(s.pool.QueryRow(
+		ctx,
+		"UPDATE users SET roles = "+roles+", updated_at = $3 WHERE id = $1 RETURNING "+postgresUserSummaryColumns,
+		userID,
+		role,
+		storedTime(at),
+	))
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrUserNotExists
+		}
+
+		s.logError(span, err, "E_UPDATE_USER_ROLES", "failed updating user roles")
+		return nil, err
+	}
+	span.Finish()
+
+	user := doc.toUser()
+	return &user, nil
+}
+
+func (s *PostgresStore) CountUsersWithRole(ctx DBOperationContext, role string) (int64, error) {
+	var count int64
+
+	span := ctx.span.StartChild("count-users-with-role")
+	span.Status = sentry.SpanStatusOK
+	if err := s.pool.QueryRow(ctx, "SELECT count(*) FROM users WHERE roles @> ARRAY[$1::text]", role).Scan(&count); nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_COUNT_USERS_WITH_ROLE", "failed counting users with role")
+		return 0, err
+	}
+	span.Finish()
+
+	return count, nil
+}
+
+// BootstrapRole runs like Repo.BootstrapRole. When replicas bootstrap
+// concurrently, the primary key of role_bootstraps rolls back all but one.
+func (s *PostgresStore) BootstrapRole(ctx DBOperationContext, userID, role string, at time.Time) (bool, error) {
+	span := ctx.span.StartChild("bootstrap-role")
+	span.Status = sentry.SpanStatusOK
+	granted := false
+	err := s.inTransaction(ctx, func(tx pgx.Tx) error {
+		granted = false
+		var bootstrapped, held bool
+		err := tx.QueryRow(
+			ctx,
+			`SELECT EXISTS (SELECT 1 FROM role_bootstraps WHERE role = $1),
+				EXISTS (SELECT 1 FROM users WHERE roles @> ARRAY[$1::text])`,
+			role,
+		).Scan(&bootstrapped, &held)
+		if nil != err || bootstrapped {
+			return err
+		}
+
+		grantedTo := ""
+		if !held {
+			tag, err := tx.Exec(
+				ctx,
+				`UPDATE users SET roles = array_append(roles, $2::text), updated_at = $3
+				WHERE id = $1 AND status = $4 AND NOT roles @> ARRAY[$2::text]`,
+				userID,
+				role,
+				storedTime(at),
+				UserStatusActive,
+			)
+			if nil != err {
+				return err
+			}
+			if tag.RowsAffected() != 1 {
+				// no eligible account yet, so the bootstrap stays open
+				return nil
+			}
+			granted = true
+			grantedTo = userID
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			"INSERT INTO role_bootstraps (role, user_id, bootstrapped_at) VALUES ($1, $2, $3)",
+			role,
+			grantedTo,
+			storedTime(at),
+		)
+		return err
+	})
+	if nil != err {
+		defer span.Finish()
+
+		if isPostgresUniqueViolation(err, postgresRoleBootstrapsKey) {
+			span.Status = sentry.SpanStatusAlreadyExists
+			return false, nil
+		}
+
+		s.logError(span, err, "E_BOOTSTRAP_ROLE", "failed bootstrapping role")
+		return false, err
+	}
+	span.Finish()
+
+	return granted, nil
+}
+
+// postgresDueForPurgeCondition is the SQL counterpart of dueForPurgeFilter,
+// with the due time as its first argument.
+const postgresDueForPurgeCondition = "status = '" + UserStatusPendingDeletion + "' AND status_expires_at <= $1"
+
+func (s *PostgresStore) ListUsersDueForPurge(ctx DBOperationContext, dueBefore time.Time, limit int64) ([]string, error) {
+	args := postgresArgs{dueBefore}
+	sql := "SELECT id FROM users WHERE " + postgresDueForPurgeCondition + " ORDER BY status_expires_at" + postgresLimit(&args, limit)
+
+	span := ctx.span.StartChild("query-users-due-for-purge")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if nil != err {
+		s.logError(span, err, "E_RETRIEVE_USER_DOCUMENTS", "failed retrieving users due for purge")
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); nil != err {
+			s.logError(span, err, "E_DECODE_DOCUMENT", "unable to decode user rows")
+			return nil, err
+		}
+		out = append(out, userID)
+	}
+	if err := rows.Err(); nil != err {
+		s.logError(span, err, "E_RETRIEVE_USER_DOCUMENTS", "failed retrieving users due for purge")
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (s *PostgresStore) DeleteUser(ctx DBOperationContext, userID string, dueBefore time.Time) (*PurgedUser, error) {
+	event, err := userDeletedEvent(userID, time.Now(), "delete")
+	if nil != err {
+		return nil, err
+	}
+
+	span := ctx.span.StartChild("delete-user")
+	span.Status = sentry.SpanStatusOK
+	var purged *PurgedUser
+	err = s.inTransaction(ctx, func(tx pgx.Tx) error {
+		purged = nil
+		tag, err := tx.Exec(ctx, "DELETE FROM users WHERE "+postgresDueForPurgeCondition+" AND id = $2", dueBefore, userID)
+		if nil != err {
+			return err
+		}
+		if tag.RowsAffected() != 1 {
+			return nil
+		}
+
+		if purged, err = deletePostgresUserLogins(ctx, tx, userID); nil != err {
+			return err
+		}
+
+		return insertOutboxEvent(ctx, tx, event)
+	})
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_DELETE_USER", "failed deleting user")
+		return nil, err
+	}
+	span.Finish()
+
+	return purged, nil
+}
+
+// AnonymizeUser strips every personal field from the user row and marks it
+// deleted, keeping only the id so audit events still resolve to a record.
+func (s *PostgresStore) AnonymizeUser(ctx DBOperationContext, userID string, dueBefore time.Time, anonymizedAt time.Time) (*PurgedUser, error) {
+	placeholderEmail := fmt.Sprintf("deleted-%s@users.invalid", userID)
+	event, err := userDeletedEvent(userID, anonymizedAt, "anonymize")
+	if nil != err {
+		return nil, err
+	}
+
+	span := ctx.span.StartChild("anonymize-user")
+	span.Status = sentry.SpanStatusOK
+	var purged *PurgedUser
+	err = s.inTransaction(ctx, func(tx pgx.Tx) error {
+		purged = nil
+		tag, err := tx.Exec(
+			ctx,
+			`UPDATE users
+			SET email = $3, normalized_email = $3, password = '', first_name = '', last_name = '', roles = '{}',
+				status = $4, status_changed_at = $5, updated_at = $5, status_reason = '', status_expires_at = NULL,
+				pending_email = NULL, pending_normalized_email = NULL, pending_token_hash = NULL, pending_requested_at = NULL, pending_expires_at = NULL
+			WHERE `+postgresDueForPurgeCondition+` AND id = $2`,
+			dueBefore,
+			userID,
+			placeholderEmail,
+			UserStatusDeleted,
+			storedTime(anonymizedAt),
+		)
+		if nil != err {
+			return err
+		}
+		if tag.RowsAffected() != 1 {
+			return nil
+		}
+
+		if purged, err = deletePostgresUserLogins(ctx, tx, userID); nil != err {
+			return err
+		}
+
+		return insertOutboxEvent(ctx, tx, event)
+	})
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_ANONYMIZE_USER", "failed anonymizing user")
+		return nil, err
+	}
+	span.Finish()
+
+	return purged, nil
+}
+
+// deletePostgresUserLogins removes the login history of a user whose removal
+// was just written in the same transaction.
+func deletePostgresUserLogins(ctx DBOperationContext, querier postgresQuerier, userID string) (*PurgedUser, error) {
+	tag, err := querier.Exec(ctx, "DELETE FROM user_logins WHERE user_id = $1", userID)
+	if nil != err {
+		return nil, err
+	}
+
+	return &PurgedUser{DeletedLogins: tag.RowsAffected()}, nil
+}
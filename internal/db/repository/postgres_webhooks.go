@@ -0,0 +1,382 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v4"
+)
+
+const postgresWebhookDeliveryColumns = "id, subscription_id, event_id, event_type, body, status, attempts, next_attempt_at, last_error, last_status_code, created_at, delivered_at"
+
+func scanPostgresWebhookDelivery(row pgx.Row) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	var attempts int64
+	var statusCode int32
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Body,
+		&delivery.Status,
+		&attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastError,
+		&statusCode,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	)
+	if nil != err {
+		return WebhookDelivery{}, err
+	}
+
+	delivery.Attempts = uint(attempts)
+	delivery.LastStatusCode = int(statusCode)
+	delivery.NextAttemptAt = delivery.NextAttemptAt.UTC()
+	delivery.CreatedAt = delivery.CreatedAt.UTC()
+	delivery.DeliveredAt = postgresTimePtr(delivery.DeliveredAt)
+
+	return delivery, nil
+}
+
+func scanPostgresWebhookSubscription(row pgx.Row) (WebhookSubscription, error) {
+	var subscription WebhookSubscription
+	if err := row.Scan(&subscription.ID, &subscription.URL, &subscription.Events, &subscription.Secret, &subscription.CreatedBy, &subscription.CreatedAt); nil != err {
+		return WebhookSubscription{}, err
+	}
+
+	if nil == subscription.Events {
+		subscription.Events = []string{}
+	}
+	subscription.CreatedAt = subscription.CreatedAt.UTC()
+
+	return subscription, nil
+}
+
+func (s *PostgresStore) SaveNewWebhookSubscription(ctx DBOperationContext, subscription WebhookSubscription) error {
+	events := subscription.Events
+	if nil == events {
+		events = []string{}
+	}
+
+	span := ctx.span.StartChild("insert-webhook-subscription")
+	span.Status = sentry.SpanStatusOK
+	_, err := s.pool.Exec(
+		ctx,
+		"INSERT INTO webhooks (id, url, events, secret, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		subscription.ID,
+		subscription.URL,
+		events,
+		subscription.Secret,
+		subscription.CreatedBy,
+		storedTime(subscription.CreatedAt),
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_SAVE_WEBHOOK_SUBSCRIPTION", "unable to save webhook subscription to database")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (s *PostgresStore) GetWebhookSubscription(ctx DBOperationContext, subscriptionID string) (*WebhookSubscription, error) {
+	span := ctx.span.StartChild("query-webhook-subscription")
+	span.Status = sentry.SpanStatusOK
+	subscription, err := scanPostgresWebhookSubscription(s.pool.QueryRow(ctx, "SELECT id, url, events, secret, created_by, created_at FROM webhooks WHERE id = $1", subscriptionID))
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrWebhookSubscriptionNotExists
+		}
+
+		s.logError(span, err, "E_RETRIEVE_WEBHOOK_SUBSCRIPTION", "failed retrieving webhook subscription")
+		return nil, err
+	}
+	span.Finish()
+
+	return &subscription, nil
+}
+
+func (s *PostgresStore) ListWebhookSubscriptions(ctx DBOperationContext) ([]WebhookSubscription, error) {
+	span := ctx.span.StartChild("query-webhook-subscriptions")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	rows, err := s.pool.Query(ctx, "SELECT id, url, events, secret, created_by, created_at FROM webhooks ORDER BY created_at DESC")
+	if nil != err {
+		s.logError(span, err, "E_RETRIEVE_WEBHOOK_SUBSCRIPTIONS", "failed retrieving webhook subscriptions")
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []WebhookSubscription{}
+	for rows.Next() {
+		subscription, err := scanPostgresWebhookSubscription(rows)
+		if nil != err {
+			s.logError(span, err, "E_DECODE_DOCUMENT", "unable to decode webhook subscription rows")
+			return nil, err
+		}
+		out = append(out, subscription)
+	}
+	if err := rows.Err(); nil != err {
+		s.logError(span, err, "E_RETRIEVE_WEBHOOK_SUBSCRIPTIONS", "failed retrieving webhook subscriptions")
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// DeleteWebhookSubscription removes the subscription together with its
+// deliveries, including the dead-lettered ones.
+func (s *PostgresStore) DeleteWebhookSubscription(ctx DBOperationContext, subscriptionID string) error {
+	span := ctx.span.StartChild("delete-webhook-subscription")
+	span.Status = sentry.SpanStatusOK
+	var deleted bool
+	err := s.inTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "DELETE FROM webhooks WHERE id = $1", subscriptionID)
+		if nil != err {
+			return err
+		}
+		if deleted = tag.RowsAffected() == 1; !deleted {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM webhook_deliveries WHERE subscription_id = $1", subscriptionID)
+		return err
+	})
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_DELETE_WEBHOOK_SUBSCRIPTION", "failed deleting webhook subscription")
+		return err
+	}
+	if !deleted {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusNotFound
+		return ErrWebhookSubscriptionNotExists
+	}
+	span.Finish()
+
+	return nil
+}
+
+// SaveNewWebhookDeliveries skips deliveries of an event a subscription already
+// has, so an outbox event published twice is still delivered once.
+func (s *PostgresStore) SaveNewWebhookDeliveries(ctx DBOperationContext, deliveries []WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, delivery := range deliveries {
+		batch.Queue(
+			`INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, body, status, attempts, next_attempt_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8)
+			ON CONFLICT DO NOTHING`,
+			delivery.ID,
+			delivery.SubscriptionID,
+			delivery.EventID,
+			delivery.EventType,
+			delivery.Body,
+			WebhookDeliveryStatusPending,
+			storedTime(delivery.NextAttemptAt),
+			storedTime(delivery.CreatedAt),
+		)
+	}
+
+	span := ctx.span.StartChild("insert-webhook-deliveries")
+	span.Status = sentry.SpanStatusOK
+	results := s.pool.SendBatch(ctx, batch)
+	var err error
+	for range deliveries {
+		if _, err = results.Exec(); nil != err {
+			break
+		}
+	}
+	if closeErr := results.Close(); nil == err {
+		err = closeErr
+	}
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_SAVE_WEBHOOK_DELIVERIES", "unable to save webhook deliveries to database")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+// ClaimDueWebhookDelivery picks the pending delivery that is due the longest
+// and pushes its next attempt to leaseUntil, so no other dispatcher picks it up
+// meanwhile. Deliveries another dispatcher is claiming right now are skipped
+// instead of waited for.
+func (s *PostgresStore) ClaimDueWebhookDelivery(ctx DBOperationContext, now, leaseUntil time.Time) (*WebhookDelivery, error) {
+	span := ctx.span.StartChild("claim-webhook-delivery")
+	span.Status = sentry.SpanStatusOK
+	delivery, err := scanPostgresWebhookDelivery(s.pool.QueryRow(
+		ctx,
+		`UPDATE webhook_deliveries SET next_attempt_at = $3
+		WHERE id = (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $1 AND next_attempt_at <= $2
+			ORDER BY next_attempt_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+postgresWebhookDeliveryColumns,
+		WebhookDeliveryStatusPending,
+		now,
+		storedTime(leaseUntil),
+	))
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrWebhookDeliveryNotExists
+		}
+
+		s.logError(span, err, "E_CLAIM_WEBHOOK_DELIVERY", "failed claiming due webhook delivery")
+		return nil, err
+	}
+	span.Finish()
+
+	return &delivery, nil
+}
+
+func (s *PostgresStore) MarkWebhookDeliveryDelivered(ctx DBOperationContext, deliveryID string, statusCode int, at time.Time) error {
+	span := ctx.span.StartChild("mark-webhook-delivery-delivered")
+	span.Status = sentry.SpanStatusOK
+	_, err := s.pool.Exec(
+		ctx,
+		"UPDATE webhook_deliveries SET status = $2, last_status_code = $3, delivered_at = $4, last_error = '', attempts = attempts + 1 WHERE id = $1",
+		deliveryID,
+		WebhookDeliveryStatusDelivered,
+		int32(statusCode),
+		storedTime(at),
+	)
+	if nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_MARK_WEBHOOK_DELIVERY_DELIVERED", "failed marking webhook delivery as delivered")
+		return err
+	}
+	span.Finish()
+
+	s.pruneDelivered(ctx, "webhook_deliveries", at)
+
+	return nil
+}
+
+func (s *PostgresStore) MarkWebhookDeliveryFailed(ctx DBOperationContext, deliveryID string, failure WebhookDeliveryFailure) error {
+	args := postgresArgs{deliveryID, WebhookDeliveryStatusPending, failure.Reason, int32(failure.StatusCode)}
+	set := "last_error = $3, last_status_code = $4, attempts = attempts + 1"
+	if nil == failure.NextAttemptAt {
+		set += ", status = " + args.add(WebhookDeliveryStatusDeadLetter)
+	} else {
+		set += ", next_attempt_at = " + args.add(storedTime(*failure.NextAttemptAt))
+	}
+
+	span := ctx.span.StartChild("mark-webhook-delivery-failed")
+	span.Status = sentry.SpanStatusOK
+	if _, err := s.pool.Exec(ctx, "UPDATE webhook_deliveries SET "+set+" WHERE id = $1 AND status = $2", args...); nil != err {
+		defer span.Finish()
+
+		s.logError(span, err, "E_MARK_WEBHOOK_DELIVERY_FAILED", "failed recording webhook delivery failure")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+// ListWebhookDeliveries returns the newest deliveries first.
+func (s *PostgresStore) ListWebhookDeliveries(ctx DBOperationContext, filter WebhookDeliveriesFilter) ([]WebhookDelivery, error) {
+	args := postgresArgs{}
+	conditions := []string{}
+	if len(filter.SubscriptionID) != 0 {
+		conditions = append(conditions, "subscription_id = "+args.add(filter.SubscriptionID))
+	}
+	if len(filter.Status) != 0 {
+		conditions = append(conditions, "status = "+args.add(filter.Status))
+	}
+	sql := "SELECT " + postgresWebhookDeliveryColumns + " FROM webhook_deliveries" +
+		postgresWhere(conditions) +
+		" ORDER BY created_at DESC, id DESC" +
+		postgresLimit(&args, filter.Limit)
+
+	span := ctx.span.StartChild("query-webhook-deliveries")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if nil != err {
+		s.logError(span, err, "E_RETRIEVE_WEBHOOK_DELIVERIES", "failed retrieving webhook deliveries")
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanPostgresWebhookDelivery(rows)
+		if nil != err {
+			s.logError(span, err, "E_DECODE_DOCUMENT", "unable to decode webhook delivery rows")
+			return nil, err
+		}
+		out = append(out, delivery)
+	}
+	if err := rows.Err(); nil != err {
+		s.logError(span, err, "E_RETRIEVE_WEBHOOK_DELIVERIES", "failed retrieving webhook deliveries")
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// RetryWebhookDelivery moves a dead-lettered delivery back to pending with a
+// fresh attempt budget, due at the given time.
+func (s *PostgresStore) RetryWebhookDelivery(ctx DBOperationContext, deliveryID string, at time.Time) (*WebhookDelivery, error) {
+	span := ctx.span.StartChild("retry-webhook-delivery")
+	span.Status = sentry.SpanStatusOK
+	delivery, err := scanPostgresWebhookDelivery(s.pool.QueryRow(
+		ctx,
+		"UPDATE webhook_deliveries SET status = $3, attempts = 0, next_attempt_at = $4 WHERE id = $1 AND status = $2 RETURNING "+postgresWebhookDeliveryColumns,
+		deliveryID,
+		WebhookDeliveryStatusDeadLetter,
+		WebhookDeliveryStatusPending,
+		storedTime(at),
+	))
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.Status = sentry.SpanStatusAborted
+			var exists bool
+			if err := s.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM webhook_deliveries WHERE id = $1)", deliveryID).Scan(&exists); nil != err {
+				s.logError(span, err, "E_RETRIEVE_WEBHOOK_DELIVERY", "failed checking webhook delivery existence")
+				return nil, err
+			}
+			if !exists {
+				span.Status = sentry.SpanStatusNotFound
+				return nil, ErrWebhookDeliveryNotExists
+			}
+
+			return nil, ErrWebhookDeliveryNotDeadLettered
+		}
+
+		s.logError(span, err, "E_RETRY_WEBHOOK_DELIVERY", "failed moving webhook delivery back to pending")
+		return nil, err
+	}
+	span.Finish()
+
+	return &delivery, nil
+}
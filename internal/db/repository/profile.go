@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+var (
+	ErrVersionConflict = errors.New("user document version does not match")
+)
+
+type UserProfileUpdate struct {
+	FirstName *string
+	LastName  *string
+	UpdatedAt time.Time
+}
+
+// versionFilter matches documents at the expected version. Documents written
+// before versioning was introduced carry no version and count as version 1.
+func versionFilter(expectedVersion uint64) bson.M {
+	if expectedVersion == 1 {
+		return bson.M{"$or": bson.A{
+			bson.M{"version": int64(1)},
+			bson.M{"version": bson.M{"$exists": false}},
+		}}
+	}
+
+	return bson.M{"version": int64(expectedVersion)}
+}
+
+func (r *Repo) UpdateUserProfile(ctx DBOperationContext, userID string, expectedVersion uint64, update UserProfileUpdate) (*User, error) {
+	filter := bson.M{
+		"$and": bson.A{
+			bson.M{"id": userID},
+			versionFilter(expectedVersion),
+		},
+	}
+	set := bson.M{
+		"version":    int64(expectedVersion + 1),
+		"updated_at": update.UpdatedAt,
+	}
+	if nil != update.FirstName {
+		set["first_name"] = *update.FirstName
+	}
+	if nil != update.LastName {
+		set["last_name"] = *update.LastName
+	}
+	changed := map[string]string{
+		"version": strconv.FormatUint(expectedVersion+1, 10),
+	}
+	if nil != update.FirstName {
+		changed["first_name"] = *update.FirstName
+	}
+	if nil != update.LastName {
+		changed["last_name"] = *update.LastName
+	}
+	event, err := userUpdatedEvent(userID, update.UpdatedAt, changed)
+	if nil != err {
+		return nil, err
+	}
+	opts := options.
+		FindOneAndUpdate().
+		SetProjection(userSummaryProjection).
+		SetReturnDocument(options.After)
+
+	span := ctx.span.StartChild("update-user-profile")
+	span.Status = sentry.SpanStatusOK
+	var doc userDocument
+	err = r.inTransaction(ctx, func(ctx context.Context) error {
+		if err := r.collections.Users.FindOneAndUpdate(ctx, filter, bson.M{"$set": set}, opts).Decode(&doc); nil != err {
+			return err
+		}
+
+		_, err := r.collections.Outbox.InsertOne(ctx, newOutboxEventDocument(event))
+		return err
+	})
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusAborted
+			exists, err := r.UserWithIDExists(NewDBOperationContext(ctx, span), userID)
+			if nil != err {
+				return nil, err
+			}
+			if !exists {
+				span.Status = sentry.SpanStatusNotFound
+				return nil, ErrUserNotExists
+			}
+
+			return nil, ErrVersionConflict
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_UPDATE_USER_PROFILE")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed updating user profile")
+		return nil, err
+	}
+	span.Finish()
+
+	user := doc.toUser()
+	return &user, nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+// PurgedUser describes a removed account. DeleteUser and AnonymizeUser
+// return nil instead when the account is no longer due for purge.
+type PurgedUser struct {
+	DeletedLogins int64
+}
+
+// dueForPurgeFilter matches accounts whose deletion grace period ended before
+// the given time. The status is part of every purge filter so an account that
+// has logged back in meanwhile is never purged.
+func dueForPurgeFilter(userID string, dueBefore time.Time) bson.M {
+	filter := bson.M{
+		"status":            UserStatusPendingDeletion,
+		"status_expires_at": bson.M{"$lte": dueBefore},
+	}
+	if len(userID) != 0 {
+		filter["id"] = userID
+	}
+
+	return filter
+}
+
+func (r *Repo) ListUsersDueForPurge(ctx DBOperationContext, dueBefore time.Time, limit int64) ([]string, error) {
+	opts := options.
+		Find().
+		SetSort(bson.D{{Key: "status_expires_at", Value: 1}}).
+		SetLimit(limit).
+		SetProjection(bson.D{
+			bson.E{Key: "_id", Value: 0},
+			bson.E{Key: "id", Value: 1},
+		})
+
+	span := ctx.span.StartChild("query-users-due-for-purge")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.Users.Find(ctx, dueForPurgeFilter("", dueBefore), opts)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_USER_DOCUMENTS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving users due for purge")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-queried-users")
+	span.Status = sentry.SpanStatusOK
+	docs := []struct {
+		ID string `bson:"id"`
+	}{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode user documents")
+		return nil, err
+	}
+	span.Finish()
+
+	out := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, doc.ID)
+	}
+
+	return out, nil
+}
+
+// DeleteUser removes the user document and then its login history. Without
+// transaction support a failure in between leaves the logins behind, until
+// the login retention expires them.
+func (r *Repo) DeleteUser(ctx DBOperationContext, userID string, dueBefore time.Time) (*PurgedUser, error) {
+	event, err := userDeletedEvent(userID, time.Now(), "delete")
+	if nil != err {
+		return nil, err
+	}
+
+	span := ctx.span.StartChild("delete-user")
+	span.Status = sentry.SpanStatusOK
+	var purged *PurgedUser
+	err = r.inTransaction(ctx, func(ctx context.Context) error {
+		purged = nil
+		result, err := r.collections.Users.DeleteOne(ctx, dueForPurgeFilter(userID, dueBefore))
+		if nil != err {
+			return err
+		}
+		if result.DeletedCount != 1 {
+			return nil
+		}
+
+		purged, err = r.deleteUserLogins(ctx, userID)
+		if nil != err {
+			return err
+		}
+
+		_, err = r.collections.Outbox.InsertOne(ctx, newOutboxEventDocument(event))
+		return err
+	})
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DELETE_USER")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed deleting user document")
+		return nil, err
+	}
+	span.Finish()
+
+	return purged, nil
+}
+
+// AnonymizeUser strips every personal field and role from the user document
+// and marks it deleted, keeping only the id so audit events still resolve to a
+// record.
+func (r *Repo) AnonymizeUser(ctx DBOperationContext, userID string, dueBefore time.Time, anonymizedAt time.Time) (*PurgedUser, error) {
+	placeholderEmail := fmt.Sprintf("deleted-%s@users.invalid", userID)
+	update := bson.M{
+		"$set": bson.M{
+			"email":             placeholderEmail,
+			"normalized_email":  placeholderEmail,
+			"password":          "",
+			"first_name":        "",
+			"last_name":         "",
+			"roles":             bson.A{},
+			"status":            UserStatusDeleted,
+			"status_changed_at": anonymizedAt,
+			"updated_at":        anonymizedAt,
+		},
+		"$unset": bson.M{
+			"status_reason":        "",
+			"status_expires_at":    "",
+			"pending_email_change": "",
+		},
+	}
+
+	event, err := userDeletedEvent(userID, anonymizedAt, "anonymize")
+	if nil != err {
+		return nil, err
+	}
+
+	span := ctx.span.StartChild("anonymize-user")
+	span.Status = sentry.SpanStatusOK
+	var purged *PurgedUser
+	err = r.inTransaction(ctx, func(ctx context.Context) error {
+		purged = nil
+		result, err := r.collections.Users.UpdateOne(ctx, dueForPurgeFilter(userID, dueBefore), update)
+		if nil != err {
+			return err
+		}
+		if result.ModifiedCount != 1 {
+			return nil
+		}
+
+		purged, err = r.deleteUserLogins(ctx, userID)
+		if nil != err {
+			return err
+		}
+
+		_, err = r.collections.Outbox.InsertOne(ctx, newOutboxEventDocument(event))
+		return err
+	})
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_ANONYMIZE_USER")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed anonymizing user document")
+		return nil, err
+	}
+	span.Finish()
+
+	return purged, nil
+}
+
+// deleteUserLogins removes the login history of a user whose removal was
+// just written in the same transaction.
+func (r *Repo) deleteUserLogins(ctx context.Context, userID string) (*PurgedUser, error) {
+	result, err := r.collections.UserLogins.DeleteMany(ctx, bson.M{"user.id": userID})
+	if nil != err {
+		return nil, err
+	}
+
+	return &PurgedUser{DeletedLogins: result.DeletedCount}, nil
+}
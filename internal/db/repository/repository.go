@@ -6,18 +6,35 @@ import (
 )
 
 type Collections struct {
-	Users      *mongo.Collection
-	UserLogins *mongo.Collection
+	Users       *mongo.Collection
+	UserLogins  *mongo.Collection
+	AuditEvents *mongo.Collection
+	APIKeys     *mongo.Collection
+	Outbox      *mongo.Collection
+
+	RoleBootstraps *mongo.Collection
+
+	UserLoginDailyStats *mongo.Collection
+
+	Webhooks          *mongo.Collection
+	WebhookDeliveries *mongo.Collection
 }
 
 type Repo struct {
+	client      *mongo.Client
 	collections Collections
 	logger      *logrus.Entry
+
+	// transactions is false on standalone servers, which reject them. Those
+	// are only used when DatabaseConfig.AllowNoTransactions is set.
+	transactions bool
 }
 
-func New(logger *logrus.Entry, collections Collections) Repo {
+func New(logger *logrus.Entry, client *mongo.Client, collections Collections, transactions bool) Repo {
 	return Repo{
+		client,
 		collections,
 		logger,
+		transactions,
 	}
 }
@@ -0,0 +1,128 @@
+package repositorytest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+const concurrentWriters = 8
+
+// race runs write concurrently and returns the errors it returned.
+func race(write func(i int) error) []error {
+	errs := make([]error, concurrentWriters)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < concurrentWriters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = write(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	return errs
+}
+
+// expectOneWinner fails unless exactly one write succeeded and every other
+// one failed with loserErr.
+func expectOneWinner(t *testing.T, errs []error, loserErr error) {
+	t.Helper()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case nil == err:
+			succeeded++
+		case !errors.Is(err, loserErr):
+			t.Errorf("expected %v, got %v", loserErr, err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected exactly one write to succeed, got %d", succeeded)
+	}
+}
+
+func testConcurrentWrites(t *testing.T, store repository.Store) {
+	t.Run("registering one email", func(t *testing.T) {
+		errs := race(func(i int) error {
+			user := newUser(fmt.Sprintf("racer%d", i), baseTime())
+			user.Email = "racer@example.com"
+			user.NormalizedEmail = "racer@example.com"
+			return store.SaveNewUser(newContext(t), user)
+		})
+		expectOneWinner(t, errs, repository.ErrEmailTaken)
+	})
+
+	t.Run("updating one version", func(t *testing.T) {
+		user := newUser("editor", baseTime())
+		saveUser(t, store, user)
+
+		errs := race(func(i int) error {
+			firstName := fmt.Sprintf("Name %d", i)
+			_, err := store.UpdateUserProfile(newContext(t), user.ID, 1, repository.UserProfileUpdate{
+				FirstName: &firstName,
+				UpdatedAt: time.Now(),
+			})
+			return err
+		})
+		expectOneWinner(t, errs, repository.ErrVersionConflict)
+
+		updated, err := store.GetUser(newContext(t), user.ID)
+		if nil != err || updated.Version != 2 {
+			t.Fatalf("expected version 2 after one update, got %+v (%v)", updated, err)
+		}
+	})
+
+	t.Run("bootstrapping a role", func(t *testing.T) {
+		user := newUser("bootstrap", baseTime())
+		saveUser(t, store, user)
+
+		errNotGranted := errors.New("role not granted")
+		errs := race(func(i int) error {
+			granted, err := store.BootstrapRole(newContext(t), user.ID, "owner", time.Now())
+			if nil != err {
+				return err
+			}
+			if !granted {
+				return errNotGranted
+			}
+			return nil
+		})
+		expectOneWinner(t, errs, errNotGranted)
+
+		if count, err := store.CountUsersWithRole(newContext(t), "owner"); nil != err || count != 1 {
+			t.Fatalf("expected one owner, got %d (%v)", count, err)
+		}
+	})
+
+	t.Run("claiming one delivery", func(t *testing.T) {
+		now := time.Now()
+		delivery := repository.WebhookDelivery{
+			ID:             "whd_race",
+			SubscriptionID: "whs_race",
+			EventID:        "evt_race",
+			EventType:      repository.OutboxEventTypeUserRegistered,
+			Body:           "{}",
+			NextAttemptAt:  now.Add(-time.Minute),
+			CreatedAt:      now.Add(-time.Minute),
+		}
+		if err := store.SaveNewWebhookDeliveries(newContext(t), []repository.WebhookDelivery{delivery}); nil != err {
+			t.Fatalf("unable to save webhook delivery: %s", err)
+		}
+
+		errs := race(func(i int) error {
+			_, err := store.ClaimDueWebhookDelivery(newContext(t), now, now.Add(time.Minute))
+			return err
+		})
+		expectOneWinner(t, errs, repository.ErrWebhookDeliveryNotExists)
+	})
+}
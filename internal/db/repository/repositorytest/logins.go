@@ -0,0 +1,178 @@
+package repositorytest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+func saveLogin(t *testing.T, store repository.Store, id, userID string, at time.Time, country, deviceClass string) {
+	t.Helper()
+
+	login := repository.NewUserLoginToSave{
+		ID:                  id,
+		UserID:              userID,
+		LoggedInAt:          at,
+		UserIPAddress:       "203.0.113.7",
+		UserDeviceUserAgent: "test",
+	}
+	if len(country) != 0 {
+		login.Location = &repository.UserLoginLocation{CountryCode: country}
+	}
+	if len(deviceClass) != 0 {
+		login.Device = &repository.UserLoginDevice{Class: deviceClass}
+	}
+	if err := store.SaveNewUserLogin(newContext(t), login); nil != err {
+		t.Fatalf("unable to save user login %s: %s", id, err)
+	}
+}
+
+func testLoginRetention(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	base := baseTime()
+
+	if earliest, err := store.EarliestUserLoginTime(ctx); nil != err || nil != earliest {
+		t.Fatalf("expected no earliest login, got %v (%v)", earliest, err)
+	}
+
+	user := newUser("jane", base.Add(-time.Hour*72))
+	saveUser(t, store, user)
+	for i, at := range []time.Time{
+		base.Add(-time.Hour * 48),
+		base.Add(-time.Hour * 47),
+		base.Add(-time.Hour * 25),
+		base,
+	} {
+		saveLogin(t, store, fmt.Sprintf("login%d", i), user.ID, at, "", "")
+	}
+
+	if earliest, err := store.EarliestUserLoginTime(ctx); nil != err || nil == earliest || !earliest.Equal(base.Add(-time.Hour*48)) {
+		t.Fatalf("expected earliest login at %s, got %v (%v)", base.Add(-time.Hour*48), earliest, err)
+	}
+
+	for _, want := range []int64{2, 1, 0} {
+		deleted, err := store.DeleteUserLoginsBefore(ctx, base.Add(-time.Hour*24), 2)
+		if nil != err || deleted != want {
+			t.Fatalf("expected %d expired logins deleted, got %d (%v)", want, deleted, err)
+		}
+	}
+
+	if earliest, err := store.EarliestUserLoginTime(ctx); nil != err || nil == earliest || !earliest.Equal(base) {
+		t.Fatalf("expected the recent login to be kept, got %v (%v)", earliest, err)
+	}
+}
+
+func testLoginDailyStats(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	day := baseTime().Truncate(time.Hour * 24)
+
+	if latest, err := store.LatestUserLoginDailyStatsDay(ctx); nil != err || nil != latest {
+		t.Fatalf("expected no aggregated day, got %v (%v)", latest, err)
+	}
+
+	saveUser(t, store, newUser("jane", day.Add(-time.Hour)))
+	saveUser(t, store, newUser("john", day.Add(-time.Hour)))
+	saveLogin(t, store, "login1", "jane", day.Add(time.Hour), "DE", "desktop")
+	saveLogin(t, store, "login2", "jane", day.Add(time.Hour*2), "", "")
+	saveLogin(t, store, "login3", "john", day.Add(time.Hour*3), "DE", "mobile")
+	saveLogin(t, store, "login4", "john", day.Add(time.Hour*25), "FR", "mobile")
+
+	stats, err := store.ComputeUserLoginDailyStats(ctx, day)
+	if nil != err {
+		t.Fatalf("unable to compute daily stats: %s", err)
+	}
+	if !stats.Day.Equal(day) || stats.Logins != 3 || stats.Users != 2 {
+		t.Fatalf("unexpected daily stats %+v", stats)
+	}
+	expectCounts(t, "countries", stats.Countries, map[string]int64{"DE": 2, repository.UnknownLoginStatsKey: 1})
+	expectCounts(t, "device classes", stats.DeviceClasses, map[string]int64{"desktop": 1, "mobile": 1, repository.UnknownLoginStatsKey: 1})
+
+	stats.AggregatedAt = time.Now()
+	for _, want := range []bool{true, false} {
+		saved, err := store.SaveUserLoginDailyStats(ctx, *stats)
+		if nil != err || saved != want {
+			t.Fatalf("expected saving daily stats to report %t, got %t (%v)", want, saved, err)
+		}
+	}
+
+	if latest, err := store.LatestUserLoginDailyStatsDay(ctx); nil != err || nil == latest || !latest.Equal(day) {
+		t.Fatalf("expected latest aggregated day %s, got %v (%v)", day, latest, err)
+	}
+}
+
+func testPurgeDeletesLogins(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	now := time.Now()
+
+	scheduleDeletion := func(userID string, at time.Time) {
+		t.Helper()
+
+		_, err := store.ChangeUserStatus(ctx, userID, []repository.UserStatus{repository.UserStatusActive}, repository.UserStatusChange{
+			Status:    repository.UserStatusPendingDeletion,
+			ExpiresAt: &at,
+			ChangedAt: now.Add(-time.Hour),
+		})
+		if nil != err {
+			t.Fatalf("unable to schedule deletion of %s: %s", userID, err)
+		}
+	}
+
+	for _, userID := range []string{"deleted", "anonymized", "waiting"} {
+		saveUser(t, store, newUser(userID, baseTime()))
+		saveLogin(t, store, userID+"-login1", userID, now.Add(-time.Hour*2), "", "")
+		saveLogin(t, store, userID+"-login2", userID, now.Add(-time.Hour), "", "")
+	}
+	if _, err := store.AddUserRole(ctx, "anonymized", "admin", now); nil != err {
+		t.Fatalf("unable to grant admin role: %s", err)
+	}
+	scheduleDeletion("deleted", now.Add(-time.Minute*2))
+	scheduleDeletion("anonymized", now.Add(-time.Minute))
+	scheduleDeletion("waiting", now.Add(time.Hour))
+
+	due, err := store.ListUsersDueForPurge(ctx, now, 10)
+	if nil != err || len(due) != 2 || due[0] != "deleted" || due[1] != "anonymized" {
+		t.Fatalf("expected deleted and anonymized to be due, got %v (%v)", due, err)
+	}
+
+	if purged, err := store.DeleteUser(ctx, "waiting", now); nil != err || nil != purged {
+		t.Fatalf("expected an account not yet due to be kept, got %+v (%v)", purged, err)
+	}
+	if purged, err := store.DeleteUser(ctx, "deleted", now); nil != err || nil == purged || purged.DeletedLogins != 2 {
+		t.Fatalf("expected deletion to remove 2 logins, got %+v (%v)", purged, err)
+	}
+	if purged, err := store.AnonymizeUser(ctx, "anonymized", now, now); nil != err || nil == purged || purged.DeletedLogins != 2 {
+		t.Fatalf("expected anonymization to remove 2 logins, got %+v (%v)", purged, err)
+	}
+
+	if count, err := store.CountUsersWithRole(ctx, "admin"); nil != err || count != 0 {
+		t.Fatalf("expected anonymization to drop the roles, got %d admins (%v)", count, err)
+	}
+
+	for userID, want := range map[string]int{"deleted": 0, "anonymized": 0, "waiting": 2} {
+		export, err := store.ExportUserData(ctx, userID)
+		if userID == "deleted" {
+			if nil == err {
+				t.Fatal("expected deleted account not to be exported")
+			}
+			continue
+		}
+		if nil != err || len(export.Logins) != want {
+			t.Fatalf("expected %d logins left for %s, got %+v (%v)", want, userID, export, err)
+		}
+	}
+}
+
+func expectCounts(t *testing.T, name string, got, want map[string]int64) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %s %v, got %v", name, want, got)
+	}
+	for key, count := range want {
+		if got[key] != count {
+			t.Fatalf("expected %s %v, got %v", name, want, got)
+		}
+	}
+}
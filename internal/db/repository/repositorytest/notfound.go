@@ -0,0 +1,91 @@
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+func testNotFoundErrors(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	now := time.Now()
+
+	checks := map[string]struct {
+		err  error
+		want error
+	}{}
+	check := func(name string, err, want error) {
+		checks[name] = struct {
+			err  error
+			want error
+		}{err, want}
+	}
+
+	_, err := store.GetUserLoginInfo(ctx, "missing@example.com")
+	check("GetUserLoginInfo", err, repository.ErrUserNotExists)
+	_, err = store.GetUserLoginInfoByID(ctx, "missing")
+	check("GetUserLoginInfoByID", err, repository.ErrUserNotExists)
+	_, err = store.GetUserAuthenticationInfo(ctx, "missing")
+	check("GetUserAuthenticationInfo", err, repository.ErrUserNotExists)
+	_, err = store.GetUser(ctx, "missing")
+	check("GetUser", err, repository.ErrUserNotExists)
+	_, err = store.UpdateUserProfile(ctx, "missing", 1, repository.UserProfileUpdate{UpdatedAt: now})
+	check("UpdateUserProfile", err, repository.ErrUserNotExists)
+	err = store.SavePendingEmailChange(ctx, "missing", repository.PendingEmailChange{
+		Email:           "new@example.com",
+		NormalizedEmail: "new@example.com",
+		TokenHash:       "hash",
+		RequestedAt:     now,
+		ExpiresAt:       now.Add(time.Hour),
+	})
+	check("SavePendingEmailChange", err, repository.ErrUserNotExists)
+	_, err = store.ConfirmEmailChange(ctx, "missing", now)
+	check("ConfirmEmailChange", err, repository.ErrEmailChangeNotExists)
+	_, err = store.ChangeUserStatus(ctx, "missing", []repository.UserStatus{repository.UserStatusActive}, repository.UserStatusChange{
+		Status:    repository.UserStatusSuspended,
+		ChangedAt: now,
+	})
+	check("ChangeUserStatus", err, repository.ErrUserNotExists)
+	_, err = store.AddUserRole(ctx, "missing", "admin", now)
+	check("AddUserRole", err, repository.ErrUserNotExists)
+	_, err = store.RemoveUserRole(ctx, "missing", "admin", now)
+	check("RemoveUserRole", err, repository.ErrUserNotExists)
+	_, err = store.ExportUserData(ctx, "missing")
+	check("ExportUserData", err, repository.ErrUserNotExists)
+	_, err = store.GetAPIKeyAuthenticationInfo(ctx, "missing")
+	check("GetAPIKeyAuthenticationInfo", err, repository.ErrAPIKeyNotExists)
+	_, err = store.RevokeAPIKey(ctx, "missing", now)
+	check("RevokeAPIKey", err, repository.ErrAPIKeyNotExists)
+	_, err = store.GetWebhookSubscription(ctx, "missing")
+	check("GetWebhookSubscription", err, repository.ErrWebhookSubscriptionNotExists)
+	err = store.DeleteWebhookSubscription(ctx, "missing")
+	check("DeleteWebhookSubscription", err, repository.ErrWebhookSubscriptionNotExists)
+	_, err = store.RetryWebhookDelivery(ctx, "missing", now)
+	check("RetryWebhookDelivery", err, repository.ErrWebhookDeliveryNotExists)
+	_, err = store.ClaimDueWebhookDelivery(ctx, now, now.Add(time.Minute))
+	check("ClaimDueWebhookDelivery", err, repository.ErrWebhookDeliveryNotExists)
+
+	for name, c := range checks {
+		if !errors.Is(c.err, c.want) {
+			t.Errorf("%s: expected %v, got %v", name, c.want, c.err)
+		}
+	}
+
+	if exists, err := store.UserWithIDExists(ctx, "missing"); nil != err || exists {
+		t.Errorf("UserWithIDExists: expected false, got %t (%v)", exists, err)
+	}
+	if users, err := store.GetUsersByIDs(ctx, []string{"missing"}); nil != err || len(users) != 0 {
+		t.Errorf("GetUsersByIDs: expected no users, got %d (%v)", len(users), err)
+	}
+	if purged, err := store.DeleteUser(ctx, "missing", now); nil != err || nil != purged {
+		t.Errorf("DeleteUser: expected nothing purged, got %+v (%v)", purged, err)
+	}
+	if purged, err := store.AnonymizeUser(ctx, "missing", now, now); nil != err || nil != purged {
+		t.Errorf("AnonymizeUser: expected nothing purged, got %+v (%v)", purged, err)
+	}
+	if granted, err := store.BootstrapRole(ctx, "missing", "admin", now); nil != err || granted {
+		t.Errorf("BootstrapRole: expected no grant, got %t (%v)", granted, err)
+	}
+}
@@ -0,0 +1,56 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+func testOutbox(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	base := baseTime()
+
+	later := newUser("later", base.Add(time.Hour))
+	earlier := newUser("earlier", base)
+	saveUser(t, store, later)
+	saveUser(t, store, earlier)
+
+	pending := func(limit int64) []repository.OutboxEvent {
+		t.Helper()
+
+		events, err := store.ListPendingOutboxEvents(ctx, limit)
+		if nil != err {
+			t.Fatalf("unable to list pending outbox events: %s", err)
+		}
+		return events
+	}
+
+	events := pending(10)
+	if len(events) != 2 || events[0].SubjectID != earlier.ID || events[1].SubjectID != later.ID {
+		t.Fatalf("expected registrations oldest first, got %+v", events)
+	}
+	for _, event := range events {
+		if event.Type != repository.OutboxEventTypeUserRegistered || event.Payload["user_id"] != event.SubjectID {
+			t.Fatalf("unexpected outbox event %+v", event)
+		}
+	}
+	if limited := pending(1); len(limited) != 1 || limited[0].ID != events[0].ID {
+		t.Fatalf("expected the limit to keep the oldest event, got %+v", limited)
+	}
+
+	if err := store.MarkOutboxEventFailed(ctx, events[0].ID, "broker unavailable"); nil != err {
+		t.Fatalf("unable to mark outbox event failed: %s", err)
+	}
+	failed := pending(10)
+	if len(failed) != 2 || failed[0].ID != events[0].ID || failed[0].Attempts != 1 || failed[0].LastError != "broker unavailable" {
+		t.Fatalf("expected failed event to stay pending with one attempt, got %+v", failed)
+	}
+
+	if err := store.MarkOutboxEventDelivered(ctx, events[0].ID, time.Now()); nil != err {
+		t.Fatalf("unable to mark outbox event delivered: %s", err)
+	}
+	if left := pending(10); len(left) != 1 || left[0].ID != events[1].ID {
+		t.Fatalf("expected only the undelivered event to be pending, got %+v", left)
+	}
+}
@@ -0,0 +1,108 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+func testUsersPagination(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	base := baseTime()
+
+	// saved out of order, with c and d registered at the same time
+	for _, user := range []repository.NewUserToSave{
+		newUser("d", base.Add(time.Hour*2)),
+		newUser("a", base),
+		newUser("e", base.Add(time.Hour*3)),
+		newUser("c", base.Add(time.Hour*2)),
+		newUser("b", base.Add(time.Hour)),
+	} {
+		saveUser(t, store, user)
+	}
+
+	pages := [][]string{}
+	filter := repository.UsersFilter{Limit: 2}
+	for {
+		users, err := store.ListUsers(ctx, filter)
+		if nil != err {
+			t.Fatalf("unable to list users: %s", err)
+		}
+		if len(users) == 0 {
+			break
+		}
+
+		page := []string{}
+		for _, user := range users {
+			page = append(page, user.ID)
+		}
+		pages = append(pages, page)
+
+		last := users[len(users)-1]
+		filter.After = &repository.UsersCursor{RegisteredAt: last.RegisteredAt, ID: last.ID}
+	}
+
+	expectPages(t, pages, [][]string{{"a", "b"}, {"c", "d"}, {"e"}})
+}
+
+func testAuditEventsPagination(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	base := baseTime()
+
+	// listed newest first, with evt_b and evt_c at the same time
+	for _, event := range []repository.AuditEvent{
+		{ID: "evt_b", OccurredAt: base.Add(time.Minute)},
+		{ID: "evt_d", OccurredAt: base.Add(time.Minute * 2)},
+		{ID: "evt_a", OccurredAt: base},
+		{ID: "evt_c", OccurredAt: base.Add(time.Minute)},
+	} {
+		event.Type = "user.login"
+		event.Outcome = "success"
+		event.SubjectID = "jane"
+		if err := store.SaveAuditEvent(ctx, event); nil != err {
+			t.Fatalf("unable to save audit event %s: %s", event.ID, err)
+		}
+	}
+
+	pages := [][]string{}
+	filter := repository.AuditEventsFilter{SubjectID: "jane", Limit: 3}
+	for {
+		events, err := store.QueryAuditEvents(ctx, filter)
+		if nil != err {
+			t.Fatalf("unable to query audit events: %s", err)
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		page := []string{}
+		for _, event := range events {
+			page = append(page, event.ID)
+		}
+		pages = append(pages, page)
+
+		last := events[len(events)-1]
+		filter.After = &repository.AuditEventsCursor{OccurredAt: last.OccurredAt, ID: last.ID}
+	}
+
+	expectPages(t, pages, [][]string{{"evt_d", "evt_c", "evt_b"}, {"evt_a"}})
+}
+
+func expectPages(t *testing.T, got, want [][]string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("expected pages %v, got %v", want, got)
+	}
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("expected pages %v, got %v", want, got)
+		}
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Fatalf("expected pages %v, got %v", want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package repositorytest holds the behaviour every repository.Store shares.
+// Each Store implementation runs Run against its own backend, so callers can
+// rely on the same errors and orderings whichever storage backend is
+// configured.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+// NewStore returns an empty store for a single test.
+type NewStore func(t *testing.T) repository.Store
+
+type storeTest struct {
+	name string
+	run  func(t *testing.T, store repository.Store)
+}
+
+var storeTests = []storeTest{
+	{"not found errors", testNotFoundErrors},
+	{"email uniqueness", testEmailUniqueness},
+	{"pending email reservations", testPendingEmailReservations},
+	{"users pagination", testUsersPagination},
+	{"audit events pagination", testAuditEventsPagination},
+	{"outbox", testOutbox},
+	{"login retention", testLoginRetention},
+	{"login daily stats", testLoginDailyStats},
+	{"status transitions", testStatusTransitions},
+	{"role bootstrap", testRoleBootstrap},
+	{"purge deletes logins", testPurgeDeletesLogins},
+	{"concurrent writes", testConcurrentWrites},
+}
+
+// Run checks the store returned by newStore against the shared suite.
+func Run(t *testing.T, newStore NewStore) {
+	for _, test := range storeTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			test.run(t, newStore(t))
+		})
+	}
+}
+
+func newContext(t *testing.T) repository.DBOperationContext {
+	t.Helper()
+
+	span := sentry.StartSpan(context.Background(), "test")
+	t.Cleanup(span.Finish)
+
+	return repository.NewDBOperationContext(context.Background(), span)
+}
+
+// baseTime is recent enough not to be expired by login retention, and kept at
+// the precision every store returns.
+func baseTime() time.Time {
+	return time.Now().UTC().Truncate(time.Hour).Add(-time.Hour * 24)
+}
+
+func newUser(id string, registeredAt time.Time) repository.NewUserToSave {
+	return repository.NewUserToSave{
+		ID:              id,
+		Email:           fmt.Sprintf("%s@example.com", id),
+		NormalizedEmail: fmt.Sprintf("%s@example.com", id),
+		Password:        "hash",
+		FirstName:       "First " + id,
+		LastName:        "Last " + id,
+		RegisteredAt:    registeredAt,
+	}
+}
+
+func saveUser(t *testing.T, store repository.Store, user repository.NewUserToSave) {
+	t.Helper()
+
+	if err := store.SaveNewUser(newContext(t), user); nil != err {
+		t.Fatalf("unable to save user %s: %s", user.ID, err)
+	}
+}
@@ -0,0 +1,80 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+func testRoleBootstrap(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	now := time.Now()
+
+	bootstrap := func(userID, role string) bool {
+		t.Helper()
+
+		granted, err := store.BootstrapRole(ctx, userID, role, now)
+		if nil != err {
+			t.Fatalf("unable to bootstrap %s to %s: %s", role, userID, err)
+		}
+		return granted
+	}
+	holders := func(role string) int64 {
+		t.Helper()
+
+		count, err := store.CountUsersWithRole(ctx, role)
+		if nil != err {
+			t.Fatalf("unable to count %s holders: %s", role, err)
+		}
+		return count
+	}
+
+	for _, userID := range []string{"jane", "john", "suspended"} {
+		saveUser(t, store, newUser(userID, baseTime()))
+	}
+	_, err := store.ChangeUserStatus(ctx, "suspended", []repository.UserStatus{repository.UserStatusActive}, repository.UserStatusChange{
+		Status:    repository.UserStatusSuspended,
+		ChangedAt: now,
+	})
+	if nil != err {
+		t.Fatalf("unable to suspend user: %s", err)
+	}
+
+	// accounts that cannot take the role leave the bootstrap open
+	if bootstrap("missing", "admin") || bootstrap("suspended", "admin") {
+		t.Fatal("expected only an active account to be granted the role")
+	}
+
+	if !bootstrap("jane", "admin") {
+		t.Fatal("expected the role to be granted to an active account")
+	}
+	if user, err := store.GetUser(ctx, "jane"); nil != err || len(user.Roles) != 1 || user.Roles[0] != "admin" {
+		t.Fatalf("expected jane to hold admin, got %+v (%v)", user, err)
+	}
+	if bootstrap("john", "admin") {
+		t.Fatal("expected a bootstrapped role not to be granted again")
+	}
+
+	// losing every holder does not reopen the bootstrap
+	if _, err := store.RemoveUserRole(ctx, "jane", "admin", now); nil != err {
+		t.Fatalf("unable to demote jane: %s", err)
+	}
+	if bootstrap("john", "admin") || holders("admin") != 0 {
+		t.Fatal("expected a bootstrapped role not to be granted after its holders are gone")
+	}
+
+	// nor does a role that was found held
+	if _, err := store.AddUserRole(ctx, "john", "owner", now); nil != err {
+		t.Fatalf("unable to grant owner role: %s", err)
+	}
+	if bootstrap("jane", "owner") {
+		t.Fatal("expected a held role not to be bootstrapped")
+	}
+	if _, err := store.RemoveUserRole(ctx, "john", "owner", now); nil != err {
+		t.Fatalf("unable to remove owner role: %s", err)
+	}
+	if bootstrap("jane", "owner") || holders("owner") != 0 {
+		t.Fatal("expected a role found held not to be bootstrapped later")
+	}
+}
@@ -0,0 +1,60 @@
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+func testStatusTransitions(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	now := time.Now()
+
+	suspend := func(userID string, until time.Time) {
+		t.Helper()
+
+		_, err := store.ChangeUserStatus(ctx, userID, []repository.UserStatus{repository.UserStatusActive}, repository.UserStatusChange{
+			Status:    repository.UserStatusSuspended,
+			ExpiresAt: &until,
+			ChangedAt: now.Add(-time.Hour),
+		})
+		if nil != err {
+			t.Fatalf("unable to suspend %s: %s", userID, err)
+		}
+	}
+	scheduleDeletion := func(userID string) (*repository.User, error) {
+		purgeAfter := now.Add(time.Hour * 24)
+		return store.ChangeUserStatus(ctx, userID, []repository.UserStatus{repository.UserStatusActive}, repository.UserStatusChange{
+			Status:    repository.UserStatusPendingDeletion,
+			ExpiresAt: &purgeAfter,
+			ChangedAt: now,
+		})
+	}
+
+	for _, userID := range []string{"expired", "suspended", "reactivated"} {
+		saveUser(t, store, newUser(userID, baseTime()))
+	}
+	suspend("expired", now.Add(-time.Minute))
+	suspend("suspended", now.Add(time.Hour))
+	suspend("reactivated", now.Add(-time.Minute))
+
+	// a suspension that has run out counts as active
+	user, err := scheduleDeletion("expired")
+	if nil != err || user.Status != repository.UserStatusPendingDeletion {
+		t.Fatalf("expected an expired suspension to allow leaving active, got %+v (%v)", user, err)
+	}
+
+	if _, err := scheduleDeletion("suspended"); !errors.Is(err, repository.ErrUserStatusTransitionNotAllowed) {
+		t.Fatalf("expected ErrUserStatusTransitionNotAllowed for a running suspension, got %v", err)
+	}
+
+	user, err = store.ChangeUserStatus(ctx, "reactivated", []repository.UserStatus{repository.UserStatusSuspended}, repository.UserStatusChange{
+		Status:    repository.UserStatusActive,
+		ChangedAt: now,
+	})
+	if nil != err || user.Status != repository.UserStatusActive {
+		t.Fatalf("expected an expired suspension to still be lifted, got %+v (%v)", user, err)
+	}
+}
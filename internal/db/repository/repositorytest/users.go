@@ -0,0 +1,109 @@
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+func testEmailUniqueness(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	registeredAt := baseTime()
+
+	jane := newUser("jane", registeredAt)
+	saveUser(t, store, jane)
+
+	duplicate := newUser("john", registeredAt)
+	duplicate.Email = "Jane@Example.com"
+	duplicate.NormalizedEmail = jane.NormalizedEmail
+	if err := store.SaveNewUser(ctx, duplicate); !errors.Is(err, repository.ErrEmailTaken) {
+		t.Fatalf("expected ErrEmailTaken, got %v", err)
+	}
+	if exists, err := store.UserWithIDExists(ctx, duplicate.ID); nil != err || exists {
+		t.Fatalf("expected rejected user not to be saved, got %t (%v)", exists, err)
+	}
+	if exists, err := store.NormalizedEmailExists(ctx, jane.NormalizedEmail, ""); nil != err || !exists {
+		t.Fatalf("expected registered email to exist, got %t (%v)", exists, err)
+	}
+
+	info, err := store.GetUserLoginInfo(ctx, jane.Email)
+	if nil != err || info.ID != jane.ID {
+		t.Fatalf("expected login info of %s, got %+v (%v)", jane.ID, info, err)
+	}
+}
+
+func testPendingEmailReservations(t *testing.T, store repository.Store) {
+	ctx := newContext(t)
+	now := time.Now()
+
+	jane := newUser("jane", baseTime())
+	john := newUser("john", baseTime())
+	saveUser(t, store, jane)
+	saveUser(t, store, john)
+
+	reserve := func(userID, email, tokenHash string, expiresAt time.Time) {
+		t.Helper()
+
+		err := store.SavePendingEmailChange(ctx, userID, repository.PendingEmailChange{
+			Email:           email,
+			NormalizedEmail: email,
+			TokenHash:       tokenHash,
+			RequestedAt:     now,
+			ExpiresAt:       expiresAt,
+		})
+		if nil != err {
+			t.Fatalf("unable to save pending email change of %s: %s", userID, err)
+		}
+	}
+	exists := func(email, exceptPendingOfUserID string) bool {
+		t.Helper()
+
+		exists, err := store.NormalizedEmailExists(ctx, email, exceptPendingOfUserID)
+		if nil != err {
+			t.Fatalf("unable to check email %s: %s", email, err)
+		}
+		return exists
+	}
+
+	reserve(jane.ID, "reserved@example.com", "jane-token", now.Add(time.Hour))
+	if !exists("reserved@example.com", "") || !exists("reserved@example.com", john.ID) {
+		t.Fatal("expected a pending reservation to take the email")
+	}
+	if exists("reserved@example.com", jane.ID) {
+		t.Fatal("expected the requester's own reservation not to take the email")
+	}
+
+	reserve(john.ID, "expired@example.com", "john-token", now.Add(-time.Minute))
+	if exists("expired@example.com", "") {
+		t.Fatal("expected an expired reservation not to take the email")
+	}
+	if _, err := store.ConfirmEmailChange(ctx, "john-token", now); !errors.Is(err, repository.ErrEmailChangeNotExists) {
+		t.Fatalf("expected expired change not to be confirmed, got %v", err)
+	}
+
+	// another account registering the reserved email first wins it
+	taker := newUser("taker", baseTime())
+	taker.Email = "reserved@example.com"
+	taker.NormalizedEmail = "reserved@example.com"
+	saveUser(t, store, taker)
+	if _, err := store.ConfirmEmailChange(ctx, "jane-token", now); !errors.Is(err, repository.ErrEmailTaken) {
+		t.Fatalf("expected ErrEmailTaken, got %v", err)
+	}
+
+	reserve(jane.ID, "jane.doe@example.com", "jane-token-2", now.Add(time.Hour))
+	confirmed, err := store.ConfirmEmailChange(ctx, "jane-token-2", now)
+	if nil != err {
+		t.Fatalf("unable to confirm email change: %s", err)
+	}
+	if confirmed.UserID != jane.ID || confirmed.OldEmail != jane.Email || confirmed.NewEmail != "jane.doe@example.com" {
+		t.Fatalf("unexpected confirmed change %+v", confirmed)
+	}
+	if exists(jane.NormalizedEmail, "") || !exists("jane.doe@example.com", "") {
+		t.Fatal("expected confirmed change to move the email")
+	}
+	if _, err := store.ConfirmEmailChange(ctx, "jane-token-2", now); !errors.Is(err, repository.ErrEmailChangeNotExists) {
+		t.Fatalf("expected confirmed token to be spent, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+func (r *Repo) AddUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error) {
+	update := bson.M{
+		"$addToSet": bson.M{"roles": role},
+		"$set":      bson.M{"updated_at": at},
+	}
+
+	return r.updateUserRoles(ctx, userID, update)
+}
+
+func (r *Repo) RemoveUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error) {
+	update := bson.M{
+		"$pull": bson.M{"roles": role},
+		"$set":  bson.M{"updated_at": at},
+	}
+
+	return r.updateUserRoles(ctx, userID, update)
+}
+
+func (r *Repo) updateUserRoles(ctx DBOperationContext, userID string, update bson.M) (*User, error) {
+	filter := bson.M{
+		"id": userID,
+	}
+	opts := options.
+		FindOneAndUpdate().
+		SetProjection(userSummaryProjection).
+		SetReturnDocument(options.After)
+
+	span := ctx.span.StartChild("update-user-roles")
+	span.Status = sentry.SpanStatusOK
+	result := r.collections.Users.FindOneAndUpdate(ctx, filter, update, opts)
+	if err := result.Err(); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrUserNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_UPDATE_USER_ROLES")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed updating user roles")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-updated-user")
+	span.Status = sentry.SpanStatusOK
+	var doc userDocument
+	if err := result.Decode(&doc); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode updated user document")
+		return nil, err
+	}
+	span.Finish()
+
+	user := doc.toUser()
+	return &user, nil
+}
+
+func (r *Repo) CountUsersWithRole(ctx DBOperationContext, role string) (int64, error) {
+	span := ctx.span.StartChild("count-users-with-role")
+	span.Status = sentry.SpanStatusOK
+	count, err := r.collections.Users.CountDocuments(ctx, bson.M{"roles": role})
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_COUNT_USERS_WITH_ROLE")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed counting users with role")
+		return 0, err
+	}
+	span.Finish()
+
+	return count, nil
+}
+
+// roleBootstrapDocument is a document of the role_bootstraps collection. It
+// names the user a role was bootstrapped to, if it was granted at all.
+type roleBootstrapDocument struct {
+	Role           string    `bson:"role"`
+	UserID         string    `bson:"user_id,omitempty"`
+	BootstrappedAt time.Time `bson:"bootstrapped_at"`
+}
+
+// BootstrapRole grants the role to the active user with the given ID, once
+// per role. A role is bootstrapped for good as soon as it is granted this way
+// or is found held by someone; the marker recording that is written in the
+// same transaction as the grant, so losing every holder later never opens
+// the bootstrap again. It reports whether the role was granted.
+func (r *Repo) BootstrapRole(ctx DBOperationContext, userID, role string, at time.Time) (bool, error) {
+	update := bson.M{
+		"$addToSet": bson.M{"roles": role},
+		"$set":      bson.M{"updated_at": at},
+	}
+
+	span := ctx.span.StartChild("bootstrap-role")
+	span.Status = sentry.SpanStatusOK
+	granted := false
+	err := r.inTransaction(ctx, func(ctx context.Context) error {
+		granted = false
+		bootstrapped, err := r.collections.RoleBootstraps.CountDocuments(ctx, bson.M{"role": role})
+		if nil != err || bootstrapped != 0 {
+			return err
+		}
+		holders, err := r.collections.Users.CountDocuments(ctx, bson.M{"roles": role})
+		if nil != err {
+			return err
+		}
+
+		marker := roleBootstrapDocument{
+			Role:           role,
+			BootstrappedAt: at,
+		}
+		if holders == 0 {
+			filter := bson.M{
+				"id":     userID,
+				"status": UserStatusActive,
+				"roles":  bson.M{"$ne": role},
+			}
+			result, err := r.collections.Users.UpdateOne(ctx, filter, update)
+			if nil != err {
+				return err
+			}
+			if result.ModifiedCount != 1 {
+				// no eligible account yet, so the bootstrap stays open
+				return nil
+			}
+			granted = true
+			marker.UserID = userID
+		}
+
+		_, err = r.collections.RoleBootstraps.InsertOne(ctx, marker)
+		return err
+	})
+	if nil != err {
+		defer span.Finish()
+
+		if mongo.IsDuplicateKeyError(err) {
+			span.Status = sentry.SpanStatusAlreadyExists
+			return false, nil
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_BOOTSTRAP_ROLE")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed bootstrapping role")
+		return false, err
+	}
+	span.Finish()
+
+	return granted, nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+var (
+	ErrUserStatusTransitionNotAllowed = errors.New("user status transition is not allowed")
+)
+
+type UserStatusInfo struct {
+	Status    UserStatus
+	Reason    string
+	ExpiresAt *time.Time
+}
+
+// Effective resolves the status that applies at the given time. Suspensions
+// with an expiry are not lifted by a background job; once the expiry passes,
+// the user is simply treated as active again.
+func (info UserStatusInfo) Effective(now time.Time) UserStatus {
+	if len(info.Status) == 0 {
+		return UserStatusActive
+	}
+	if info.Status == UserStatusSuspended && nil != info.ExpiresAt && !now.Before(*info.ExpiresAt) {
+		return UserStatusActive
+	}
+
+	return info.Status
+}
+
+var userStatusProjection = bson.D{
+	bson.E{Key: "status", Value: 1},
+	bson.E{Key: "status_reason", Value: 1},
+	bson.E{Key: "status_expires_at", Value: 1},
+}
+
+// statusInFilter matches documents whose stored status is one of the given
+// statuses. Documents without a status, and suspensions that expired by now,
+// count as active.
+func statusInFilter(statuses []UserStatus, now time.Time) bson.M {
+	for _, status := range statuses {
+		if status == UserStatusActive {
+			return bson.M{"$or": bson.A{
+				bson.M{"status": bson.M{"$in": statuses}},
+				bson.M{"status": bson.M{"$exists": false}},
+				bson.M{"status": UserStatusSuspended, "status_expires_at": bson.M{"$lte": now}},
+			}}
+		}
+	}
+
+	return bson.M{"status": bson.M{"$in": statuses}}
+}
+
+type UserStatusChange struct {
+	Status    UserStatus
+	Reason    string
+	ExpiresAt *time.Time
+	ChangedAt time.Time
+
+	// RevokeTokens invalidates every token issued before ChangedAt.
+	RevokeTokens bool
+}
+
+func (r *Repo) ChangeUserStatus(ctx DBOperationContext, userID string, allowedFrom []UserStatus, change UserStatusChange) (*User, error) {
+	filter := bson.M{
+		"$and": bson.A{
+			bson.M{"id": userID},
+			statusInFilter(allowedFrom, change.ChangedAt),
+		},
+	}
+	set := bson.M{
+		"status":            change.Status,
+		"status_changed_at": change.ChangedAt,
+		"updated_at":        change.ChangedAt,
+	}
+	if change.RevokeTokens {
+		set["tokens_not_before"] = change.ChangedAt
+	}
+	unset := bson.M{}
+	if len(change.Reason) != 0 {
+		set["status_reason"] = change.Reason
+	} else {
+		unset["status_reason"] = ""
+	}
+	if nil != change.ExpiresAt {
+		set["status_expires_at"] = *change.ExpiresAt
+	} else {
+		unset["status_expires_at"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) != 0 {
+		update["$unset"] = unset
+	}
+	opts := options.
+		FindOneAndUpdate().
+		SetProjection(userSummaryProjection).
+		SetReturnDocument(options.After)
+
+	span := ctx.span.StartChild("update-user-status")
+	span.Status = sentry.SpanStatusOK
+	result := r.collections.Users.FindOneAndUpdate(ctx, filter, update, opts)
+	if err := result.Err(); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusFailedPrecondition
+			exists, err := r.UserWithIDExists(NewDBOperationContext(ctx, span), userID)
+			if nil != err {
+				return nil, err
+			}
+			if !exists {
+				span.Status = sentry.SpanStatusNotFound
+				return nil, ErrUserNotExists
+			}
+
+			return nil, ErrUserStatusTransitionNotAllowed
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_UPDATE_USER_STATUS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed updating user status")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-updated-user")
+	span.Status = sentry.SpanStatusOK
+	var doc userDocument
+	if err := result.Decode(&doc); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode updated user document")
+		return nil, err
+	}
+	span.Finish()
+
+	user := doc.toUser()
+	return &user, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"time"
+)
+
+// Store is the persistence boundary of the service. Repo implements it on
+// MongoDB, PostgresStore on PostgreSQL and MemoryStore in process memory; all
+// of them report the same errors for missing records and uniqueness
+// violations.
+type Store interface {
+	SaveNewUser(ctx DBOperationContext, user NewUserToSave) error
+	GetUserLoginInfo(ctx DBOperationContext, email string) (*UserLoginInfo, error)
+	GetUserLoginInfoByID(ctx DBOperationContext, userID string) (*UserLoginInfo, error)
+	NormalizedEmailExists(ctx DBOperationContext, normalizedEmail, exceptPendingOfUserID string) (bool, error)
+	UserWithIDExists(ctx DBOperationContext, userID string) (bool, error)
+	GetUserAuthenticationInfo(ctx DBOperationContext, userID string) (*UserAuthenticationInfo, error)
+	GetUser(ctx DBOperationContext, userID string) (*User, error)
+	GetUsersByIDs(ctx DBOperationContext, userIDs []string) ([]User, error)
+	ListUsers(ctx DBOperationContext, filter UsersFilter) ([]User, error)
+	UpdateUserProfile(ctx DBOperationContext, userID string, expectedVersion uint64, update UserProfileUpdate) (*User, error)
+
+	SavePendingEmailChange(ctx DBOperationContext, userID string, change PendingEmailChange) error
+	ConfirmEmailChange(ctx DBOperationContext, tokenHash string, confirmedAt time.Time) (*ConfirmedEmailChange, error)
+
+	ChangeUserStatus(ctx DBOperationContext, userID string, allowedFrom []UserStatus, change UserStatusChange) (*User, error)
+
+	AddUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error)
+	RemoveUserRole(ctx DBOperationContext, userID, role string, at time.Time) (*User, error)
+	CountUsersWithRole(ctx DBOperationContext, role string) (int64, error)
+	BootstrapRole(ctx DBOperationContext, userID, role string, at time.Time) (bool, error)
+
+	ListUsersDueForPurge(ctx DBOperationContext, dueBefore time.Time, limit int64) ([]string, error)
+	DeleteUser(ctx DBOperationContext, userID string, dueBefore time.Time) (*PurgedUser, error)
+	AnonymizeUser(ctx DBOperationContext, userID string, dueBefore time.Time, anonymizedAt time.Time) (*PurgedUser, error)
+
+	SaveNewUserLogin(ctx DBOperationContext, userLogin NewUserLoginToSave) error
+	DeleteUserLoginsBefore(ctx DBOperationContext, before time.Time, limit int64) (int64, error)
+	EarliestUserLoginTime(ctx DBOperationContext) (*time.Time, error)
+
+	ComputeUserLoginDailyStats(ctx DBOperationContext, day time.Time) (*UserLoginDailyStats, error)
+	SaveUserLoginDailyStats(ctx DBOperationContext, stats UserLoginDailyStats) (bool, error)
+	LatestUserLoginDailyStatsDay(ctx DBOperationContext) (*time.Time, error)
+
+	ExportUserData(ctx DBOperationContext, userID string) (*UserDataExport, error)
+
+	SaveAuditEvent(ctx DBOperationContext, event AuditEvent) error
+	QueryAuditEvents(ctx DBOperationContext, filter AuditEventsFilter) ([]AuditEvent, error)
+
+	SaveNewAPIKey(ctx DBOperationContext, key NewAPIKeyToSave) error
+	GetAPIKeyAuthenticationInfo(ctx DBOperationContext, prefix string) (*APIKeyAuthenticationInfo, error)
+	ListAPIKeys(ctx DBOperationContext, includeRevoked bool) ([]APIKey, error)
+	RevokeAPIKey(ctx DBOperationContext, keyID string, at time.Time) (*APIKey, error)
+	TouchAPIKey(ctx DBOperationContext, keyID string, at, staleBefore time.Time) error
+
+	ListPendingOutboxEvents(ctx DBOperationContext, limit int64) ([]OutboxEvent, error)
+	MarkOutboxEventDelivered(ctx DBOperationContext, eventID string, at time.Time) error
+	MarkOutboxEventFailed(ctx DBOperationContext, eventID string, reason string) error
+
+	SaveNewWebhookSubscription(ctx DBOperationContext, subscription WebhookSubscription) error
+	GetWebhookSubscription(ctx DBOperationContext, subscriptionID string) (*WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx DBOperationContext) ([]WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx DBOperationContext, subscriptionID string) error
+	SaveNewWebhookDeliveries(ctx DBOperationContext, deliveries []WebhookDelivery) error
+	ClaimDueWebhookDelivery(ctx DBOperationContext, now, leaseUntil time.Time) (*WebhookDelivery, error)
+	MarkWebhookDeliveryDelivered(ctx DBOperationContext, deliveryID string, statusCode int, at time.Time) error
+	MarkWebhookDeliveryFailed(ctx DBOperationContext, deliveryID string, failure WebhookDeliveryFailure) error
+	ListWebhookDeliveries(ctx DBOperationContext, filter WebhookDeliveriesFilter) ([]WebhookDelivery, error)
+	RetryWebhookDelivery(ctx DBOperationContext, deliveryID string, at time.Time) (*WebhookDelivery, error)
+}
+
+var _ Store = (*Repo)(nil)
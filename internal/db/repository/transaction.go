@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// inTransaction runs fn in a transaction, retrying it on transient errors.
+// Standalone servers do not support transactions and are only accepted when
+// explicitly allowed for local development; there fn runs without one and a
+// failure between its writes leaves the earlier ones in place.
+func (r *Repo) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !r.transactions {
+		return fn(ctx)
+	}
+
+	session, err := r.client.StartSession()
+	if nil != err {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+
+	return err
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -15,10 +16,47 @@ import (
 type UserLoginInfo struct {
 	ID       string
 	Password string
+	Status   UserStatusInfo
+	Roles    []string
 }
 
 var (
 	ErrUserNotExists = errors.New("user does not exist")
+	ErrEmailTaken    = errors.New("email address is already taken")
+)
+
+// UsersEmailIndex is the unique index on users.normalized_email. Duplicate
+// keys on other indexes, e.g. a colliding id, are not an email being taken.
+const UsersEmailIndex = "normalized_email_unique"
+
+// duplicate key error codes reported by MongoDB, see mongo.IsDuplicateKeyError
+var duplicateKeyErrorCodes = []int{11000, 11001, 12582, 16460}
+
+// isDuplicateKeyErrorOn reports whether err is a duplicate key error raised by
+// the index with the given name. The server names it in the error message.
+func isDuplicateKeyErrorOn(err error, index string) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+
+	for _, code := range duplicateKeyErrorCodes {
+		if serverErr.HasErrorCodeWithMessage(code, " index: "+index+" ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+type UserStatus = string
+
+const (
+	UserStatusActive              UserStatus = "active"
+	UserStatusSuspended           UserStatus = "suspended"
+	UserStatusPendingVerification UserStatus = "pending_verification"
+	UserStatusPendingDeletion     UserStatus = "pending_deletion"
+	UserStatusDeleted             UserStatus = "deleted"
 )
 
 type NewUserToSave struct {
@@ -31,23 +69,136 @@ type NewUserToSave struct {
 	RegisteredAt    time.Time
 }
 
+// userDocument is a document of the users collection. Queries project only
+// the fields they need, so decoded documents are usually partial. Documents
+// written before statuses, roles and versions existed may lack those fields;
+// the accessors below resolve them to the values readers have always assumed.
+type userDocument struct {
+	ID                 string                      `bson:"id"`
+	RegisteredAt       time.Time                   `bson:"registered_at"`
+	Email              string                      `bson:"email"`
+	NormalizedEmail    string                      `bson:"normalized_email"`
+	Password           string                      `bson:"password"`
+	FirstName          string                      `bson:"first_name"`
+	LastName           string                      `bson:"last_name"`
+	Status             string                      `bson:"status"`
+	StatusReason       string                      `bson:"status_reason,omitempty"`
+	StatusExpiresAt    *time.Time                  `bson:"status_expires_at,omitempty"`
+	StatusChangedAt    *time.Time                  `bson:"status_changed_at,omitempty"`
+	Roles              []string                    `bson:"roles"`
+	Version            int64                       `bson:"version"`
+	UpdatedAt          time.Time                   `bson:"updated_at"`
+	TokensNotBefore    *time.Time                  `bson:"tokens_not_before,omitempty"`
+	PendingEmailChange *pendingEmailChangeDocument `bson:"pending_email_change,omitempty"`
+}
+
+type pendingEmailChangeDocument struct {
+	Email           string    `bson:"email"`
+	NormalizedEmail string    `bson:"normalized_email"`
+	TokenHash       string    `bson:"token_hash"`
+	RequestedAt     time.Time `bson:"requested_at"`
+	ExpiresAt       time.Time `bson:"expires_at"`
+}
+
+func newUserDocument(user NewUserToSave) userDocument {
+	return userDocument{
+		ID:              user.ID,
+		RegisteredAt:    user.RegisteredAt,
+		Email:           user.Email,
+		NormalizedEmail: user.NormalizedEmail,
+		Password:        user.Password,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Status:          UserStatusActive,
+		Roles:           []string{},
+		Version:         1,
+		UpdatedAt:       user.RegisteredAt,
+	}
+}
+
+func (doc userDocument) statusInfo() UserStatusInfo {
+	status := doc.Status
+	if len(status) == 0 {
+		status = UserStatusActive
+	}
+
+	return UserStatusInfo{
+		Status:    status,
+		Reason:    doc.StatusReason,
+		ExpiresAt: doc.StatusExpiresAt,
+	}
+}
+
+func (doc userDocument) roles() []string {
+	if nil == doc.Roles {
+		return []string{}
+	}
+
+	return doc.Roles
+}
+
+func (doc userDocument) version() uint64 {
+	if doc.Version == 0 {
+		return 1
+	}
+
+	return uint64(doc.Version)
+}
+
+func (doc userDocument) toUser() User {
+	statusInfo := doc.statusInfo()
+	status := statusInfo.Effective(time.Now())
+	reason, until := doc.StatusReason, doc.StatusExpiresAt
+	if status != statusInfo.Status {
+		reason, until = "", nil
+	}
+	updatedAt := doc.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = doc.RegisteredAt
+	}
+
+	return User{
+		ID:           doc.ID,
+		FirstName:    doc.FirstName,
+		LastName:     doc.LastName,
+		Email:        doc.Email,
+		RegisteredAt: doc.RegisteredAt,
+		Status:       status,
+		StatusReason: reason,
+		StatusUntil:  until,
+		Roles:        doc.roles(),
+		Version:      doc.version(),
+		UpdatedAt:    updatedAt,
+	}
+}
+
 func (r *Repo) SaveNewUser(ctx DBOperationContext, user NewUserToSave) error {
-	userDoc := bson.D{
-		{Key: "id", Value: user.ID},
-		{Key: "registered_at", Value: user.RegisteredAt},
-		{Key: "email", Value: user.Email},
-		{Key: "normalized_email", Value: user.NormalizedEmail},
-		{Key: "password", Value: user.Password},
-		{Key: "first_name", Value: user.FirstName},
-		{Key: "last_name", Value: user.LastName},
+	userDoc := newUserDocument(user)
+	event, err := userRegisteredEvent(user)
+	if nil != err {
+		return err
 	}
 
 	span := ctx.span.StartChild("insert-user")
 	span.Status = sentry.SpanStatusOK
-	_, err := r.collections.Users.InsertOne(ctx, userDoc)
+	err = r.inTransaction(ctx, func(ctx context.Context) error {
+		if _, err := r.collections.Users.InsertOne(ctx, userDoc); nil != err {
+			return err
+		}
+
+		_, err := r.collections.Outbox.InsertOne(ctx, newOutboxEventDocument(event))
+		return err
+	})
 	if nil != err {
 		defer span.Finish()
 
+		// The availability check during validation races with concurrent
+		// registrations; the unique index on normalized_email settles it.
+		if isDuplicateKeyErrorOn(err, UsersEmailIndex) {
+			span.Status = sentry.SpanStatusAlreadyExists
+			return ErrEmailTaken
+		}
+
 		span.Status = sentry.SpanStatusInternalError
 		log := r.logger.WithError(err).WithField("err_code", "E_SAVE_USER")
 		apm.SetSpanTagsFromLogEntry(span, log)
@@ -64,13 +215,28 @@ func (r *Repo) GetUserLoginInfo(ctx DBOperationContext, email string) (*UserLogi
 	filter := bson.M{
 		"email": email,
 	}
+
+	return r.getUserLoginInfo(ctx, filter)
+}
+
+func (r *Repo) GetUserLoginInfoByID(ctx DBOperationContext, userID string) (*UserLoginInfo, error) {
+	filter := bson.M{
+		"id": userID,
+	}
+
+	return r.getUserLoginInfo(ctx, filter)
+}
+
+func (r *Repo) getUserLoginInfo(ctx DBOperationContext, filter bson.M) (*UserLoginInfo, error) {
 	projection := bson.D{
 		bson.E{Key: "_id", Value: 0},
 		bson.E{Key: "id", Value: 1},
 		bson.E{Key: "password", Value: 1},
+		bson.E{Key: "roles", Value: 1},
 	}
+	projection = append(projection, userStatusProjection...)
 	opts := options.FindOne().SetProjection(projection)
-	user := bson.M{}
+	var user userDocument
 
 	span := ctx.span.StartChild("query-user-login-info")
 	span.Status = sentry.SpanStatusOK
@@ -104,39 +270,31 @@ func (r *Repo) GetUserLoginInfo(ctx DBOperationContext, email string) (*UserLogi
 	}
 	span.Finish()
 
-	span = ctx.span.StartChild("parse-decoded-user-login-info")
-	span.Status = sentry.SpanStatusOK
-	passwd, ok := user["password"].(string)
-	if !ok {
-		defer span.Finish()
-
-		span.Status = sentry.SpanStatusInternalError
-		log := r.logger.WithField("err_code", "E_CAST_USER_PASSWORD_FIELD")
-		apm.SetSpanTagsFromLogEntry(span, log)
-		log.Error("could not parse user document password field")
-		return nil, errors.New("could not parse user password")
-	}
-	id, ok := user["id"].(string)
-	if !ok {
-		defer span.Finish()
-
-		span.Status = sentry.SpanStatusInternalError
-		log := r.logger.WithField("err_code", "E_CAST_USER_ID_FIELD")
-		apm.SetSpanTagsFromLogEntry(span, log)
-		log.Error("could not parse user document id field")
-		return nil, errors.New("could not parse user id")
-	}
-	span.Finish()
-
 	return &UserLoginInfo{
-		Password: passwd,
-		ID:       id,
+		Password: user.Password,
+		ID:       user.ID,
+		Status:   user.statusInfo(),
+		Roles:    user.roles(),
 	}, nil
 }
 
-func (r *Repo) NormalizedEmailExists(ctx DBOperationContext, normalizedEmail string) (bool, error) {
+// NormalizedEmailExists reports whether a user has the email or reserved it
+// with an unexpired pending email change. A reservation of the user with
+// exceptPendingOfUserID is ignored, since requesting the change again
+// replaces it anyway.
+func (r *Repo) NormalizedEmailExists(ctx DBOperationContext, normalizedEmail, exceptPendingOfUserID string) (bool, error) {
+	pending := bson.M{
+		"pending_email_change.normalized_email": normalizedEmail,
+		"pending_email_change.expires_at":       bson.M{"$gt": time.Now()},
+	}
+	if len(exceptPendingOfUserID) != 0 {
+		pending["id"] = bson.M{"$ne": exceptPendingOfUserID}
+	}
 	filter := bson.M{
-		"normalized_email": normalizedEmail,
+		"$or": bson.A{
+			bson.M{"normalized_email": normalizedEmail},
+			pending,
+		},
 	}
 
 	span := ctx.span.StartChild("check-user-with-email-existence")
@@ -171,9 +329,10 @@ func (r *Repo) UserWithIDExists(ctx DBOperationContext, userID string) (bool, er
 }
 
 func (r *Repo) userWithFilterExists(ctx DBOperationContext, filter bson.M) (bool, error) {
-	projection := bson.D{}
+	projection := bson.D{
+		bson.E{Key: "_id", Value: 1},
+	}
 	opts := options.FindOne().SetProjection(projection)
-	doc := bson.M{}
 
 	span := ctx.span.StartChild("query-user-with-filter")
 	span.Status = sentry.SpanStatusOK
@@ -194,35 +353,16 @@ func (r *Repo) userWithFilterExists(ctx DBOperationContext, filter bson.M) (bool
 	}
 	span.Finish()
 
-	span = ctx.span.StartChild("decode-queried-user")
-	span.Status = sentry.SpanStatusOK
-	if err := result.Decode(&doc); nil != err {
-		defer span.Finish()
-
-		span.Status = sentry.SpanStatusInternalError
-		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
-		apm.SetSpanTagsFromLogEntry(span, log)
-		log.Error("unable to decode user document")
-		return false, err
-	}
-	span.Finish()
-
-	if userID, ok := doc["_id"].(string); !ok || len(userID) > 0 {
-		defer span.Finish()
-
-		span.Status = sentry.SpanStatusOK
-		return true, nil
-	}
-
-	log := r.logger.WithField("err_code", "E_UNEXPECTED_LOGICAL_SITUATION")
-	apm.SetSpanTagsFromLogEntry(span, log)
-	log.Error("expected to not to reach this branch")
-	return false, errors.New("unexpected situation")
+	return true, nil
 }
 
 type UserAuthenticationInfo struct {
-	FirstName string
-	LastName  string
+	FirstName       string
+	LastName        string
+	Version         uint64
+	Status          UserStatusInfo
+	TokensNotBefore *time.Time
+	Roles           []string
 }
 
 func (r *Repo) GetUserAuthenticationInfo(ctx DBOperationContext, userID string) (*UserAuthenticationInfo, error) {
@@ -233,9 +373,13 @@ func (r *Repo) GetUserAuthenticationInfo(ctx DBOperationContext, userID string)
 		bson.E{Key: "_id", Value: 0},
 		bson.E{Key: "first_name", Value: 1},
 		bson.E{Key: "last_name", Value: 1},
+		bson.E{Key: "version", Value: 1},
+		bson.E{Key: "tokens_not_before", Value: 1},
+		bson.E{Key: "roles", Value: 1},
 	}
+	projection = append(projection, userStatusProjection...)
 	opts := options.FindOne().SetProjection(projection)
-	user := bson.M{}
+	var user userDocument
 
 	span := ctx.span.StartChild("query-user-authentication-info")
 	span.Status = sentry.SpanStatusOK
@@ -269,33 +413,12 @@ func (r *Repo) GetUserAuthenticationInfo(ctx DBOperationContext, userID string)
 	}
 	span.Finish()
 
-	span = ctx.span.StartChild("parse-decoded-authentication-info")
-	span.Status = sentry.SpanStatusOK
-	firstName, ok := user["first_name"].(string)
-	if !ok {
-		defer span.Finish()
-
-		span.Status = sentry.SpanStatusInternalError
-		log := r.logger.WithField("err_code", "E_CAST_USER_FIRST_NAME_FIELD")
-		apm.SetSpanTagsFromLogEntry(span, log)
-		log.Error("could not parse user document first_name field")
-		return nil, errors.New("could not parse user first_name")
-	}
-
-	lastName, ok := user["last_name"].(string)
-	if !ok {
-		defer span.Finish()
-
-		span.Status = sentry.SpanStatusInternalError
-		log := r.logger.WithField("err_code", "E_CAST_USER_LAST_NAME_FIELD")
-		apm.SetSpanTagsFromLogEntry(span, log)
-		log.Error("could not parse user document last_name field")
-		return nil, errors.New("could not parse user last_name")
-	}
-	span.Finish()
-
 	return &UserAuthenticationInfo{
-		FirstName: firstName,
-		LastName:  lastName,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Version:         user.version(),
+		Status:          user.statusInfo(),
+		TokensNotBefore: user.TokensNotBefore,
+		Roles:           user.roles(),
 	}, nil
 }
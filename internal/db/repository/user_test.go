@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsDuplicateKeyErrorOn(t *testing.T) {
+	duplicateOn := func(index string) error {
+		return mongo.WriteException{
+			WriteErrors: mongo.WriteErrors{{
+				Code:    11000,
+				Message: "E11000 duplicate key error collection: users.users index: " + index + " dup key: { x: 1 }",
+			}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"email index", duplicateOn(UsersEmailIndex), true},
+		{"wrapped email index", fmt.Errorf("saving user: %w", duplicateOn(UsersEmailIndex)), true},
+		{"id index", duplicateOn("id_unique"), false},
+		{"index with email index as prefix", duplicateOn(UsersEmailIndex + "_v2"), false},
+		{"other error", mongo.CommandError{Code: 112, Message: "WriteConflict index: " + UsersEmailIndex + " "}, false},
+		{"no server error", mongo.ErrNoDocuments, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDuplicateKeyErrorOn(tt.err, UsersEmailIndex); got != tt.want {
+				t.Fatalf("isDuplicateKeyErrorOn() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,544 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+)
+
+var (
+	ErrWebhookSubscriptionNotExists   = errors.New("webhook subscription does not exist")
+	ErrWebhookDeliveryNotExists       = errors.New("webhook delivery does not exist")
+	ErrWebhookDeliveryNotDeadLettered = errors.New("webhook delivery is not dead-lettered")
+)
+
+type WebhookDeliveryStatus = string
+
+const (
+	WebhookDeliveryStatusPending    WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusDelivered  WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusDeadLetter WebhookDeliveryStatus = "dead_letter"
+)
+
+// WebhookSubscription keeps its signing secret in plain text: signing needs
+// the secret itself, not a hash of it.
+type WebhookSubscription struct {
+	ID        string
+	URL       string
+	Events    []OutboxEventType
+	Secret    string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is one event on its way to one subscription. Body is the
+// exact request body, so retries send and sign the same bytes.
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	EventID        string
+	EventType      OutboxEventType
+	Body           string
+	Status         WebhookDeliveryStatus
+	Attempts       uint
+	NextAttemptAt  time.Time
+	LastError      string
+	LastStatusCode int
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// WebhookDeliveryFailure describes a failed attempt. A nil NextAttemptAt
+// gives up on the delivery and moves it to the dead-letter state.
+type WebhookDeliveryFailure struct {
+	Reason        string
+	StatusCode    int
+	NextAttemptAt *time.Time
+}
+
+type WebhookDeliveriesFilter struct {
+	SubscriptionID string
+	Status         WebhookDeliveryStatus
+	Limit          int64
+}
+
+type webhookSubscriptionDocument struct {
+	ID        string    `bson:"id"`
+	URL       string    `bson:"url"`
+	Events    []string  `bson:"events"`
+	Secret    string    `bson:"secret"`
+	CreatedBy string    `bson:"created_by"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func newWebhookSubscriptionDocument(subscription WebhookSubscription) webhookSubscriptionDocument {
+	return webhookSubscriptionDocument{
+		ID:        subscription.ID,
+		URL:       subscription.URL,
+		Events:    subscription.Events,
+		Secret:    subscription.Secret,
+		CreatedBy: subscription.CreatedBy,
+		CreatedAt: subscription.CreatedAt,
+	}
+}
+
+func (doc webhookSubscriptionDocument) toWebhookSubscription() WebhookSubscription {
+	events := doc.Events
+	if nil == events {
+		events = []string{}
+	}
+
+	return WebhookSubscription{
+		ID:        doc.ID,
+		URL:       doc.URL,
+		Events:    events,
+		Secret:    doc.Secret,
+		CreatedBy: doc.CreatedBy,
+		CreatedAt: doc.CreatedAt,
+	}
+}
+
+type webhookDeliveryDocument struct {
+	ID             string     `bson:"id"`
+	SubscriptionID string     `bson:"subscription_id"`
+	EventID        string     `bson:"event_id"`
+	EventType      string     `bson:"event_type"`
+	Body           string     `bson:"body"`
+	Status         string     `bson:"status"`
+	Attempts       int64      `bson:"attempts"`
+	NextAttemptAt  time.Time  `bson:"next_attempt_at"`
+	LastError      string     `bson:"last_error,omitempty"`
+	LastStatusCode int32      `bson:"last_status_code,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at"`
+	DeliveredAt    *time.Time `bson:"delivered_at,omitempty"`
+}
+
+func newWebhookDeliveryDocument(delivery WebhookDelivery) webhookDeliveryDocument {
+	return webhookDeliveryDocument{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Body:           delivery.Body,
+		Status:         WebhookDeliveryStatusPending,
+		Attempts:       0,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+func (doc webhookDeliveryDocument) toWebhookDelivery() WebhookDelivery {
+	return WebhookDelivery{
+		ID:             doc.ID,
+		SubscriptionID: doc.SubscriptionID,
+		EventID:        doc.EventID,
+		EventType:      doc.EventType,
+		Body:           doc.Body,
+		Status:         doc.Status,
+		Attempts:       uint(doc.Attempts),
+		NextAttemptAt:  doc.NextAttemptAt,
+		LastError:      doc.LastError,
+		LastStatusCode: int(doc.LastStatusCode),
+		CreatedAt:      doc.CreatedAt,
+		DeliveredAt:    doc.DeliveredAt,
+	}
+}
+
+// isOnlyDuplicateKeyError reports whether every write of an unordered bulk
+// insert failed on a unique index, which means the records already exist.
+func isOnlyDuplicateKeyError(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return mongo.IsDuplicateKeyError(err)
+	}
+	if nil != bulkErr.WriteConcernError || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *Repo) SaveNewWebhookSubscription(ctx DBOperationContext, subscription WebhookSubscription) error {
+	span := ctx.span.StartChild("insert-webhook-subscription")
+	span.Status = sentry.SpanStatusOK
+	if _, err := r.collections.Webhooks.InsertOne(ctx, newWebhookSubscriptionDocument(subscription)); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_SAVE_WEBHOOK_SUBSCRIPTION")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to save webhook subscription to database")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (r *Repo) GetWebhookSubscription(ctx DBOperationContext, subscriptionID string) (*WebhookSubscription, error) {
+	filter := bson.M{
+		"id": subscriptionID,
+	}
+	opts := options.
+		FindOne().
+		SetProjection(bson.D{{Key: "_id", Value: 0}})
+
+	span := ctx.span.StartChild("query-webhook-subscription")
+	span.Status = sentry.SpanStatusOK
+	var doc webhookSubscriptionDocument
+	if err := r.collections.Webhooks.FindOne(ctx, filter, opts).Decode(&doc); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrWebhookSubscriptionNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_WEBHOOK_SUBSCRIPTION")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving webhook subscription")
+		return nil, err
+	}
+	span.Finish()
+
+	subscription := doc.toWebhookSubscription()
+	return &subscription, nil
+}
+
+func (r *Repo) ListWebhookSubscriptions(ctx DBOperationContext) ([]WebhookSubscription, error) {
+	opts := options.
+		Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(bson.D{{Key: "_id", Value: 0}})
+
+	span := ctx.span.StartChild("query-webhook-subscriptions")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.Webhooks.Find(ctx, bson.M{}, opts)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_WEBHOOK_SUBSCRIPTIONS")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving webhook subscriptions")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-queried-webhook-subscriptions")
+	span.Status = sentry.SpanStatusOK
+	docs := []webhookSubscriptionDocument{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode webhook subscription documents")
+		return nil, err
+	}
+	span.Finish()
+
+	out := make([]WebhookSubscription, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, doc.toWebhookSubscription())
+	}
+
+	return out, nil
+}
+
+// DeleteWebhookSubscription removes the subscription together with its
+// deliveries, including the dead-lettered ones.
+func (r *Repo) DeleteWebhookSubscription(ctx DBOperationContext, subscriptionID string) error {
+	span := ctx.span.StartChild("delete-webhook-subscription")
+	span.Status = sentry.SpanStatusOK
+	var deleted bool
+	err := r.inTransaction(ctx, func(ctx context.Context) error {
+		result, err := r.collections.Webhooks.DeleteOne(ctx, bson.M{"id": subscriptionID})
+		if nil != err {
+			return err
+		}
+		if deleted = result.DeletedCount == 1; !deleted {
+			return nil
+		}
+
+		_, err = r.collections.WebhookDeliveries.DeleteMany(ctx, bson.M{"subscription_id": subscriptionID})
+		return err
+	})
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DELETE_WEBHOOK_SUBSCRIPTION")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed deleting webhook subscription")
+		return err
+	}
+	if !deleted {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusNotFound
+		return ErrWebhookSubscriptionNotExists
+	}
+	span.Finish()
+
+	return nil
+}
+
+// SaveNewWebhookDeliveries skips deliveries of an event a subscription already
+// has, so an outbox event published twice is still delivered once.
+func (r *Repo) SaveNewWebhookDeliveries(ctx DBOperationContext, deliveries []WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		docs = append(docs, newWebhookDeliveryDocument(delivery))
+	}
+	opts := options.
+		InsertMany().
+		SetOrdered(false)
+
+	span := ctx.span.StartChild("insert-webhook-deliveries")
+	span.Status = sentry.SpanStatusOK
+	if _, err := r.collections.WebhookDeliveries.InsertMany(ctx, docs, opts); nil != err && !isOnlyDuplicateKeyError(err) {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_SAVE_WEBHOOK_DELIVERIES")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to save webhook deliveries to database")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+// ClaimDueWebhookDelivery picks the pending delivery that is due the longest
+// and pushes its next attempt to leaseUntil, so no other dispatcher picks it up
+// meanwhile. A dispatcher that dies mid-attempt leaves the delivery to be
+// retried once the lease ran out.
+func (r *Repo) ClaimDueWebhookDelivery(ctx DBOperationContext, now, leaseUntil time.Time) (*WebhookDelivery, error) {
+	filter := bson.M{
+		"status":          WebhookDeliveryStatusPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": leaseUntil},
+	}
+	opts := options.
+		FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetProjection(bson.D{{Key: "_id", Value: 0}}).
+		SetReturnDocument(options.After)
+
+	span := ctx.span.StartChild("claim-webhook-delivery")
+	span.Status = sentry.SpanStatusOK
+	var doc webhookDeliveryDocument
+	if err := r.collections.WebhookDeliveries.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrWebhookDeliveryNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_CLAIM_WEBHOOK_DELIVERY")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed claiming due webhook delivery")
+		return nil, err
+	}
+	span.Finish()
+
+	delivery := doc.toWebhookDelivery()
+	return &delivery, nil
+}
+
+func (r *Repo) MarkWebhookDeliveryDelivered(ctx DBOperationContext, deliveryID string, statusCode int, at time.Time) error {
+	filter := bson.M{
+		"id": deliveryID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":           WebhookDeliveryStatusDelivered,
+			"last_status_code": int32(statusCode),
+			"delivered_at":     at,
+		},
+		"$unset": bson.M{"last_error": ""},
+		"$inc":   bson.M{"attempts": int64(1)},
+	}
+
+	span := ctx.span.StartChild("mark-webhook-delivery-delivered")
+	span.Status = sentry.SpanStatusOK
+	if _, err := r.collections.WebhookDeliveries.UpdateOne(ctx, filter, update); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_MARK_WEBHOOK_DELIVERY_DELIVERED")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed marking webhook delivery as delivered")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (r *Repo) MarkWebhookDeliveryFailed(ctx DBOperationContext, deliveryID string, failure WebhookDeliveryFailure) error {
+	filter := bson.M{
+		"id":     deliveryID,
+		"status": WebhookDeliveryStatusPending,
+	}
+	set := bson.M{
+		"last_error":       failure.Reason,
+		"last_status_code": int32(failure.StatusCode),
+	}
+	if nil == failure.NextAttemptAt {
+		set["status"] = WebhookDeliveryStatusDeadLetter
+	} else {
+		set["next_attempt_at"] = *failure.NextAttemptAt
+	}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"attempts": int64(1)},
+	}
+
+	span := ctx.span.StartChild("mark-webhook-delivery-failed")
+	span.Status = sentry.SpanStatusOK
+	if _, err := r.collections.WebhookDeliveries.UpdateOne(ctx, filter, update); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_MARK_WEBHOOK_DELIVERY_FAILED")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed recording webhook delivery failure")
+		return err
+	}
+	span.Finish()
+
+	return nil
+}
+
+// ListWebhookDeliveries returns the newest deliveries first.
+func (r *Repo) ListWebhookDeliveries(ctx DBOperationContext, filter WebhookDeliveriesFilter) ([]WebhookDelivery, error) {
+	query := bson.M{}
+	if len(filter.SubscriptionID) != 0 {
+		query["subscription_id"] = filter.SubscriptionID
+	}
+	if len(filter.Status) != 0 {
+		query["status"] = filter.Status
+	}
+	opts := options.
+		Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}}).
+		SetProjection(bson.D{{Key: "_id", Value: 0}})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+
+	span := ctx.span.StartChild("query-webhook-deliveries")
+	span.Status = sentry.SpanStatusOK
+	cursor, err := r.collections.WebhookDeliveries.Find(ctx, query, opts)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_WEBHOOK_DELIVERIES")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed retrieving webhook deliveries")
+		return nil, err
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("decode-queried-webhook-deliveries")
+	span.Status = sentry.SpanStatusOK
+	docs := []webhookDeliveryDocument{}
+	if err := cursor.All(ctx, &docs); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_DECODE_DOCUMENT")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("unable to decode webhook delivery documents")
+		return nil, err
+	}
+	span.Finish()
+
+	out := make([]WebhookDelivery, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, doc.toWebhookDelivery())
+	}
+
+	return out, nil
+}
+
+// RetryWebhookDelivery moves a dead-lettered delivery back to pending with a
+// fresh attempt budget, due at the given time.
+func (r *Repo) RetryWebhookDelivery(ctx DBOperationContext, deliveryID string, at time.Time) (*WebhookDelivery, error) {
+	filter := bson.M{
+		"id":     deliveryID,
+		"status": WebhookDeliveryStatusDeadLetter,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":          WebhookDeliveryStatusPending,
+			"attempts":        int64(0),
+			"next_attempt_at": at,
+		},
+	}
+	opts := options.
+		FindOneAndUpdate().
+		SetProjection(bson.D{{Key: "_id", Value: 0}}).
+		SetReturnDocument(options.After)
+
+	span := ctx.span.StartChild("retry-webhook-delivery")
+	span.Status = sentry.SpanStatusOK
+	var doc webhookDeliveryDocument
+	if err := r.collections.WebhookDeliveries.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			span.Status = sentry.SpanStatusAborted
+			count, err := r.collections.WebhookDeliveries.CountDocuments(ctx, bson.M{"id": deliveryID})
+			if nil != err {
+				span.Status = sentry.SpanStatusInternalError
+				log := r.logger.WithError(err).WithField("err_code", "E_RETRIEVE_WEBHOOK_DELIVERY")
+				apm.SetSpanTagsFromLogEntry(span, log)
+				log.Error("failed checking webhook delivery existence")
+				return nil, err
+			}
+			if count == 0 {
+				span.Status = sentry.SpanStatusNotFound
+				return nil, ErrWebhookDeliveryNotExists
+			}
+
+			return nil, ErrWebhookDeliveryNotDeadLettered
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := r.logger.WithError(err).WithField("err_code", "E_RETRY_WEBHOOK_DELIVERY")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed moving webhook delivery back to pending")
+		return nil, err
+	}
+	span.Finish()
+
+	delivery := doc.toWebhookDelivery()
+	return &delivery, nil
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/migrate"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/db/repository/repositorytest"
+)
+
+// The database backed suites run only against throwaway servers named by
+// these variables, since they drop and truncate what they write to. MongoDB
+// has to be a replica set, like in production.
+const (
+	testMongoURIEnv    = "USERS_TEST_MONGODB_URI"
+	testPostgresURLEnv = "USERS_TEST_POSTGRES_URL"
+)
+
+// postgresTestTables are emptied before every test of the suite.
+var postgresTestTables = []string{
+	"users",
+	"user_logins",
+	"user_login_daily_stats",
+	"audit_events",
+	"api_keys",
+	"outbox",
+	"role_bootstraps",
+	"webhooks",
+	"webhook_deliveries",
+}
+
+func newTestLogger() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	return logrus.NewEntry(logger)
+}
+
+func newTestSpan(t *testing.T) *sentry.Span {
+	t.Helper()
+
+	span := sentry.StartSpan(context.Background(), "test")
+	t.Cleanup(span.Finish)
+
+	return span
+}
+
+func TestMongoStore(t *testing.T) {
+	uri := os.Getenv(testMongoURIEnv)
+	if len(uri) == 0 {
+		t.Skipf("%s is not set", testMongoURIEnv)
+	}
+
+	repositorytest.Run(t, func(t *testing.T) repository.Store {
+		ctx := context.Background()
+		cfg := config.DatabaseConfig{
+			URI:                    uri,
+			Name:                   fmt.Sprintf("users_test_%d", time.Now().UnixNano()),
+			ConnectTimeout:         time.Second * 10,
+			ServerSelectionTimeout: time.Second * 10,
+		}
+		database, err := Connect(NewConnectContext(ctx, newTestSpan(t)), newTestLogger(), &cfg)
+		if nil != err {
+			t.Fatalf("unable to connect to mongodb: %s", err)
+		}
+		t.Cleanup(func() {
+			if err := database.database.Drop(ctx); nil != err {
+				t.Errorf("unable to drop test database: %s", err)
+			}
+			_ = database.Disconnect()
+		})
+
+		migrator := database.Migrator(newTestLogger(), &config.MigrationsConfig{LockTimeout: time.Minute})
+		if _, err := migrator.Up(migrate.NewContext(ctx, newTestSpan(t))); nil != err {
+			t.Fatalf("unable to apply migrations: %s", err)
+		}
+		if err := database.EnsureIndexes(NewConnectContext(ctx, newTestSpan(t))); nil != err {
+			t.Fatalf("unable to ensure indexes: %s", err)
+		}
+
+		return &database.Repo
+	})
+}
+
+func TestPostgresStore(t *testing.T) {
+	url := os.Getenv(testPostgresURLEnv)
+	if len(url) == 0 {
+		t.Skipf("%s is not set", testPostgresURLEnv)
+	}
+
+	ctx := context.Background()
+	cfg := config.PostgresConfig{
+		URL:            url,
+		MaxConns:       16,
+		ConnectTimeout: time.Second * 10,
+	}
+	database, err := ConnectPostgres(NewConnectContext(ctx, newTestSpan(t)), newTestLogger(), &cfg)
+	if nil != err {
+		t.Fatalf("unable to connect to postgres: %s", err)
+	}
+	defer database.Disconnect()
+
+	migrator := database.Migrator(newTestLogger(), &config.MigrationsConfig{LockTimeout: time.Minute})
+	if _, err := migrator.Up(migrate.NewContext(ctx, newTestSpan(t))); nil != err {
+		t.Fatalf("unable to apply migrations: %s", err)
+	}
+
+	repositorytest.Run(t, func(t *testing.T) repository.Store {
+		for _, table := range postgresTestTables {
+			if _, err := database.pool.Exec(ctx, "TRUNCATE "+table); nil != err {
+				t.Fatalf("unable to truncate %s: %s", table, err)
+			}
+		}
+
+		return database.Store
+	})
+}
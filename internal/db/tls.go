@@ -0,0 +1,43 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+)
+
+// newTLSConfig verifies the server against the configured CA bundle, or the
+// system roots when none is configured, and presents the client certificate
+// when one is configured.
+func newTLSConfig(cfg *config.DatabaseTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if len(cfg.CAFile) != 0 {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if nil != err {
+			return nil, fmt.Errorf("unable to read database tls ca file: %s", err)
+		}
+
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, errors.New("database tls ca file does not contain any pem encoded certificate")
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	if len(cfg.CertFile) != 0 {
+		certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if nil != err {
+			return nil, fmt.Errorf("unable to load database tls client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
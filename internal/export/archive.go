@@ -0,0 +1,126 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type document struct {
+	ExportedAt  time.Time                `json:"exported_at"`
+	User        map[string]interface{}   `json:"user"`
+	Logins      []map[string]interface{} `json:"logins"`
+	AuditEvents []map[string]interface{} `json:"audit_events"`
+}
+
+func (e exporter) Export(ctx Context, userID string, format Format) (*Archive, error) {
+	span := ctx.span.StartChild("collect-user-data")
+	span.Status = sentry.SpanStatusOK
+	data, err := e.repo.ExportUserData(repository.NewDBOperationContext(ctx, span), userID)
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrUserNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := e.logger.WithError(err).WithField("err_code", "E_COLLECT_USER_DATA")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed collecting user data for export")
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	doc := document{
+		ExportedAt:  time.Now().UTC(),
+		User:        data.User,
+		Logins:      data.Logins,
+		AuditEvents: data.AuditEvents,
+	}
+	baseName := fmt.Sprintf("user-data-%s-%s", userID, doc.ExportedAt.Format("20060102T150405Z"))
+
+	span = ctx.span.StartChild("encode-user-data")
+	span.Status = sentry.SpanStatusOK
+	var archive *Archive
+	if format == FormatZip {
+		archive, err = encodeZip(doc, baseName)
+	} else {
+		archive, err = encodeJSON(doc, baseName)
+	}
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := e.logger.WithError(err).WithField("err_code", "E_ENCODE_USER_DATA").WithField("format", format)
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed encoding user data export")
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	return archive, nil
+}
+
+func encodeJSON(doc document, baseName string) (*Archive, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if nil != err {
+		return nil, err
+	}
+
+	return &Archive{
+		ContentType: "application/json",
+		Filename:    baseName + ".json",
+		Data:        data,
+	}, nil
+}
+
+func encodeZip(doc document, baseName string) (*Archive, error) {
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{name: "user.json", content: doc.User},
+		{name: "logins.json", content: doc.Logins},
+		{name: "audit_events.json", content: doc.AuditEvents},
+	}
+
+	buf := bytes.Buffer{}
+	w := zip.NewWriter(&buf)
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.content, "", "  ")
+		if nil != err {
+			return nil, err
+		}
+
+		f, err := w.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: doc.ExportedAt,
+		})
+		if nil != err {
+			return nil, err
+		}
+		if _, err := f.Write(data); nil != err {
+			return nil, err
+		}
+	}
+	if err := w.Close(); nil != err {
+		return nil, err
+	}
+
+	return &Archive{
+		ContentType: "application/zip",
+		Filename:    baseName + ".zip",
+		Data:        buf.Bytes(),
+	}, nil
+}
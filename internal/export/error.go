@@ -0,0 +1,10 @@
+package export
+
+import (
+	"errors"
+)
+
+var (
+	ErrUserNotExists = errors.New("user does not exist")
+	ErrInternal      = errors.New("internal error occurred")
+)
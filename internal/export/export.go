@@ -0,0 +1,18 @@
+package export
+
+type Format = string
+
+const (
+	FormatJSON Format = "json"
+	FormatZip  Format = "zip"
+)
+
+type Archive struct {
+	ContentType string
+	Filename    string
+	Data        []byte
+}
+
+type Exporter interface {
+	Export(ctx Context, userID string, format Format) (*Archive, error)
+}
@@ -0,0 +1,19 @@
+package export
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type exporter struct {
+	repo   repository.Store
+	logger *logrus.Entry
+}
+
+func New(repo repository.Store, logger *logrus.Entry) Exporter {
+	return exporter{
+		repo,
+		logger,
+	}
+}
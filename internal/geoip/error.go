@@ -0,0 +1,9 @@
+package geoip
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidIP = errors.New("invalid ip address")
+)
@@ -0,0 +1,15 @@
+package geoip
+
+type Location struct {
+	CountryCode     string
+	CountryName     string
+	City            string
+	ASN             uint
+	ASNOrganization string
+}
+
+type Locator interface {
+	Enabled() bool
+	Locate(ip string) (*Location, error)
+	Close() error
+}
@@ -0,0 +1,77 @@
+package geoip
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+}
+
+type asnRecord struct {
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+type mmdbLocator struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+func (l mmdbLocator) Locate(ip string) (*Location, error) {
+	parsed := net.ParseIP(ip)
+	if nil == parsed {
+		return nil, ErrInvalidIP
+	}
+
+	out := Location{}
+
+	if nil != l.city {
+		var record cityRecord
+		if err := l.city.Lookup(parsed, &record); nil != err {
+			return nil, err
+		}
+
+		out.CountryCode = record.Country.ISOCode
+		out.CountryName = record.Country.Names["en"]
+		out.City = record.City.Names["en"]
+	}
+
+	if nil != l.asn {
+		var record asnRecord
+		if err := l.asn.Lookup(parsed, &record); nil != err {
+			return nil, err
+		}
+
+		out.ASN = record.AutonomousSystemNumber
+		out.ASNOrganization = record.AutonomousSystemOrganization
+	}
+
+	return &out, nil
+}
+
+func (l mmdbLocator) Enabled() bool {
+	return nil != l.city || nil != l.asn
+}
+
+func (l mmdbLocator) Close() error {
+	var err error
+	if nil != l.city {
+		err = l.city.Close()
+	}
+	if nil != l.asn {
+		if asnErr := l.asn.Close(); nil != asnErr {
+			err = asnErr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,35 @@
+package geoip
+
+import (
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+)
+
+func Open(logger *logrus.Entry, cfg *config.EnrichmentConfig) (Locator, error) {
+	l := mmdbLocator{}
+
+	if len(cfg.GeoIPCityDatabasePath) != 0 {
+		logger.WithField("path", cfg.GeoIPCityDatabasePath).Debug("opening geoip city database")
+		reader, err := maxminddb.Open(cfg.GeoIPCityDatabasePath)
+		if nil != err {
+			return nil, err
+		}
+		l.city = reader
+	}
+
+	if len(cfg.GeoIPASNDatabasePath) != 0 {
+		logger.WithField("path", cfg.GeoIPASNDatabasePath).Debug("opening geoip asn database")
+		reader, err := maxminddb.Open(cfg.GeoIPASNDatabasePath)
+		if nil != err {
+			if nil != l.city {
+				_ = l.city.Close()
+			}
+			return nil, err
+		}
+		l.asn = reader
+	}
+
+	return l, nil
+}
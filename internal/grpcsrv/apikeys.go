@@ -0,0 +1,325 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/game-sales-analytics/users-service/internal/apikey"
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/authz"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+func apiKeyToPB(key repository.APIKey) *pb.APIKey {
+	out := &pb.APIKey{
+		Id:        key.ID,
+		Prefix:    apikey.KeyPrefix + key.Prefix,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedBy: key.CreatedBy,
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	if nil != key.ExpiresAt {
+		out.ExpiresAt = timestamppb.New(*key.ExpiresAt)
+	}
+	if nil != key.LastUsedAt {
+		out.LastUsedAt = timestamppb.New(*key.LastUsedAt)
+	}
+	if nil != key.RevokedAt {
+		out.RevokedAt = timestamppb.New(*key.RevokedAt)
+	}
+
+	return out
+}
+
+// heldScopes reports whether the caller holds every requested scope, so keys
+// can never be used to escalate privileges. Calls without a principal only
+// happen with authorization disabled and are let through.
+func heldScopes(ctx context.Context, scopes []string) bool {
+	principal, ok := authz.PrincipalFromContext(ctx)
+	if !ok {
+		return true
+	}
+
+	for _, scope := range scopes {
+		if !principal.HasPermission(scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s server) CreateAPIKey(ctx context.Context, in *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "create-api-key", sentry.TransactionName("handle-create-api-key-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.CreateAPIKeyForm{
+		Name:      in.Name,
+		Scopes:    in.Scopes,
+		ExpiresAt: optionalTime(in.ExpiresAt),
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateCreateAPIKeyForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_CREATE_API_KEY_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating create api key form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("check-held-scopes")
+	child.Status = sentry.SpanStatusOK
+	if !heldScopes(ctx, form.Scopes) {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusPermissionDenied
+		return nil, status.Error(codes.PermissionDenied, "cannot grant scopes the caller does not hold")
+	}
+	child.Finish()
+
+	actorID := authz.ActorID(ctx)
+	child = span.StartChild("create-api-key")
+	child.Status = sentry.SpanStatusOK
+	created, err := s.keys.Create(apikey.NewContext(ctx, child), apikey.CreateRequest{
+		Name:      form.Name,
+		Scopes:    form.Scopes,
+		ExpiresAt: form.ExpiresAt,
+		CreatedBy: actorID,
+	})
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_CREATE_API_KEY")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed creating api key")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:    audit.EventTypeAdminAPIKeyCreated,
+		Outcome: audit.OutcomeSuccess,
+		ActorID: actorID,
+		Details: map[string]string{
+			"api_key_id": created.Key.ID,
+			"name":       created.Key.Name,
+			"scopes":     strings.Join(created.Key.Scopes, ","),
+		},
+	})
+	child.Finish()
+
+	return &pb.CreateAPIKeyReply{
+		ApiKey: apiKeyToPB(created.Key),
+		Secret: created.Secret,
+	}, nil
+}
+
+func (s server) ListAPIKeys(ctx context.Context, in *pb.ListAPIKeysRequest) (*pb.ListAPIKeysReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "list-api-keys", sentry.TransactionName("handle-list-api-keys-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	child := span.StartChild("list-api-keys")
+	child.Status = sentry.SpanStatusOK
+	keys, err := s.keys.List(apikey.NewContext(ctx, child), in.IncludeRevoked)
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_LIST_API_KEYS")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed listing api keys")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	out := make([]*pb.APIKey, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, apiKeyToPB(key))
+	}
+
+	return &pb.ListAPIKeysReply{
+		ApiKeys: out,
+	}, nil
+}
+
+func (s server) RevokeAPIKey(ctx context.Context, in *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "revoke-api-key", sentry.TransactionName("handle-revoke-api-key-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.RevokeAPIKeyForm{
+		ID: in.Id,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateRevokeAPIKeyForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_REVOKE_API_KEY_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating revoke api key form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("revoke-api-key")
+	child.Status = sentry.SpanStatusOK
+	key, err := s.keys.Revoke(apikey.NewContext(ctx, child), in.Id)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, apikey.ErrKeyNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return nil, status.Error(codes.NotFound, "api key not found")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_REVOKE_API_KEY")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed revoking api key")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:    audit.EventTypeAdminAPIKeyRevoked,
+		Outcome: audit.OutcomeSuccess,
+		ActorID: authz.ActorID(ctx),
+		Details: map[string]string{
+			"api_key_id": key.ID,
+		},
+	})
+	child.Finish()
+
+	return &pb.RevokeAPIKeyReply{
+		ApiKey: apiKeyToPB(*key),
+	}, nil
+}
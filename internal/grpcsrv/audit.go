@@ -0,0 +1,162 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/authz"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+const defaultPageSize = 50
+
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if nil == ts {
+		return nil
+	}
+
+	t := ts.AsTime()
+	return &t
+}
+
+func (s server) QueryAuditEvents(ctx context.Context, in *pb.QueryAuditEventsRequest) (*pb.QueryAuditEventsReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "query-audit-events", sentry.TransactionName("handle-query-audit-events-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	if err := s.requirePermission(ctx, rbac.PermissionAuditEventsRead); nil != err {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, err
+	}
+
+	form := validate.QueryAuditEventsForm{
+		Outcome:  in.Outcome,
+		From:     optionalTime(in.From),
+		To:       optionalTime(in.To),
+		PageSize: in.PageSize,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateQueryAuditEventsForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_QUERY_AUDIT_EVENTS_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating query audit events form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	pageSize := in.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	query := audit.Query{
+		Types:     in.Types,
+		Outcome:   in.Outcome,
+		ActorID:   in.ActorId,
+		SubjectID: in.SubjectId,
+		From:      form.From,
+		To:        form.To,
+		PageSize:  pageSize,
+		PageToken: in.PageToken,
+	}
+
+	child = span.StartChild("query-audit-events")
+	child.Status = sentry.SpanStatusOK
+	result, err := s.audit.Query(audit.NewContext(ctx, child), query)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, audit.ErrInvalidPageToken) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, "page_token", "invalid")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_QUERY_AUDIT_EVENTS")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed querying audit events")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:    audit.EventTypeAdminAuditEventsQueried,
+		Outcome: audit.OutcomeSuccess,
+		ActorID: authz.ActorID(ctx),
+	})
+	child.Finish()
+
+	events := make([]*pb.QueryAuditEventsReply_AuditEvent, 0, len(result.Events))
+	for _, event := range result.Events {
+		events = append(events, &pb.QueryAuditEventsReply_AuditEvent{
+			Id:         event.ID,
+			Type:       event.Type,
+			Outcome:    event.Outcome,
+			ActorId:    event.ActorID,
+			SubjectId:  event.SubjectID,
+			Ip:         event.IPAddress,
+			TraceId:    event.TraceID,
+			OccurredAt: timestamppb.New(event.OccurredAt),
+			Details:    event.Details,
+		})
+	}
+
+	return &pb.QueryAuditEventsReply{
+		Events:        events,
+		NextPageToken: result.NextPageToken,
+	}, nil
+}
@@ -9,7 +9,6 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/game-sales-analytics/users-service/internal/apm"
-	"github.com/game-sales-analytics/users-service/internal/auth"
 	"github.com/game-sales-analytics/users-service/internal/pb"
 	"github.com/game-sales-analytics/users-service/internal/validate"
 )
@@ -69,35 +68,19 @@ func (s server) Authenticate(ctx context.Context, in *pb.AuthenticateRequest) (*
 	}
 	child.Finish()
 
-	child = span.StartChild("verify-token")
-	child.Status = sentry.SpanStatusOK
-	verificationResult, err := s.auth.VerifyToken(auth.NewContext(ctx, child), in.Token)
+	verificationResult, err := s.verifyRequestToken(ctx, hub, span, in.Token)
 	if nil != err {
-		defer child.Finish()
-
-		if errors.Is(err, auth.ErrTokenNotVerified) || errors.Is(err, auth.ErrUserNotExists) {
-			child.Status = sentry.SpanStatusUnauthenticated
-			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
-		}
-
-		child.Status = sentry.SpanStatusInternalError
-		log := s.logger.WithError(err).WithField("err_code", "E_VERIFY_TOKEN")
-		apm.SetSpanTagsFromLogEntry(child, log)
-		log.Error("failed verifying user auth token")
-		hub.WithScope(func(scope *sentry.Scope) {
-			scope.SetLevel(sentry.LevelError)
-			scope.SetExtras(log.Data)
-			hub.CaptureException(err)
-		})
-		return nil, errorInternal
+		return nil, err
 	}
-	child.Finish()
 
 	return &pb.AuthenticateReply{
 		AuthenticatedUser: &pb.AuthenticateReply_AuthenticatedUser{
-			Id:        verificationResult.User.ID,
-			FirstName: verificationResult.User.FirstName,
-			LastName:  verificationResult.User.LastName,
+			Id:          verificationResult.User.ID,
+			FirstName:   verificationResult.User.FirstName,
+			LastName:    verificationResult.User.LastName,
+			Version:     verificationResult.User.Version,
+			Roles:       verificationResult.User.Roles,
+			Permissions: verificationResult.User.Permissions,
 		},
 	}, nil
 }
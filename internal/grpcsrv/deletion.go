@@ -0,0 +1,170 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/auth"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+func (s server) DeleteAccount(ctx context.Context, in *pb.DeleteAccountRequest) (*pb.DeleteAccountReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "delete-account", sentry.TransactionName("handle-delete-account-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.DeleteAccountForm{
+		Token:    in.Token,
+		Password: in.Password,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateDeleteAccountForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_DELETE_ACCOUNT_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating delete account form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	verificationResult, err := s.verifyRequestToken(ctx, hub, span, in.Token)
+	if nil != err {
+		return nil, err
+	}
+
+	child = span.StartChild("verify-password")
+	child.Status = sentry.SpanStatusOK
+	if err := s.auth.VerifyPassword(auth.NewContext(ctx, child), verificationResult.User.ID, in.Password); nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			child.Status = sentry.SpanStatusUnauthenticated
+			s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+				Type:      audit.EventTypeUserDeletionRequested,
+				Outcome:   audit.OutcomeFailure,
+				ActorID:   verificationResult.User.ID,
+				SubjectID: verificationResult.User.ID,
+				Details: map[string]string{
+					"reason": "invalid_credentials",
+				},
+			})
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VERIFY_PASSWORD")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed verifying user password")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	requestedAt := time.Now()
+	purgeAfter := requestedAt.Add(s.deletionCfg.GracePeriod)
+	change := repository.UserStatusChange{
+		Status:       repository.UserStatusPendingDeletion,
+		Reason:       "requested_by_user",
+		ExpiresAt:    &purgeAfter,
+		ChangedAt:    requestedAt,
+		RevokeTokens: true,
+	}
+	allowedFrom := []repository.UserStatus{
+		repository.UserStatusActive,
+		repository.UserStatusPendingVerification,
+	}
+	child = span.StartChild("schedule-account-deletion")
+	child.Status = sentry.SpanStatusOK
+	if _, err := s.repo.ChangeUserStatus(repository.NewDBOperationContext(ctx, child), verificationResult.User.ID, allowedFrom, change); nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusUnauthenticated
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		if errors.Is(err, repository.ErrUserStatusTransitionNotAllowed) {
+			child.Status = sentry.SpanStatusFailedPrecondition
+			return nil, status.Error(codes.FailedPrecondition, "account cannot be deleted in its current status")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_SCHEDULE_ACCOUNT_DELETION")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed scheduling user account deletion")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeUserDeletionRequested,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   verificationResult.User.ID,
+		SubjectID: verificationResult.User.ID,
+		Details: map[string]string{
+			"purge_after": purgeAfter.UTC().Format(time.RFC3339),
+		},
+	})
+	child.Finish()
+
+	return &pb.DeleteAccountReply{
+		PurgeAfter: timestamppb.New(purgeAfter),
+	}, nil
+}
@@ -0,0 +1,323 @@
+package grpcsrv
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/id"
+	"github.com/game-sales-analytics/users-service/internal/mailer"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+func hashConfirmationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s server) RequestEmailChange(ctx context.Context, in *pb.RequestEmailChangeRequest) (*pb.RequestEmailChangeReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "request-email-change", sentry.TransactionName("handle-request-email-change-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	authForm := validate.AuthenticateForm{
+		Token: in.Token,
+	}
+	child := span.StartChild("validate-auth-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateAuthenticateForm(validate.NewContext(ctx, child), authForm); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_AUTHENTICATE_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating authenticate form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	// the token is verified before the new email is validated, since the
+	// user's own pending reservation of it does not make it unavailable
+	verificationResult, err := s.verifyRequestToken(ctx, hub, span, in.Token)
+	if nil != err {
+		return nil, err
+	}
+
+	form := validate.RequestEmailChangeForm{
+		UserID:   verificationResult.User.ID,
+		NewEmail: in.NewEmail,
+	}
+	child = span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	normalizedForm, err := s.validator.ValidateRequestEmailChangeForm(validate.NewContext(ctx, child), form)
+	if nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_REQUEST_EMAIL_CHANGE_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating request email change form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("generate-confirmation-token")
+	child.Status = sentry.SpanStatusOK
+	confirmationToken, err := id.GenerateSecretToken()
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_GENERATE_CONFIRMATION_TOKEN")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed generating email change confirmation token")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	requestedAt := time.Now()
+	change := repository.PendingEmailChange{
+		Email:           in.NewEmail,
+		NormalizedEmail: normalizedForm.Email,
+		TokenHash:       hashConfirmationToken(confirmationToken),
+		RequestedAt:     requestedAt,
+		ExpiresAt:       requestedAt.Add(s.emailChangeCfg.TokenTTL),
+	}
+
+	child = span.StartChild("save-pending-email-change")
+	child.Status = sentry.SpanStatusOK
+	if err := s.repo.SavePendingEmailChange(repository.NewDBOperationContext(ctx, child), verificationResult.User.ID, change); nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusUnauthenticated
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_SAVE_PENDING_EMAIL_CHANGE")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed saving pending email change")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("send-confirmation-email")
+	child.Status = sentry.SpanStatusOK
+	if err := s.mailer.Send(ctx, mailer.EmailChangeConfirmation(in.NewEmail, confirmationToken, change.ExpiresAt)); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_SEND_EMAIL_CHANGE_CONFIRMATION")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed sending email change confirmation")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeUserEmailChangeRequested,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   verificationResult.User.ID,
+		SubjectID: verificationResult.User.ID,
+		Details: map[string]string{
+			"new_email": in.NewEmail,
+		},
+	})
+	child.Finish()
+
+	return &pb.RequestEmailChangeReply{
+		ExpirationDateTime: timestamppb.New(change.ExpiresAt),
+	}, nil
+}
+
+func (s server) ConfirmEmailChange(ctx context.Context, in *pb.ConfirmEmailChangeRequest) (*pb.ConfirmEmailChangeReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "confirm-email-change", sentry.TransactionName("handle-confirm-email-change-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.ConfirmEmailChangeForm{
+		ConfirmationToken: in.ConfirmationToken,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateConfirmEmailChangeForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_CONFIRM_EMAIL_CHANGE_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating confirm email change form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("confirm-email-change")
+	child.Status = sentry.SpanStatusOK
+	confirmed, err := s.repo.ConfirmEmailChange(repository.NewDBOperationContext(ctx, child), hashConfirmationToken(in.ConfirmationToken), time.Now())
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, repository.ErrEmailChangeNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return nil, status.Error(codes.NotFound, "confirmation token is invalid or expired")
+		}
+		if errors.Is(err, repository.ErrEmailTaken) {
+			child.Status = sentry.SpanStatusFailedPrecondition
+			return nil, status.Error(codes.FailedPrecondition, "email address is no longer available")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_CONFIRM_EMAIL_CHANGE")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed confirming email change")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("send-email-changed-notice")
+	child.Status = sentry.SpanStatusOK
+	if err := s.mailer.Send(ctx, mailer.EmailChangedNotice(confirmed.OldEmail, confirmed.NewEmail)); nil != err {
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_SEND_EMAIL_CHANGED_NOTICE")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed notifying previous email address about email change")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeUserEmailChanged,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   confirmed.UserID,
+		SubjectID: confirmed.UserID,
+		Details: map[string]string{
+			"old_email": confirmed.OldEmail,
+			"new_email": confirmed.NewEmail,
+		},
+	})
+	child.Finish()
+
+	return &pb.ConfirmEmailChangeReply{
+		Id:    confirmed.UserID,
+		Email: confirmed.NewEmail,
+	}, nil
+}
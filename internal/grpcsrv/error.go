@@ -6,5 +6,6 @@ import (
 )
 
 var (
-	errorInternal = status.Error(codes.Internal, "internal error occurred. try again later.")
+	errorInternal         = status.Error(codes.Internal, "internal error occurred. try again later.")
+	errorPermissionDenied = status.Error(codes.PermissionDenied, "permission denied")
 )
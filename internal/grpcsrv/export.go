@@ -0,0 +1,266 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/authz"
+	"github.com/game-sales-analytics/users-service/internal/export"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+const exportChunkSize = 64 * 1024
+
+type exportChunkSender interface {
+	Send(*pb.ExportUserDataChunk) error
+}
+
+// sendArchive streams the archive in fixed size chunks. Only the first chunk
+// carries the content type and file name.
+func sendArchive(stream exportChunkSender, archive *export.Archive) error {
+	data := archive.Data
+	first := true
+	for first || len(data) > 0 {
+		size := exportChunkSize
+		if len(data) < size {
+			size = len(data)
+		}
+
+		chunk := pb.ExportUserDataChunk{
+			Data: data[:size],
+		}
+		if first {
+			chunk.ContentType = archive.ContentType
+			chunk.Filename = archive.Filename
+			first = false
+		}
+		if err := stream.Send(&chunk); nil != err {
+			return err
+		}
+
+		data = data[size:]
+	}
+
+	return nil
+}
+
+func (s server) exportUserData(ctx context.Context, hub *sentry.Hub, span *sentry.Span, stream exportChunkSender, userID, format string) error {
+	child := span.StartChild("export-user-data")
+	child.Status = sentry.SpanStatusOK
+	archive, err := s.exporter.Export(export.NewContext(ctx, child), userID, format)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, export.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return status.Error(codes.NotFound, "user not found")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_EXPORT_USER_DATA")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed exporting user data")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("send-user-data-archive")
+	child.Status = sentry.SpanStatusOK
+	if err := sendArchive(stream, archive); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusAborted
+		log := s.logger.WithError(err).WithField("err_code", "E_SEND_USER_DATA_ARCHIVE")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Warn("failed streaming user data archive to client")
+		return err
+	}
+	child.Finish()
+
+	return nil
+}
+
+func (s server) ExportUserData(in *pb.ExportUserDataRequest, stream pb.UsersService_ExportUserDataServer) error {
+	ctx := stream.Context()
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "export-user-data", sentry.TransactionName("handle-export-user-data-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.ExportUserDataForm{
+		Token:  in.Token,
+		Format: in.Format,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateExportUserDataForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_EXPORT_USER_DATA_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating export user data form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return errorInternal
+	}
+	child.Finish()
+
+	verificationResult, err := s.verifyRequestToken(ctx, hub, span, in.Token)
+	if nil != err {
+		return err
+	}
+
+	format := in.Format
+	if len(format) == 0 {
+		format = export.FormatJSON
+	}
+	if err := s.exportUserData(ctx, hub, span, stream, verificationResult.User.ID, format); nil != err {
+		return err
+	}
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeUserDataExported,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   verificationResult.User.ID,
+		SubjectID: verificationResult.User.ID,
+		Details: map[string]string{
+			"format": format,
+		},
+	})
+	child.Finish()
+
+	return nil
+}
+
+func (s server) AdminExportUserData(in *pb.AdminExportUserDataRequest, stream pb.UsersService_AdminExportUserDataServer) error {
+	ctx := stream.Context()
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "admin-export-user-data", sentry.TransactionName("handle-admin-export-user-data-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return errorInternal
+	}
+	span.TraceID = traceID
+
+	if err := s.requirePermission(ctx, rbac.PermissionUsersExport); nil != err {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return err
+	}
+
+	form := validate.AdminExportUserDataForm{
+		ID:     in.Id,
+		Format: in.Format,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateAdminExportUserDataForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_ADMIN_EXPORT_USER_DATA_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating admin export user data form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return errorInternal
+	}
+	child.Finish()
+
+	format := in.Format
+	if len(format) == 0 {
+		format = export.FormatJSON
+	}
+	if err := s.exportUserData(ctx, hub, span, stream, in.Id, format); nil != err {
+		return err
+	}
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeAdminUserDataExported,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   authz.ActorID(ctx),
+		SubjectID: in.Id,
+		Details: map[string]string{
+			"format": format,
+		},
+	})
+	child.Finish()
+
+	return nil
+}
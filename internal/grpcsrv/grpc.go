@@ -2,18 +2,35 @@ package grpcsrv
 
 import (
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 
+	"github.com/game-sales-analytics/users-service/internal/apikey"
+	"github.com/game-sales-analytics/users-service/internal/audit"
 	"github.com/game-sales-analytics/users-service/internal/auth"
+	"github.com/game-sales-analytics/users-service/internal/config"
 	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/export"
+	"github.com/game-sales-analytics/users-service/internal/mailer"
 	"github.com/game-sales-analytics/users-service/internal/pb"
 	"github.com/game-sales-analytics/users-service/internal/validate"
+	"github.com/game-sales-analytics/users-service/internal/webhook"
 )
 
 func New(
 	logger *logrus.Entry,
-	repo *repository.Repo,
+	repo repository.Store,
 	validator validate.Validator,
 	auth auth.Auth,
+	audit audit.Trail,
+	exporter export.Exporter,
+	keys apikey.Keys,
+	webhooks webhook.Webhooks,
+	mailer mailer.Mailer,
+	emailChangeCfg *config.EmailChangeConfig,
+	deletionCfg *config.AccountDeletionConfig,
+	authzCfg *config.AuthorizationConfig,
+	interceptors []grpc.UnaryServerInterceptor,
+	streamInterceptors []grpc.StreamServerInterceptor,
 ) GrpcService {
 	return server{
 		pb.UnimplementedUsersServiceServer{},
@@ -21,5 +38,15 @@ func New(
 		repo,
 		validator,
 		auth,
+		audit,
+		exporter,
+		keys,
+		webhooks,
+		mailer,
+		emailChangeCfg,
+		deletionCfg,
+		authzCfg,
+		interceptors,
+		streamInterceptors,
 	}
 }
@@ -0,0 +1,151 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/authz"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/pagetoken"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+func (s server) ListUsers(ctx context.Context, in *pb.ListUsersRequest) (*pb.ListUsersReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "list-users", sentry.TransactionName("handle-list-users-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	if err := s.requirePermission(ctx, rbac.PermissionUsersList); nil != err {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, err
+	}
+
+	form := validate.ListUsersForm{
+		RegisteredFrom: optionalTime(in.RegisteredFrom),
+		RegisteredTo:   optionalTime(in.RegisteredTo),
+		Status:         in.Status,
+		EmailDomain:    in.EmailDomain,
+		PageSize:       in.PageSize,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateListUsersForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_LIST_USERS_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating list users form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	pageSize := in.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	filter := repository.UsersFilter{
+		RegisteredFrom: form.RegisteredFrom,
+		RegisteredTo:   form.RegisteredTo,
+		Status:         in.Status,
+		EmailDomain:    in.EmailDomain,
+		EmailPrefix:    in.EmailPrefix,
+		NameQuery:      in.NameQuery,
+		Limit:          int64(pageSize) + 1,
+	}
+	if len(in.PageToken) != 0 {
+		registeredAt, userID, err := pagetoken.Decode(in.PageToken)
+		if nil != err {
+			span.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, "page_token", "invalid")
+		}
+		filter.After = &repository.UsersCursor{
+			RegisteredAt: registeredAt,
+			ID:           userID,
+		}
+	}
+
+	child = span.StartChild("list-users")
+	child.Status = sentry.SpanStatusOK
+	users, err := s.repo.ListUsers(repository.NewDBOperationContext(ctx, child), filter)
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_LIST_USERS")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed listing users")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:    audit.EventTypeAdminUsersListed,
+		Outcome: audit.OutcomeSuccess,
+		ActorID: authz.ActorID(ctx),
+	})
+	child.Finish()
+
+	reply := pb.ListUsersReply{
+		Users: make([]*pb.User, 0, len(users)),
+	}
+	if len(users) > int(pageSize) {
+		users = users[:pageSize]
+		last := users[len(users)-1]
+		reply.NextPageToken = pagetoken.Encode(last.RegisteredAt, last.ID)
+	}
+	for _, user := range users {
+		reply.Users = append(reply.Users, userToPB(user))
+	}
+
+	return &reply, nil
+}
@@ -10,7 +10,9 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
 	"github.com/game-sales-analytics/users-service/internal/auth"
+	"github.com/game-sales-analytics/users-service/internal/authz"
 	"github.com/game-sales-analytics/users-service/internal/pb"
 	"github.com/game-sales-analytics/users-service/internal/validate"
 )
@@ -48,6 +50,7 @@ func (s server) LoginWithEmail(ctx context.Context, in *pb.LoginWithEmailRequest
 		Password:        in.Password,
 		DeviceUserAgent: in.DeviceUserAgent,
 		IP:              in.Ip,
+		Challenge:       in.Challenge,
 	}
 	child := span.StartChild("validate-form")
 	child.Status = sentry.SpanStatusOK
@@ -90,9 +93,33 @@ func (s server) LoginWithEmail(ctx context.Context, in *pb.LoginWithEmailRequest
 
 		if errors.Is(err, auth.ErrUnauthenticated) {
 			child.Status = sentry.SpanStatusInvalidArgument
+			s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+				Type:      audit.EventTypeUserLogin,
+				Outcome:   audit.OutcomeFailure,
+				IPAddress: in.Ip,
+				Details: map[string]string{
+					"email":  in.Email,
+					"reason": "invalid_credentials",
+				},
+			})
 			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
 		}
 
+		var suspendedErr *auth.SuspendedError
+		if errors.As(err, &suspendedErr) {
+			child.Status = sentry.SpanStatusPermissionDenied
+			s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+				Type:      audit.EventTypeUserLogin,
+				Outcome:   audit.OutcomeFailure,
+				IPAddress: in.Ip,
+				Details: map[string]string{
+					"email":  in.Email,
+					"reason": "account_suspended",
+				},
+			})
+			return nil, authz.AccountSuspendedError(suspendedErr)
+		}
+
 		child.Status = sentry.SpanStatusInternalError
 		log := s.logger.WithError(err).WithField("err_code", "E_LOGIN_WITH_EMAIL")
 		apm.SetSpanTagsFromLogEntry(child, log)
@@ -106,6 +133,29 @@ func (s server) LoginWithEmail(ctx context.Context, in *pb.LoginWithEmailRequest
 	}
 	child.Finish()
 
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeUserLogin,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   loginRes.UserID,
+		SubjectID: loginRes.UserID,
+		IPAddress: in.Ip,
+		Details: map[string]string{
+			"token_id": loginRes.Token.ID,
+		},
+	})
+	if loginRes.DeletionCancelled {
+		s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+			Type:      audit.EventTypeUserDeletionCancelled,
+			Outcome:   audit.OutcomeSuccess,
+			ActorID:   loginRes.UserID,
+			SubjectID: loginRes.UserID,
+			IPAddress: in.Ip,
+		})
+	}
+	child.Finish()
+
 	return &pb.LoginWithEmailReply{
 		AuthToken: &pb.LoginWithEmailReply_AuthToken{
 			Id:                 loginRes.Token.ID,
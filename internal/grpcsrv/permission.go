@@ -0,0 +1,30 @@
+package grpcsrv
+
+import (
+	"context"
+
+	"github.com/game-sales-analytics/users-service/internal/authz"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+)
+
+// requirePermission checks the caller of an admin RPC once more in its
+// handler, so the RPC stays closed when the authorization interceptor is not
+// installed or its policy misses the method. Only with authorization turned
+// off are calls let through without a principal.
+func (s server) requirePermission(ctx context.Context, permission rbac.Permission) error {
+	if !s.authzCfg.Enabled {
+		return nil
+	}
+
+	principal, ok := authz.PrincipalFromContext(ctx)
+	if !ok {
+		s.logger.WithField("permission", permission).Warn("denying admin call without an authorized principal")
+		return errorPermissionDenied
+	}
+	if !principal.HasPermission(permission) {
+		s.logger.WithField("user_id", principal.UserID).WithField("api_key_id", principal.APIKeyID).WithField("permission", permission).Debug("caller lacks required permission")
+		return errorPermissionDenied
+	}
+
+	return nil
+}
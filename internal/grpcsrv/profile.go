@@ -0,0 +1,147 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+func (s server) UpdateProfile(ctx context.Context, in *pb.UpdateProfileRequest) (*pb.UpdateProfileReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "update-profile", sentry.TransactionName("handle-update-profile-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.UpdateProfileForm{
+		Token:     in.Token,
+		FirstName: in.GetProfile().GetFirstName(),
+		LastName:  in.GetProfile().GetLastName(),
+		Paths:     in.GetUpdateMask().GetPaths(),
+		Version:   in.Version,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateUpdateProfileForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_UPDATE_PROFILE_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating update profile form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	verificationResult, err := s.verifyRequestToken(ctx, hub, span, in.Token)
+	if nil != err {
+		return nil, err
+	}
+
+	update := repository.UserProfileUpdate{
+		UpdatedAt: time.Now(),
+	}
+	for _, path := range form.Paths {
+		switch path {
+		case "first_name":
+			update.FirstName = &form.FirstName
+		case "last_name":
+			update.LastName = &form.LastName
+		}
+	}
+
+	child = span.StartChild("update-user-profile")
+	child.Status = sentry.SpanStatusOK
+	user, err := s.repo.UpdateUserProfile(repository.NewDBOperationContext(ctx, child), verificationResult.User.ID, in.Version, update)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, repository.ErrVersionConflict) {
+			child.Status = sentry.SpanStatusAborted
+			return nil, status.Error(codes.Aborted, "profile was modified concurrently. reload and try again.")
+		}
+		if errors.Is(err, repository.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusUnauthenticated
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_UPDATE_USER_PROFILE")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed updating user profile")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeUserProfileUpdated,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   user.ID,
+		SubjectID: user.ID,
+		Details: map[string]string{
+			"fields": strings.Join(form.Paths, ","),
+		},
+	})
+	child.Finish()
+
+	return &pb.UpdateProfileReply{
+		Profile: &pb.UpdateProfileReply_UpdatedProfile{
+			Id:        user.ID,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Version:   user.Version,
+			UpdatedAt: timestamppb.New(user.UpdatedAt),
+		},
+	}, nil
+}
@@ -11,6 +11,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
 	"github.com/game-sales-analytics/users-service/internal/db/repository"
 	"github.com/game-sales-analytics/users-service/internal/id"
 	"github.com/game-sales-analytics/users-service/internal/passhash"
@@ -52,6 +53,7 @@ func (s server) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.Regis
 		PasswordConfirmation: in.PasswordConfirmation,
 		FirstName:            in.FirstName,
 		LastName:             in.LastName,
+		Challenge:            in.Challenge,
 	}
 	child := span.StartChild("validate-form")
 	child.Status = sentry.SpanStatusOK
@@ -134,6 +136,13 @@ func (s server) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.Regis
 	if err := s.repo.SaveNewUser(repository.NewDBOperationContext(ctx, child), user); nil != err {
 		defer child.Finish()
 
+		if errors.Is(err, repository.ErrEmailTaken) {
+			// Lost the race against a concurrent registration of the same
+			// address; report it like the validation step would have.
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, "email", "duplicate email address")
+		}
+
 		child.Status = sentry.SpanStatusInternalError
 		log := s.logger.WithError(err).WithField("err_code", "E_SAVE_USER")
 		apm.SetSpanTagsFromLogEntry(child, log)
@@ -148,6 +157,16 @@ func (s server) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.Regis
 	}
 	child.Finish()
 
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeUserRegistered,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   user.ID,
+		SubjectID: user.ID,
+	})
+	child.Finish()
+
 	return &pb.RegisterReply{
 		RegisteredUser: &pb.RegisterReply_RegisteredUser{
 			Id:           user.ID,
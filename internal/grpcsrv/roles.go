@@ -0,0 +1,274 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/authz"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+func (s server) GrantRole(ctx context.Context, in *pb.GrantRoleRequest) (*pb.GrantRoleReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "grant-role", sentry.TransactionName("handle-grant-role-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	if err := s.requirePermission(ctx, rbac.PermissionRolesManage); nil != err {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, err
+	}
+
+	form := validate.ChangeRoleForm{
+		ID:   in.Id,
+		Role: in.Role,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateChangeRoleForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_CHANGE_ROLE_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating change role form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("add-user-role")
+	child.Status = sentry.SpanStatusOK
+	user, err := s.repo.AddUserRole(repository.NewDBOperationContext(ctx, child), in.Id, in.Role, time.Now())
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_ADD_USER_ROLE")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed adding role to user")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeAdminRoleGranted,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   authz.ActorID(ctx),
+		SubjectID: user.ID,
+		Details: map[string]string{
+			"role": in.Role,
+		},
+	})
+	child.Finish()
+
+	return &pb.GrantRoleReply{
+		User: userToPB(*user),
+	}, nil
+}
+
+func (s server) RevokeRole(ctx context.Context, in *pb.RevokeRoleRequest) (*pb.RevokeRoleReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "revoke-role", sentry.TransactionName("handle-revoke-role-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	if err := s.requirePermission(ctx, rbac.PermissionRolesManage); nil != err {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, err
+	}
+
+	form := validate.ChangeRoleForm{
+		ID:   in.Id,
+		Role: in.Role,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateChangeRoleForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_CHANGE_ROLE_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating change role form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	if in.Role == rbac.RoleAdmin {
+		child = span.StartChild("check-remaining-admins")
+		child.Status = sentry.SpanStatusOK
+		admins, err := s.repo.CountUsersWithRole(repository.NewDBOperationContext(ctx, child), rbac.RoleAdmin)
+		if nil != err {
+			defer child.Finish()
+
+			child.Status = sentry.SpanStatusInternalError
+			log := s.logger.WithError(err).WithField("err_code", "E_COUNT_ADMINS")
+			apm.SetSpanTagsFromLogEntry(child, log)
+			log.Error("failed counting administrators")
+			hub.WithScope(func(scope *sentry.Scope) {
+				scope.SetLevel(sentry.LevelError)
+				scope.SetExtras(log.Data)
+				hub.CaptureException(err)
+			})
+			return nil, errorInternal
+		}
+		if admins <= 1 {
+			target, err := s.repo.GetUser(repository.NewDBOperationContext(ctx, child), in.Id)
+			if nil != err {
+				defer child.Finish()
+
+				if errors.Is(err, repository.ErrUserNotExists) {
+					child.Status = sentry.SpanStatusNotFound
+					return nil, status.Error(codes.NotFound, "user not found")
+				}
+
+				child.Status = sentry.SpanStatusInternalError
+				log := s.logger.WithError(err).WithField("err_code", "E_GET_USER")
+				apm.SetSpanTagsFromLogEntry(child, log)
+				log.Error("failed retrieving user")
+				hub.WithScope(func(scope *sentry.Scope) {
+					scope.SetLevel(sentry.LevelError)
+					scope.SetExtras(log.Data)
+					hub.CaptureException(err)
+				})
+				return nil, errorInternal
+			}
+			if rbac.HasRole(target.Roles, rbac.RoleAdmin) {
+				defer child.Finish()
+
+				child.Status = sentry.SpanStatusFailedPrecondition
+				return nil, status.Error(codes.FailedPrecondition, "cannot revoke the role of the last administrator")
+			}
+		}
+		child.Finish()
+	}
+
+	child = span.StartChild("remove-user-role")
+	child.Status = sentry.SpanStatusOK
+	user, err := s.repo.RemoveUserRole(repository.NewDBOperationContext(ctx, child), in.Id, in.Role, time.Now())
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_REMOVE_USER_ROLE")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed removing role from user")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeAdminRoleRevoked,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   authz.ActorID(ctx),
+		SubjectID: user.ID,
+		Details: map[string]string{
+			"role": in.Role,
+		},
+	})
+	child.Finish()
+
+	return &pb.RevokeRoleReply{
+		User: userToPB(*user),
+	}, nil
+}
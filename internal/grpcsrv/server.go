@@ -7,10 +7,16 @@ import (
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 
+	"github.com/game-sales-analytics/users-service/internal/apikey"
+	"github.com/game-sales-analytics/users-service/internal/audit"
 	"github.com/game-sales-analytics/users-service/internal/auth"
+	"github.com/game-sales-analytics/users-service/internal/config"
 	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/export"
+	"github.com/game-sales-analytics/users-service/internal/mailer"
 	"github.com/game-sales-analytics/users-service/internal/pb"
 	"github.com/game-sales-analytics/users-service/internal/validate"
+	"github.com/game-sales-analytics/users-service/internal/webhook"
 )
 
 type GrpcService interface {
@@ -19,10 +25,20 @@ type GrpcService interface {
 
 type server struct {
 	pb.UnimplementedUsersServiceServer
-	logger    *logrus.Entry
-	repo      *repository.Repo
-	validator validate.Validator
-	auth      auth.Auth
+	logger             *logrus.Entry
+	repo               repository.Store
+	validator          validate.Validator
+	auth               auth.Auth
+	audit              audit.Trail
+	exporter           export.Exporter
+	keys               apikey.Keys
+	webhooks           webhook.Webhooks
+	mailer             mailer.Mailer
+	emailChangeCfg     *config.EmailChangeConfig
+	deletionCfg        *config.AccountDeletionConfig
+	authzCfg           *config.AuthorizationConfig
+	interceptors       []grpc.UnaryServerInterceptor
+	streamInterceptors []grpc.StreamServerInterceptor
 }
 
 func (s server) Listen(host string, port uint) error {
@@ -32,7 +48,10 @@ func (s server) Listen(host string, port uint) error {
 		s.logger.WithField("host", host).WithField("port", port).WithError(err).WithField("err_code", "E_SERVER_TCP_BIND").Error("failed to start listening at specified address")
 		return err
 	}
-	var opts []grpc.ServerOption
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.interceptors...),
+		grpc.ChainStreamInterceptor(s.streamInterceptors...),
+	}
 	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterUsersServiceServer(grpcServer, s)
 	return grpcServer.Serve(lis)
@@ -0,0 +1,252 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/authz"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+func (s server) SuspendUser(ctx context.Context, in *pb.SuspendUserRequest) (*pb.SuspendUserReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "suspend-user", sentry.TransactionName("handle-suspend-user-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	if err := s.requirePermission(ctx, rbac.PermissionUsersModerate); nil != err {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, err
+	}
+
+	form := validate.SuspendUserForm{
+		ID:        in.Id,
+		Reason:    in.Reason,
+		ExpiresAt: optionalTime(in.ExpiresAt),
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateSuspendUserForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_SUSPEND_USER_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating suspend user form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	change := repository.UserStatusChange{
+		Status:    repository.UserStatusSuspended,
+		Reason:    form.Reason,
+		ExpiresAt: form.ExpiresAt,
+		ChangedAt: time.Now(),
+	}
+	allowedFrom := []repository.UserStatus{
+		repository.UserStatusActive,
+		repository.UserStatusSuspended,
+		repository.UserStatusPendingVerification,
+	}
+	child = span.StartChild("suspend-user")
+	child.Status = sentry.SpanStatusOK
+	user, err := s.repo.ChangeUserStatus(repository.NewDBOperationContext(ctx, child), in.Id, allowedFrom, change)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		if errors.Is(err, repository.ErrUserStatusTransitionNotAllowed) {
+			child.Status = sentry.SpanStatusFailedPrecondition
+			return nil, status.Error(codes.FailedPrecondition, "user cannot be suspended in its current status")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_SUSPEND_USER")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed suspending user")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	details := map[string]string{
+		"reason": form.Reason,
+	}
+	if nil != form.ExpiresAt {
+		details["expires_at"] = form.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeAdminUserSuspended,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   authz.ActorID(ctx),
+		SubjectID: user.ID,
+		Details:   details,
+	})
+	child.Finish()
+
+	return &pb.SuspendUserReply{
+		User: userToPB(*user),
+	}, nil
+}
+
+func (s server) ReactivateUser(ctx context.Context, in *pb.ReactivateUserRequest) (*pb.ReactivateUserReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "reactivate-user", sentry.TransactionName("handle-reactivate-user-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	if err := s.requirePermission(ctx, rbac.PermissionUsersModerate); nil != err {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, err
+	}
+
+	form := validate.ReactivateUserForm{
+		ID: in.Id,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateReactivateUserForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_REACTIVATE_USER_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating reactivate user form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	change := repository.UserStatusChange{
+		Status:    repository.UserStatusActive,
+		ChangedAt: time.Now(),
+	}
+	allowedFrom := []repository.UserStatus{
+		repository.UserStatusSuspended,
+	}
+	child = span.StartChild("reactivate-user")
+	child.Status = sentry.SpanStatusOK
+	user, err := s.repo.ChangeUserStatus(repository.NewDBOperationContext(ctx, child), in.Id, allowedFrom, change)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		if errors.Is(err, repository.ErrUserStatusTransitionNotAllowed) {
+			child.Status = sentry.SpanStatusFailedPrecondition
+			return nil, status.Error(codes.FailedPrecondition, "user is not suspended")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_REACTIVATE_USER")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed reactivating user")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:      audit.EventTypeAdminUserReactivated,
+		Outcome:   audit.OutcomeSuccess,
+		ActorID:   authz.ActorID(ctx),
+		SubjectID: user.ID,
+	})
+	child.Finish()
+
+	return &pb.ReactivateUserReply{
+		User: userToPB(*user),
+	}, nil
+}
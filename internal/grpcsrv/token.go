@@ -0,0 +1,62 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/auth"
+	"github.com/game-sales-analytics/users-service/internal/authz"
+)
+
+func (s server) verifyRequestToken(ctx context.Context, hub *sentry.Hub, span *sentry.Span, token string) (*auth.TokenVerificationResult, error) {
+	child := span.StartChild("verify-token")
+	child.Status = sentry.SpanStatusOK
+	defer child.Finish()
+
+	verificationResult, err := s.auth.VerifyToken(auth.NewContext(ctx, child), token)
+	if nil != err {
+		if errors.Is(err, auth.ErrTokenNotVerified) || errors.Is(err, auth.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusUnauthenticated
+			s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+				Type:    audit.EventTypeTokenVerificationFailed,
+				Outcome: audit.OutcomeFailure,
+				Details: map[string]string{
+					"reason": err.Error(),
+				},
+			})
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+
+		var suspendedErr *auth.SuspendedError
+		if errors.As(err, &suspendedErr) {
+			child.Status = sentry.SpanStatusPermissionDenied
+			s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+				Type:    audit.EventTypeTokenVerificationFailed,
+				Outcome: audit.OutcomeFailure,
+				Details: map[string]string{
+					"reason": err.Error(),
+				},
+			})
+			return nil, authz.AccountSuspendedError(suspendedErr)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VERIFY_TOKEN")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed verifying user auth token")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+
+	return verificationResult, nil
+}
@@ -0,0 +1,234 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+)
+
+func userToPB(user repository.User) *pb.User {
+	out := &pb.User{
+		Id:           user.ID,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Email:        user.Email,
+		RegisteredAt: timestamppb.New(user.RegisteredAt),
+		Status:       user.Status,
+		Version:      user.Version,
+		UpdatedAt:    timestamppb.New(user.UpdatedAt),
+		StatusReason: user.StatusReason,
+		Roles:        user.Roles,
+	}
+	if nil != user.StatusUntil {
+		out.StatusExpiresAt = timestamppb.New(*user.StatusUntil)
+	}
+
+	return out
+}
+
+func (s server) GetUser(ctx context.Context, in *pb.GetUserRequest) (*pb.GetUserReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "get-user", sentry.TransactionName("handle-get-user-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	if err := s.requirePermission(ctx, rbac.PermissionUsersRead); nil != err {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, err
+	}
+
+	form := validate.GetUserForm{
+		ID: in.Id,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateGetUserForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_GET_USER_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating get user form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("get-user")
+	child.Status = sentry.SpanStatusOK
+	user, err := s.repo.GetUser(repository.NewDBOperationContext(ctx, child), in.Id)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, repository.ErrUserNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_GET_USER")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed retrieving user")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	return &pb.GetUserReply{
+		User: userToPB(*user),
+	}, nil
+}
+
+func (s server) BatchGetUsers(ctx context.Context, in *pb.BatchGetUsersRequest) (*pb.BatchGetUsersReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "batch-get-users", sentry.TransactionName("handle-batch-get-users-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	if err := s.requirePermission(ctx, rbac.PermissionUsersRead); nil != err {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, err
+	}
+
+	form := validate.BatchGetUsersForm{
+		IDs: in.Ids,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateBatchGetUsersForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_BATCH_GET_USERS_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating batch get users form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	uniqueIDs := make([]string, 0, len(in.Ids))
+	seen := make(map[string]struct{}, len(in.Ids))
+	for _, id := range in.Ids {
+		if _, exists := seen[id]; !exists {
+			seen[id] = struct{}{}
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+
+	child = span.StartChild("get-users-by-ids")
+	child.Status = sentry.SpanStatusOK
+	users, err := s.repo.GetUsersByIDs(repository.NewDBOperationContext(ctx, child), uniqueIDs)
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_GET_USERS_BY_IDS")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed retrieving users by ids")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	found := make(map[string]repository.User, len(users))
+	for _, user := range users {
+		found[user.ID] = user
+	}
+
+	reply := pb.BatchGetUsersReply{
+		Users:      make([]*pb.User, 0, len(found)),
+		MissingIds: []string{},
+	}
+	for _, id := range uniqueIDs {
+		if user, exists := found[id]; exists {
+			reply.Users = append(reply.Users, userToPB(user))
+		} else {
+			reply.MissingIds = append(reply.MissingIds, id)
+		}
+	}
+
+	return &reply, nil
+}
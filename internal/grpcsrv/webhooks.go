@@ -0,0 +1,499 @@
+package grpcsrv
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/authz"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/pb"
+	"github.com/game-sales-analytics/users-service/internal/validate"
+	"github.com/game-sales-analytics/users-service/internal/webhook"
+)
+
+// webhookSubscriptionToPB leaves out the signing secret, which is only
+// returned when the subscription is created.
+func webhookSubscriptionToPB(subscription repository.WebhookSubscription) *pb.WebhookSubscription {
+	return &pb.WebhookSubscription{
+		Id:        subscription.ID,
+		Url:       subscription.URL,
+		Events:    subscription.Events,
+		CreatedBy: subscription.CreatedBy,
+		CreatedAt: timestamppb.New(subscription.CreatedAt),
+	}
+}
+
+func webhookDeliveryToPB(delivery repository.WebhookDelivery) *pb.WebhookDelivery {
+	out := &pb.WebhookDelivery{
+		Id:             delivery.ID,
+		SubscriptionId: delivery.SubscriptionID,
+		EventId:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       uint32(delivery.Attempts),
+		LastError:      delivery.LastError,
+		LastStatusCode: uint32(delivery.LastStatusCode),
+		CreatedAt:      timestamppb.New(delivery.CreatedAt),
+	}
+	if delivery.Status == repository.WebhookDeliveryStatusPending {
+		out.NextAttemptAt = timestamppb.New(delivery.NextAttemptAt)
+	}
+	if nil != delivery.DeliveredAt {
+		out.DeliveredAt = timestamppb.New(*delivery.DeliveredAt)
+	}
+
+	return out
+}
+
+func (s server) CreateWebhookSubscription(ctx context.Context, in *pb.CreateWebhookSubscriptionRequest) (*pb.CreateWebhookSubscriptionReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "create-webhook-subscription", sentry.TransactionName("handle-create-webhook-subscription-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.CreateWebhookSubscriptionForm{
+		URL:    in.Url,
+		Events: in.Events,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateCreateWebhookSubscriptionForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_CREATE_WEBHOOK_SUBSCRIPTION_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating create webhook subscription form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	actorID := authz.ActorID(ctx)
+	child = span.StartChild("create-webhook-subscription")
+	child.Status = sentry.SpanStatusOK
+	subscription, err := s.webhooks.Create(webhook.NewContext(ctx, child), webhook.CreateRequest{
+		URL:       form.URL,
+		Events:    form.Events,
+		CreatedBy: actorID,
+	})
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_CREATE_WEBHOOK_SUBSCRIPTION")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed creating webhook subscription")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:    audit.EventTypeAdminWebhookCreated,
+		Outcome: audit.OutcomeSuccess,
+		ActorID: actorID,
+		Details: map[string]string{
+			"subscription_id": subscription.ID,
+			"url":             subscription.URL,
+			"events":          strings.Join(subscription.Events, ","),
+		},
+	})
+	child.Finish()
+
+	return &pb.CreateWebhookSubscriptionReply{
+		Subscription: webhookSubscriptionToPB(*subscription),
+		Secret:       subscription.Secret,
+	}, nil
+}
+
+func (s server) ListWebhookSubscriptions(ctx context.Context, in *pb.ListWebhookSubscriptionsRequest) (*pb.ListWebhookSubscriptionsReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "list-webhook-subscriptions", sentry.TransactionName("handle-list-webhook-subscriptions-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	child := span.StartChild("list-webhook-subscriptions")
+	child.Status = sentry.SpanStatusOK
+	subscriptions, err := s.webhooks.List(webhook.NewContext(ctx, child))
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_LIST_WEBHOOK_SUBSCRIPTIONS")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed listing webhook subscriptions")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	out := make([]*pb.WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		out = append(out, webhookSubscriptionToPB(subscription))
+	}
+
+	return &pb.ListWebhookSubscriptionsReply{
+		Subscriptions: out,
+	}, nil
+}
+
+func (s server) DeleteWebhookSubscription(ctx context.Context, in *pb.DeleteWebhookSubscriptionRequest) (*pb.DeleteWebhookSubscriptionReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "delete-webhook-subscription", sentry.TransactionName("handle-delete-webhook-subscription-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.DeleteWebhookSubscriptionForm{
+		ID: in.Id,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateDeleteWebhookSubscriptionForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_DELETE_WEBHOOK_SUBSCRIPTION_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating delete webhook subscription form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("delete-webhook-subscription")
+	child.Status = sentry.SpanStatusOK
+	if err := s.webhooks.Delete(webhook.NewContext(ctx, child), form.ID); nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, webhook.ErrSubscriptionNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return nil, status.Error(codes.NotFound, "webhook subscription not found")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_DELETE_WEBHOOK_SUBSCRIPTION")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed deleting webhook subscription")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:    audit.EventTypeAdminWebhookDeleted,
+		Outcome: audit.OutcomeSuccess,
+		ActorID: authz.ActorID(ctx),
+		Details: map[string]string{
+			"subscription_id": form.ID,
+		},
+	})
+	child.Finish()
+
+	return &pb.DeleteWebhookSubscriptionReply{}, nil
+}
+
+func (s server) ListWebhookDeliveries(ctx context.Context, in *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "list-webhook-deliveries", sentry.TransactionName("handle-list-webhook-deliveries-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.ListWebhookDeliveriesForm{
+		Status:   in.Status,
+		PageSize: in.PageSize,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateListWebhookDeliveriesForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_LIST_WEBHOOK_DELIVERIES_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating list webhook deliveries form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	pageSize := in.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	child = span.StartChild("list-webhook-deliveries")
+	child.Status = sentry.SpanStatusOK
+	deliveries, err := s.webhooks.ListDeliveries(webhook.NewContext(ctx, child), repository.WebhookDeliveriesFilter{
+		SubscriptionID: in.SubscriptionId,
+		Status:         form.Status,
+		Limit:          int64(pageSize),
+	})
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_LIST_WEBHOOK_DELIVERIES")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed listing webhook deliveries")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	out := make([]*pb.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		out = append(out, webhookDeliveryToPB(delivery))
+	}
+
+	return &pb.ListWebhookDeliveriesReply{
+		Deliveries: out,
+	}, nil
+}
+
+func (s server) RetryWebhookDelivery(ctx context.Context, in *pb.RetryWebhookDeliveryRequest) (*pb.RetryWebhookDeliveryReply, error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	defer apm.RecoverUnaryWithSentry(hub, ctx, in)
+	span := sentry.StartSpan(ctx, "retry-webhook-delivery", sentry.TransactionName("handle-retry-webhook-delivery-request"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	traceID, err := apm.ReadOrGenerateTraceID(ctx)
+	if nil != err {
+		span.Status = sentry.SpanStatusFailedPrecondition
+
+		log := s.logger.WithError(err).WithField("err_code", "E_READ_OT_GENERATE_TRACE_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed read or generating trace id from context")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+
+		return nil, errorInternal
+	}
+	span.TraceID = traceID
+
+	form := validate.RetryWebhookDeliveryForm{
+		ID: in.Id,
+	}
+	child := span.StartChild("validate-form")
+	child.Status = sentry.SpanStatusOK
+	if err := s.validator.ValidateRetryWebhookDeliveryForm(validate.NewContext(ctx, child), form); nil != err {
+		defer child.Finish()
+
+		var validationErr *validate.ValidationError
+		if errors.As(err, &validationErr) {
+			child.Status = sentry.SpanStatusInvalidArgument
+			return nil, status.Errorf(codes.InvalidArgument, `{"field":"%s","error":"%s"}`, validationErr.Field, validationErr.Message)
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_VALIDATE_RETRY_WEBHOOK_DELIVERY_FORM")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed validating retry webhook delivery form")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("retry-webhook-delivery")
+	child.Status = sentry.SpanStatusOK
+	delivery, err := s.webhooks.RetryDelivery(webhook.NewContext(ctx, child), form.ID)
+	if nil != err {
+		defer child.Finish()
+
+		if errors.Is(err, webhook.ErrDeliveryNotExists) {
+			child.Status = sentry.SpanStatusNotFound
+			return nil, status.Error(codes.NotFound, "webhook delivery not found")
+		}
+		if errors.Is(err, webhook.ErrDeliveryNotDeadLettered) {
+			child.Status = sentry.SpanStatusFailedPrecondition
+			return nil, status.Error(codes.FailedPrecondition, "webhook delivery is not dead-lettered")
+		}
+
+		child.Status = sentry.SpanStatusInternalError
+		log := s.logger.WithError(err).WithField("err_code", "E_RETRY_WEBHOOK_DELIVERY")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed retrying webhook delivery")
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelError)
+			scope.SetExtras(log.Data)
+			hub.CaptureException(err)
+		})
+		return nil, errorInternal
+	}
+	child.Finish()
+
+	child = span.StartChild("record-audit-event")
+	child.Status = sentry.SpanStatusOK
+	s.audit.Record(audit.NewContext(ctx, child), audit.Event{
+		Type:    audit.EventTypeAdminWebhookDeliveryRetried,
+		Outcome: audit.OutcomeSuccess,
+		ActorID: authz.ActorID(ctx),
+		Details: map[string]string{
+			"delivery_id":     delivery.ID,
+			"subscription_id": delivery.SubscriptionID,
+		},
+	})
+	child.Finish()
+
+	return &pb.RetryWebhookDeliveryReply{
+		Delivery: webhookDeliveryToPB(*delivery),
+	}, nil
+}
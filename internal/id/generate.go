@@ -17,3 +17,23 @@ func GenerateUserID() (string, error) {
 
 	return uniqueID.String(), nil
 }
+
+func GenerateAuditEventID() (string, error) {
+	return xid.New().String(), nil
+}
+
+func GenerateAPIKeyID() (string, error) {
+	return xid.New().String(), nil
+}
+
+func GenerateOutboxEventID() (string, error) {
+	return xid.New().String(), nil
+}
+
+func GenerateWebhookSubscriptionID() (string, error) {
+	return xid.New().String(), nil
+}
+
+func GenerateWebhookDeliveryID() (string, error) {
+	return xid.New().String(), nil
+}
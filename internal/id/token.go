@@ -0,0 +1,15 @@
+package id
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+func GenerateSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); nil != err {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
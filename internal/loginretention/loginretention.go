@@ -0,0 +1,12 @@
+package loginretention
+
+import (
+	"context"
+)
+
+// Enforcer rolls completed days of user logins into anonymized daily stats
+// and removes logins older than the retention period where the store does
+// not expire them by itself.
+type Enforcer interface {
+	Run(ctx context.Context)
+}
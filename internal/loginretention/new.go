@@ -0,0 +1,31 @@
+package loginretention
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type enforcer struct {
+	logger *logrus.Entry
+	repo   repository.Store
+	cfg    *config.LoginRetentionConfig
+
+	// pruneExpired is set for stores without a TTL index expiring logins.
+	pruneExpired bool
+}
+
+func New(
+	logger *logrus.Entry,
+	repo repository.Store,
+	cfg *config.LoginRetentionConfig,
+	pruneExpired bool,
+) Enforcer {
+	return enforcer{
+		logger,
+		repo,
+		cfg,
+		pruneExpired,
+	}
+}
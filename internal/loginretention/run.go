@@ -0,0 +1,182 @@
+package loginretention
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+const batchSize = 100
+
+// maxDaysPerRun bounds how many days one run aggregates, so catching up on a
+// long history is spread over several runs.
+const maxDaysPerRun = 31
+
+// settleTime is how long after a day ends it is aggregated, leaving logins
+// recorded around midnight time to be stored.
+const settleTime = time.Minute * 5
+
+const oneDay = time.Hour * 24
+
+func (e enforcer) Run(ctx context.Context) {
+	e.logger.
+		WithField("interval", e.cfg.Interval).
+		WithField("retention", e.cfg.Period).
+		WithField("aggregate", e.cfg.Aggregate).
+		Debug("starting user logins retention enforcer")
+
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		e.enforce(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.logger.Debug("stopping user logins retention enforcer")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e enforcer) enforce(ctx context.Context) {
+	span := sentry.StartSpan(ctx, "enforce-user-logins-retention", sentry.TransactionName("enforce-user-logins-retention"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	now := time.Now().UTC()
+	if e.cfg.Aggregate {
+		if err := e.aggregateCompletedDays(ctx, span, now); nil != err {
+			span.Status = sentry.SpanStatusInternalError
+			return
+		}
+	}
+
+	if e.pruneExpired && e.cfg.Period != 0 {
+		e.pruneExpiredLogins(ctx, span, now.Add(-e.cfg.Period))
+	}
+}
+
+// aggregateCompletedDays stores the stats of every day since the last
+// aggregated one that is over. Days whose logins may have already started to
+// expire are skipped, since their stats would be incomplete.
+func (e enforcer) aggregateCompletedDays(ctx context.Context, span *sentry.Span, now time.Time) error {
+	child := span.StartChild("find-first-day-to-aggregate")
+	child.Status = sentry.SpanStatusOK
+	next, err := e.firstDayToAggregate(repository.NewDBOperationContext(ctx, child))
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		log := e.logger.WithError(err).WithField("err_code", "E_FIND_DAY_TO_AGGREGATE")
+		apm.SetSpanTagsFromLogEntry(child, log)
+		log.Error("failed finding first day of user logins to aggregate")
+		return err
+	}
+	child.Finish()
+	if nil == next {
+		return nil
+	}
+
+	day := *next
+	if e.cfg.Period != 0 {
+		expiredBefore := now.Add(-e.cfg.Period)
+		if !day.After(expiredBefore) {
+			first := expiredBefore.Truncate(oneDay).Add(oneDay)
+			e.logger.
+				WithField("from", day).
+				WithField("to", first).
+				Warn("skipping days of user logins that have partly expired before being aggregated")
+			day = first
+		}
+	}
+
+	aggregated := 0
+	for ; aggregated < maxDaysPerRun && !day.Add(oneDay+settleTime).After(now); day = day.Add(oneDay) {
+		child = span.StartChild("aggregate-user-logins-of-day")
+		child.Status = sentry.SpanStatusOK
+		if err := e.aggregateDay(repository.NewDBOperationContext(ctx, child), day, now); nil != err {
+			defer child.Finish()
+
+			child.Status = sentry.SpanStatusInternalError
+			log := e.logger.WithError(err).WithField("err_code", "E_AGGREGATE_USER_LOGINS").WithField("day", day)
+			apm.SetSpanTagsFromLogEntry(child, log)
+			log.Error("failed aggregating user logins. will retry on next run")
+			return err
+		}
+		child.Finish()
+		aggregated++
+	}
+
+	if aggregated > 0 {
+		e.logger.WithField("count", aggregated).Info("aggregated days of user logins")
+	}
+
+	return nil
+}
+
+// firstDayToAggregate returns the day after the last aggregated one or, when
+// none was aggregated yet, the day of the earliest login. It returns nil when
+// there is nothing to aggregate.
+func (e enforcer) firstDayToAggregate(ctx repository.DBOperationContext) (*time.Time, error) {
+	latest, err := e.repo.LatestUserLoginDailyStatsDay(ctx)
+	if nil != err {
+		return nil, err
+	}
+	if nil != latest {
+		next := latest.Add(oneDay)
+		return &next, nil
+	}
+
+	earliest, err := e.repo.EarliestUserLoginTime(ctx)
+	if nil != err || nil == earliest {
+		return nil, err
+	}
+
+	first := earliest.UTC().Truncate(oneDay)
+	return &first, nil
+}
+
+func (e enforcer) aggregateDay(ctx repository.DBOperationContext, day, now time.Time) error {
+	stats, err := e.repo.ComputeUserLoginDailyStats(ctx, day)
+	if nil != err {
+		return err
+	}
+
+	stats.AggregatedAt = now
+	_, err = e.repo.SaveUserLoginDailyStats(ctx, *stats)
+	return err
+}
+
+func (e enforcer) pruneExpiredLogins(ctx context.Context, span *sentry.Span, before time.Time) {
+	pruned := int64(0)
+	for {
+		child := span.StartChild("prune-expired-user-logins")
+		child.Status = sentry.SpanStatusOK
+		deleted, err := e.repo.DeleteUserLoginsBefore(repository.NewDBOperationContext(ctx, child), before, batchSize)
+		if nil != err {
+			defer child.Finish()
+
+			child.Status = sentry.SpanStatusInternalError
+			log := e.logger.WithError(err).WithField("err_code", "E_PRUNE_USER_LOGINS")
+			apm.SetSpanTagsFromLogEntry(child, log)
+			log.Error("failed pruning expired user logins. will retry on next run")
+			return
+		}
+		child.Finish()
+		pruned += deleted
+
+		if deleted < batchSize {
+			break
+		}
+	}
+
+	if pruned > 0 {
+		e.logger.WithField("count", pruned).Info("pruned expired user logins")
+	}
+}
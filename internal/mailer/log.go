@@ -0,0 +1,27 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type logMailer struct {
+	logger *logrus.Entry
+}
+
+func NewLogMailer(logger *logrus.Entry) Mailer {
+	return logMailer{
+		logger,
+	}
+}
+
+func (m logMailer) Send(ctx context.Context, message Message) error {
+	m.logger.
+		WithField("to", message.To).
+		WithField("subject", message.Subject).
+		WithField("body", message.Body).
+		Info("sending email")
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package mailer
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+)
+
+func New(logger *logrus.Entry, cfg *config.MailerConfig) Mailer {
+	switch cfg.Driver {
+	case config.MailerDriverSMTP:
+		return NewSMTPMailer(cfg)
+	default:
+		return NewLogMailer(logger)
+	}
+}
@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+)
+
+type smtpMailer struct {
+	cfg *config.MailerConfig
+}
+
+func NewSMTPMailer(cfg *config.MailerConfig) Mailer {
+	return smtpMailer{
+		cfg,
+	}
+}
+
+func (m smtpMailer) Send(ctx context.Context, message Message) error {
+	addr := net.JoinHostPort(m.cfg.SMTPHost, strconv.FormatUint(uint64(m.cfg.SMTPPort), 10))
+
+	var auth smtp.Auth
+	if len(m.cfg.SMTPUsername) != 0 {
+		auth = smtp.PlainAuth("", m.cfg.SMTPUsername, m.cfg.SMTPPassword, m.cfg.SMTPHost)
+	}
+
+	body := strings.Join([]string{
+		"From: " + m.cfg.From,
+		"To: " + message.To,
+		"Subject: " + message.Subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		message.Body,
+	}, "\r\n")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, m.cfg.From, []string{message.To}, []byte(body))
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		if nil != err {
+			return fmt.Errorf("unable to send email through smtp server: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"fmt"
+	"time"
+)
+
+func EmailChangeConfirmation(to, token string, expiresAt time.Time) Message {
+	return Message{
+		To:      to,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"A request was made to use this address for your Game Sales Analytics account.\n\n"+
+				"Use the following confirmation token to complete the change:\n\n%s\n\n"+
+				"The token expires at %s. If you did not request this change, ignore this email.\n",
+			token,
+			expiresAt.UTC().Format(time.RFC1123),
+		),
+	}
+}
+
+func EmailChangedNotice(to, newEmail string) Message {
+	return Message{
+		To:      to,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf(
+			"The email address of your Game Sales Analytics account was changed to %s.\n\n"+
+				"If you did not make this change, contact support immediately.\n",
+			newEmail,
+		),
+	}
+}
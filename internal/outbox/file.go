@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// filePublisher appends events to a file as JSON lines.
+type filePublisher struct {
+	mu   *sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(path string) (EventPublisher, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if nil != err {
+		return nil, fmt.Errorf("unable to open outbox events file: %w", err)
+	}
+
+	return filePublisher{
+		&sync.Mutex{},
+		file,
+	}, nil
+}
+
+func (p filePublisher) Publish(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if nil != err {
+		return err
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(line); nil != err {
+		return fmt.Errorf("unable to write event to outbox events file: %w", err)
+	}
+
+	// the event is marked delivered right after, so it has to be on disk
+	return p.file.Sync()
+}
+
+func (p filePublisher) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,33 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type logPublisher struct {
+	logger *logrus.Entry
+}
+
+func NewLogPublisher(logger *logrus.Entry) EventPublisher {
+	return logPublisher{
+		logger,
+	}
+}
+
+func (p logPublisher) Publish(ctx context.Context, event Event) error {
+	p.logger.
+		WithField("id", event.ID).
+		WithField("type", event.Type).
+		WithField("subject_id", event.SubjectID).
+		WithField("occurred_at", event.OccurredAt).
+		WithField("payload", event.Payload).
+		Info("publishing event")
+
+	return nil
+}
+
+func (p logPublisher) Close() error {
+	return nil
+}
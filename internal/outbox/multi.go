@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"context"
+)
+
+type multiPublisher struct {
+	publishers []EventPublisher
+}
+
+// NewMultiPublisher hands every event to each publisher in turn. An event
+// that fails on one of them is retried on all of them, which the at least once
+// delivery already allows for.
+func NewMultiPublisher(publishers ...EventPublisher) EventPublisher {
+	return multiPublisher{
+		publishers,
+	}
+}
+
+func (p multiPublisher) Publish(ctx context.Context, event Event) error {
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, event); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p multiPublisher) Close() error {
+	var firstErr error
+	for _, publisher := range p.publishers {
+		if err := publisher.Close(); nil != err && nil == firstErr {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsPublisher publishes every event on '<prefix>.<event type>', e.g.
+// 'users.user.registered'. The event ID is sent as the Nats-Msg-Id header so
+// JetStream streams drop events the relay publishes twice.
+type natsPublisher struct {
+	conn          *nats.Conn
+	subjectPrefix string
+}
+
+func NewNATSPublisher(url, subjectPrefix string) (EventPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("users-service outbox relay"), nats.MaxReconnects(-1))
+	if nil != err {
+		return nil, fmt.Errorf("unable to connect to nats server: %w", err)
+	}
+
+	return natsPublisher{
+		conn,
+		subjectPrefix,
+	}, nil
+}
+
+func (p natsPublisher) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if nil != err {
+		return err
+	}
+
+	msg := nats.NewMsg(p.subjectPrefix + "." + event.Type)
+	msg.Header.Set(nats.MsgIdHdr, event.ID)
+	msg.Data = data
+	if err := p.conn.PublishMsg(msg); nil != err {
+		return fmt.Errorf("unable to publish event to nats: %w", err)
+	}
+
+	// Core NATS publishing is fire and forget. Flushing waits for the
+	// server to acknowledge everything sent so far.
+	if err := p.conn.FlushWithContext(ctx); nil != err {
+		return fmt.Errorf("unable to flush published event to nats: %w", err)
+	}
+
+	return nil
+}
+
+func (p natsPublisher) Close() error {
+	return p.conn.Drain()
+}
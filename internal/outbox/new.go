@@ -0,0 +1,40 @@
+package outbox
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+func NewPublisher(logger *logrus.Entry, cfg *config.OutboxConfig) (EventPublisher, error) {
+	switch cfg.Publisher {
+	case config.OutboxPublisherFile:
+		return NewFilePublisher(cfg.FilePath)
+	case config.OutboxPublisherNATS:
+		return NewNATSPublisher(cfg.NATSURL, cfg.NATSSubjectPrefix)
+	default:
+		return NewLogPublisher(logger), nil
+	}
+}
+
+type relay struct {
+	logger    *logrus.Entry
+	repo      repository.Store
+	publisher EventPublisher
+	cfg       *config.OutboxConfig
+}
+
+func New(
+	logger *logrus.Entry,
+	repo repository.Store,
+	publisher EventPublisher,
+	cfg *config.OutboxConfig,
+) Relay {
+	return relay{
+		logger,
+		repo,
+		publisher,
+		cfg,
+	}
+}
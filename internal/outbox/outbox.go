@@ -0,0 +1,28 @@
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+// Event is the message published for every outbox event. Consumers may
+// receive an event more than once and should deduplicate on ID.
+type Event struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	SubjectID  string            `json:"subject_id"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Payload    map[string]string `json:"payload"`
+}
+
+type EventPublisher interface {
+	// Publish returns once the event is handed to the destination. A nil
+	// error marks the event delivered.
+	Publish(ctx context.Context, event Event) error
+	Close() error
+}
+
+// Relay publishes pending outbox events until its context is canceled.
+type Relay interface {
+	Run(ctx context.Context)
+}
@@ -0,0 +1,110 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+const publishTimeout = time.Second * 10
+
+func (r relay) Run(ctx context.Context) {
+	r.logger.WithField("interval", r.cfg.RelayInterval).WithField("publisher", r.cfg.Publisher).Debug("starting outbox relay")
+
+	ticker := time.NewTicker(r.cfg.RelayInterval)
+	defer ticker.Stop()
+
+	for {
+		r.relayPendingEvents(ctx)
+
+		select {
+		case <-ctx.Done():
+			r.logger.Debug("stopping outbox relay")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayPendingEvents publishes pending events oldest first. It stops at the
+// first event that fails to publish, so later events of the same user are
+// never delivered before earlier ones; the failed event is retried on the
+// next run. An event that is published but not marked delivered is published
+// again, so delivery is at least once.
+func (r relay) relayPendingEvents(ctx context.Context) {
+	span := sentry.StartSpan(ctx, "relay-outbox-events", sentry.TransactionName("relay-outbox-events"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	batchSize := int64(r.cfg.BatchSize)
+	published := 0
+	for {
+		child := span.StartChild("list-pending-outbox-events")
+		child.Status = sentry.SpanStatusOK
+		events, err := r.repo.ListPendingOutboxEvents(repository.NewDBOperationContext(ctx, child), batchSize)
+		if nil != err {
+			defer child.Finish()
+
+			child.Status = sentry.SpanStatusInternalError
+			log := r.logger.WithError(err).WithField("err_code", "E_LIST_PENDING_OUTBOX_EVENTS")
+			apm.SetSpanTagsFromLogEntry(child, log)
+			log.Error("failed listing pending outbox events")
+			return
+		}
+		child.Finish()
+
+		for _, event := range events {
+			child = span.StartChild("publish-outbox-event")
+			child.Status = sentry.SpanStatusOK
+			if err := r.publish(child.Context(), event); nil != err {
+				defer child.Finish()
+
+				child.Status = sentry.SpanStatusUnavailable
+				log := r.logger.WithError(err).WithField("err_code", "E_PUBLISH_OUTBOX_EVENT").WithField("event_id", event.ID).WithField("attempts", event.Attempts+1)
+				apm.SetSpanTagsFromLogEntry(child, log)
+				log.Error("failed publishing outbox event. will retry on next run")
+
+				if err := r.repo.MarkOutboxEventFailed(repository.NewDBOperationContext(ctx, child), event.ID, err.Error()); nil != err {
+					r.logger.WithError(err).WithField("err_code", "E_MARK_OUTBOX_EVENT_FAILED").WithField("event_id", event.ID).Error("failed recording outbox event publishing failure")
+				}
+				return
+			}
+			if err := r.repo.MarkOutboxEventDelivered(repository.NewDBOperationContext(ctx, child), event.ID, time.Now()); nil != err {
+				defer child.Finish()
+
+				child.Status = sentry.SpanStatusInternalError
+				log := r.logger.WithError(err).WithField("err_code", "E_MARK_OUTBOX_EVENT_DELIVERED").WithField("event_id", event.ID)
+				apm.SetSpanTagsFromLogEntry(child, log)
+				log.Error("failed marking published outbox event as delivered. it will be published again")
+				return
+			}
+			child.Finish()
+			published++
+		}
+
+		if int64(len(events)) < batchSize {
+			break
+		}
+	}
+
+	if published > 0 {
+		r.logger.WithField("count", published).Debug("published outbox events")
+	}
+}
+
+func (r relay) publish(ctx context.Context, event repository.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	return r.publisher.Publish(ctx, Event{
+		ID:         event.ID,
+		Type:       event.Type,
+		SubjectID:  event.SubjectID,
+		OccurredAt: event.OccurredAt,
+		Payload:    event.Payload,
+	})
+}
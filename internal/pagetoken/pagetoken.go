@@ -0,0 +1,39 @@
+package pagetoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalid = errors.New("invalid page token")
+
+// Encode builds an opaque token for resuming a listing after the record with
+// the given time and id.
+func Encode(at time.Time, id string) string {
+	raw := fmt.Sprintf("%d:%s", at.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode returns the time and id an Encode token was built from.
+func Decode(token string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if nil != err {
+		return time.Time{}, "", ErrInvalid
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || len(parts[1]) == 0 {
+		return time.Time{}, "", ErrInvalid
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if nil != err {
+		return time.Time{}, "", ErrInvalid
+	}
+
+	return time.Unix(0, nanos).UTC(), parts[1], nil
+}
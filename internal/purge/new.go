@@ -0,0 +1,30 @@
+package purge
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type purger struct {
+	logger *logrus.Entry
+	repo   repository.Store
+	audit  audit.Trail
+	cfg    *config.AccountDeletionConfig
+}
+
+func New(
+	logger *logrus.Entry,
+	repo repository.Store,
+	audit audit.Trail,
+	cfg *config.AccountDeletionConfig,
+) Purger {
+	return purger{
+		logger,
+		repo,
+		audit,
+		cfg,
+	}
+}
@@ -0,0 +1,10 @@
+package purge
+
+import (
+	"context"
+)
+
+// Purger permanently removes accounts whose deletion grace period is over.
+type Purger interface {
+	Run(ctx context.Context)
+}
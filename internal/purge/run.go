@@ -0,0 +1,114 @@
+package purge
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/audit"
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+const batchSize = 100
+
+func (p purger) Run(ctx context.Context) {
+	p.logger.WithField("interval", p.cfg.PurgeInterval).WithField("mode", p.cfg.PurgeMode).Debug("starting deleted accounts purger")
+
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.purgeDueAccounts(ctx)
+
+		select {
+		case <-ctx.Done():
+			p.logger.Debug("stopping deleted accounts purger")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p purger) purgeDueAccounts(ctx context.Context) {
+	span := sentry.StartSpan(ctx, "purge-deleted-accounts", sentry.TransactionName("purge-deleted-accounts"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	now := time.Now()
+	purged := 0
+	for {
+		child := span.StartChild("list-accounts-due-for-purge")
+		child.Status = sentry.SpanStatusOK
+		userIDs, err := p.repo.ListUsersDueForPurge(repository.NewDBOperationContext(ctx, child), now, batchSize)
+		if nil != err {
+			defer child.Finish()
+
+			child.Status = sentry.SpanStatusInternalError
+			log := p.logger.WithError(err).WithField("err_code", "E_LIST_USERS_DUE_FOR_PURGE")
+			apm.SetSpanTagsFromLogEntry(child, log)
+			log.Error("failed listing accounts due for purge")
+			return
+		}
+		child.Finish()
+
+		for _, userID := range userIDs {
+			child = span.StartChild("purge-account")
+			child.Status = sentry.SpanStatusOK
+			if err := p.purgeAccount(ctx, child, userID, now); nil != err {
+				defer child.Finish()
+
+				child.Status = sentry.SpanStatusInternalError
+				log := p.logger.WithError(err).WithField("err_code", "E_PURGE_ACCOUNT").WithField("user_id", userID)
+				apm.SetSpanTagsFromLogEntry(child, log)
+				log.Error("failed purging account. will retry on next run")
+				return
+			}
+			child.Finish()
+			purged++
+		}
+
+		if len(userIDs) < batchSize {
+			break
+		}
+	}
+
+	if purged > 0 {
+		p.logger.WithField("count", purged).Info("purged deleted accounts")
+	}
+}
+
+// purgeAccount removes the user along with their login history. The store
+// removes the logins only once the user is confirmed to still be due, so an
+// account recovered by logging in meanwhile keeps its history.
+func (p purger) purgeAccount(ctx context.Context, span *sentry.Span, userID string, dueBefore time.Time) error {
+	var purged *repository.PurgedUser
+	var err error
+	if p.cfg.PurgeMode == config.AccountPurgeModeAnonymize {
+		purged, err = p.repo.AnonymizeUser(repository.NewDBOperationContext(ctx, span), userID, dueBefore, time.Now())
+	} else {
+		purged, err = p.repo.DeleteUser(repository.NewDBOperationContext(ctx, span), userID, dueBefore)
+	}
+	if nil != err {
+		return err
+	}
+	if nil == purged {
+		// the user logged back in between listing and purging
+		return nil
+	}
+
+	p.audit.Record(audit.NewContext(ctx, span), audit.Event{
+		Type:      audit.EventTypeUserPurged,
+		Outcome:   audit.OutcomeSuccess,
+		SubjectID: userID,
+		Details: map[string]string{
+			"mode":           p.cfg.PurgeMode,
+			"deleted_logins": strconv.FormatInt(purged.DeletedLogins, 10),
+		},
+	})
+
+	return nil
+}
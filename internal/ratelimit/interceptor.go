@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"path"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+)
+
+type ipRequest interface {
+	GetIp() string
+}
+
+func UnaryServerInterceptor(logger *logrus.Entry, store Store, cfg *config.RateLimitConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		method := path.Base(info.FullMethod)
+		limit, limited := methodLimit(cfg, method)
+		if !limited {
+			return handler(ctx, req)
+		}
+
+		clientKey := readClientKey(ctx, req, cfg)
+		result, err := store.Take(ctx, method+"|"+clientKey, limit)
+		if nil != err {
+			logger.WithError(err).WithField("err_code", "E_TAKE_RATE_LIMIT_TOKEN").WithField("method", method).Error("failed taking rate limit token. allowing request")
+			return handler(ctx, req)
+		}
+
+		if !result.Allowed {
+			logger.WithField("method", method).WithField("client", clientKey).Debug("rate limit exceeded")
+			return nil, resourceExhaustedError(ctx, logger, result)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func methodLimit(cfg *config.RateLimitConfig, method string) (Limit, bool) {
+	rule, exists := cfg.Methods[method]
+	if !exists {
+		rule = cfg.Default
+	}
+	if rule.Requests == 0 {
+		return Limit{}, false
+	}
+
+	return Limit{
+		Rate:  float64(rule.Requests) / rule.Period.Seconds(),
+		Burst: rule.Burst,
+	}, true
+}
+
+func readClientKey(ctx context.Context, req interface{}, cfg *config.RateLimitConfig) string {
+	switch cfg.KeySource {
+	case config.RateLimitKeySourceIP:
+		if r, ok := req.(ipRequest); ok && len(r.GetIp()) != 0 {
+			return r.GetIp()
+		}
+	case config.RateLimitKeySourceHeader:
+		if meta, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := meta.Get(cfg.KeyHeader); len(values) > 0 && len(values[0]) != 0 {
+				return values[0]
+			}
+		}
+	}
+
+	return readPeerIP(ctx)
+}
+
+func readPeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || nil == p.Addr {
+		return "unknown"
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if nil != err {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+func resourceExhaustedError(ctx context.Context, logger *logrus.Entry, result *Result) error {
+	retryAfterSeconds := int64(math.Ceil(result.RetryAfter.Seconds()))
+	if err := grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(retryAfterSeconds, 10))); nil != err {
+		logger.WithError(err).WithField("err_code", "E_SET_RETRY_AFTER_HEADER").Debug("failed setting retry-after response header")
+	}
+
+	st := status.New(codes.ResourceExhausted, "too many requests. try again later.")
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(result.RetryAfter),
+	})
+	if nil != err {
+		logger.WithError(err).WithField("err_code", "E_ATTACH_RETRY_INFO").Error("failed attaching retry info to rate limit error")
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
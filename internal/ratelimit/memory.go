@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const memoryStoreSweepInterval = time.Minute
+
+type bucket struct {
+	tokens   float64
+	limit    Limit
+	lastSeen time.Time
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, limit Limit) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, exists := s.buckets[key]
+	if !exists || b.limit != limit {
+		b = &bucket{
+			tokens:   float64(limit.Burst),
+			limit:    limit,
+			lastSeen: now,
+		}
+		s.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return &Result{
+			Allowed:   true,
+			Remaining: uint(b.tokens),
+		}, nil
+	}
+
+	retryAfter := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return &Result{
+		Allowed:    false,
+		Remaining:  0,
+		RetryAfter: retryAfter,
+	}, nil
+}
+
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memoryStoreSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		refill := now.Sub(b.lastSeen).Seconds() * b.limit.Rate
+		if b.tokens+refill >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+type Limit struct {
+	Rate  float64
+	Burst uint
+}
+
+type Result struct {
+	Allowed    bool
+	Remaining  uint
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (*Result, error)
+}
@@ -0,0 +1,22 @@
+package rbac
+
+import (
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+// BootstrapAdmin grants the admin role to the active user with the given ID
+// and reports whether it did. It only runs at startup and grants at most
+// once: the store records the bootstrap together with the grant, and nothing
+// is granted either once any user holds the admin role. Otherwise losing
+// every admin, e.g. by purging the bootstrapped account, would hand the role
+// out again. The account is named by ID rather than email, since anyone can
+// register an email nobody owns yet.
+func BootstrapAdmin(ctx repository.DBOperationContext, repo repository.Store, userID string) (bool, error) {
+	if len(userID) == 0 {
+		return false, nil
+	}
+
+	return repo.BootstrapRole(ctx, userID, RoleAdmin, time.Now())
+}
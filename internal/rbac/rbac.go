@@ -0,0 +1,100 @@
+package rbac
+
+import (
+	"sort"
+)
+
+type Role = string
+
+const (
+	RoleAdmin   Role = "admin"
+	RoleSupport Role = "support"
+)
+
+type Permission = string
+
+const (
+	PermissionUsersRead       Permission = "users:read"
+	PermissionUsersList       Permission = "users:list"
+	PermissionUsersModerate   Permission = "users:moderate"
+	PermissionUsersExport     Permission = "users:export"
+	PermissionRolesManage     Permission = "roles:manage"
+	PermissionAuditEventsRead Permission = "audit_events:read"
+	PermissionAPIKeysManage   Permission = "api_keys:manage"
+	PermissionWebhooksManage  Permission = "webhooks:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleSupport: {
+		PermissionUsersRead,
+		PermissionUsersList,
+	},
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionUsersList,
+		PermissionUsersModerate,
+		PermissionUsersExport,
+		PermissionRolesManage,
+		PermissionAuditEventsRead,
+		PermissionAPIKeysManage,
+		PermissionWebhooksManage,
+	},
+}
+
+func IsRoleValid(role string) bool {
+	_, exists := rolePermissions[role]
+	return exists
+}
+
+func IsPermissionValid(permission string) bool {
+	for _, permissions := range rolePermissions {
+		for _, known := range permissions {
+			if known == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Permissions returns the sorted union of the permissions granted by the
+// given roles. Unknown roles grant nothing.
+func Permissions(roles []Role) []Permission {
+	set := map[Permission]struct{}{}
+	for _, role := range roles {
+		for _, permission := range rolePermissions[role] {
+			set[permission] = struct{}{}
+		}
+	}
+
+	out := make([]Permission, 0, len(set))
+	for permission := range set {
+		out = append(out, permission)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+func HasRole(roles []Role, role Role) bool {
+	for _, held := range roles {
+		if held == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func HasPermission(roles []Role, permission Permission) bool {
+	for _, role := range roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package useragent
+
+import (
+	"strings"
+
+	"github.com/mssola/user_agent"
+)
+
+type DeviceClass = string
+
+const (
+	DeviceClassDesktop DeviceClass = "desktop"
+	DeviceClassMobile  DeviceClass = "mobile"
+	DeviceClassTablet  DeviceClass = "tablet"
+	DeviceClassBot     DeviceClass = "bot"
+	DeviceClassUnknown DeviceClass = "unknown"
+)
+
+type Device struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	Class          DeviceClass
+}
+
+func Parse(raw string) Device {
+	ua := user_agent.New(raw)
+	browser, version := ua.Browser()
+
+	return Device{
+		Browser:        browser,
+		BrowserVersion: version,
+		OS:             ua.OS(),
+		Class:          deviceClass(ua, raw),
+	}
+}
+
+func deviceClass(ua *user_agent.UserAgent, raw string) DeviceClass {
+	lowered := strings.ToLower(raw)
+
+	switch {
+	case ua.Bot():
+		return DeviceClassBot
+	case strings.Contains(lowered, "ipad") || strings.Contains(lowered, "tablet"):
+		return DeviceClassTablet
+	case strings.Contains(lowered, "android") && !strings.Contains(lowered, "mobile"):
+		return DeviceClassTablet
+	case ua.Mobile():
+		return DeviceClassMobile
+	case len(ua.OS()) != 0:
+		return DeviceClassDesktop
+	default:
+		return DeviceClassUnknown
+	}
+}
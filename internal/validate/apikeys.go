@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+)
+
+const maxAPIKeyNameLength = 100
+
+type CreateAPIKeyForm struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type RevokeAPIKeyForm struct {
+	ID string
+}
+
+func (v validator) ValidateCreateAPIKeyForm(ctx Context, form CreateAPIKeyForm) error {
+	span := ctx.span.StartChild("validate-name")
+	span.Status = sentry.SpanStatusOK
+	if len(form.Name) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "name", Message: "cannot be empty"}
+	}
+	if len(form.Name) > maxAPIKeyNameLength {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "name", Message: "must not be longer than 100 characters"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-scopes")
+	span.Status = sentry.SpanStatusOK
+	if len(form.Scopes) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "scopes", Message: "cannot be empty"}
+	}
+	seen := map[string]struct{}{}
+	for _, scope := range form.Scopes {
+		if !rbac.IsPermissionValid(scope) {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInvalidArgument
+			return &ValidationError{Field: "scopes", Message: "unknown scope"}
+		}
+		if _, duplicate := seen[scope]; duplicate {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInvalidArgument
+			return &ValidationError{Field: "scopes", Message: "must not contain duplicates"}
+		}
+		seen[scope] = struct{}{}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-expires-at")
+	span.Status = sentry.SpanStatusOK
+	if nil != form.ExpiresAt {
+		now := time.Now()
+		if !form.ExpiresAt.After(now) {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInvalidArgument
+			return &ValidationError{Field: "expires_at", Message: "must be in the future"}
+		}
+		if form.ExpiresAt.After(now.Add(v.apiKeysCfg.MaxTTL)) {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInvalidArgument
+			return &ValidationError{Field: "expires_at", Message: "exceeds the maximum api key lifetime"}
+		}
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (v validator) ValidateRevokeAPIKeyForm(ctx Context, form RevokeAPIKeyForm) error {
+	span := ctx.span.StartChild("validate-id")
+	span.Status = sentry.SpanStatusOK
+	if len(form.ID) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "id", Message: "cannot be empty"}
+	}
+	span.Finish()
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package validate
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const MaxPageSize = 500
+
+type QueryAuditEventsForm struct {
+	Outcome  string
+	From     *time.Time
+	To       *time.Time
+	PageSize uint32
+}
+
+func (v validator) ValidateQueryAuditEventsForm(ctx Context, form QueryAuditEventsForm) error {
+	span := ctx.span.StartChild("validate-outcome")
+	span.Status = sentry.SpanStatusOK
+	if len(form.Outcome) != 0 && form.Outcome != "success" && form.Outcome != "failure" {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "outcome", Message: "must be either 'success' or 'failure'"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-time-range")
+	span.Status = sentry.SpanStatusOK
+	if nil != form.From && nil != form.To && !form.From.Before(*form.To) {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "to", Message: "must be after 'from'"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-page-size")
+	span.Status = sentry.SpanStatusOK
+	if form.PageSize > MaxPageSize {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "page_size", Message: "must not be greater than 500"}
+	}
+	span.Finish()
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package validate
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+)
+
+var (
+	ErrChallengeFailed = errors.New("challenge verification failed")
+)
+
+type Challenge struct {
+	Response string
+	Resource string
+	RemoteIP string
+}
+
+type ChallengeVerifier interface {
+	VerifyChallenge(ctx Context, challenge Challenge) error
+}
+
+func NewChallengeVerifier(cfg *config.ChallengeConfig) ChallengeVerifier {
+	switch cfg.Provider {
+	case config.ChallengeProviderHashcash:
+		return NewHashcashVerifier(cfg.HashcashDifficulty, cfg.HashcashMaxAge)
+	case config.ChallengeProviderHCaptcha:
+		return NewHCaptchaVerifier(&http.Client{Timeout: time.Second * 5}, cfg.VerifyURL, cfg.Secret)
+	case config.ChallengeProviderTurnstile:
+		return NewTurnstileVerifier(&http.Client{Timeout: time.Second * 5}, cfg.VerifyURL, cfg.Secret)
+	default:
+		return nil
+	}
+}
+
+func (v validator) validateChallenge(ctx Context, challenge Challenge) error {
+	if nil == v.challengeVerifier {
+		return nil
+	}
+
+	if len(challenge.Response) == 0 {
+		return &ValidationError{Field: "challenge", Message: "cannot be empty"}
+	}
+
+	if err := v.challengeVerifier.VerifyChallenge(ctx, challenge); nil != err {
+		if errors.Is(err, ErrChallengeFailed) {
+			return &ValidationError{Field: "challenge", Message: "verification failed"}
+		}
+
+		return err
+	}
+
+	return nil
+}
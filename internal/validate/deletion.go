@@ -0,0 +1,28 @@
+package validate
+
+import (
+	"github.com/getsentry/sentry-go"
+)
+
+type DeleteAccountForm struct {
+	Token    string
+	Password string
+}
+
+func (v validator) ValidateDeleteAccountForm(ctx Context, form DeleteAccountForm) error {
+	if err := v.ValidateAuthenticateForm(ctx, AuthenticateForm{Token: form.Token}); nil != err {
+		return err
+	}
+
+	span := ctx.span.StartChild("validate-password")
+	span.Status = sentry.SpanStatusOK
+	if len(form.Password) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "password", Message: "cannot be empty"}
+	}
+	span.Finish()
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package validate
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"net/mail"
+	"os"
+	"strings"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+)
+
+//go:embed disposable_domains.txt
+var bundledDisposableDomains string
+
+type domainSet struct {
+	exact     map[string]struct{}
+	wildcards []string
+}
+
+func newDomainSet(patterns []string) domainSet {
+	set := domainSet{
+		exact:     make(map[string]struct{}),
+		wildcards: []string{},
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if strings.HasPrefix(pattern, "*.") {
+			set.wildcards = append(set.wildcards, pattern[1:])
+		} else if len(pattern) != 0 {
+			set.exact[pattern] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+func (s domainSet) matches(domain string) bool {
+	if _, exists := s.exact[domain]; exists {
+		return true
+	}
+	for _, suffix := range s.wildcards {
+		if strings.HasSuffix(domain, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type DomainPolicy struct {
+	rejectDisposable bool
+	disposable       map[string]struct{}
+	allowed          domainSet
+	denied           domainSet
+}
+
+func NewDomainPolicy(cfg *config.EmailDomainPolicyConfig) (*DomainPolicy, error) {
+	var list io.Reader = strings.NewReader(bundledDisposableDomains)
+	if len(cfg.DisposableDomainsFile) != 0 {
+		file, err := os.Open(cfg.DisposableDomainsFile)
+		if nil != err {
+			return nil, err
+		}
+		defer file.Close()
+
+		list = file
+	}
+
+	disposable, err := readDomainList(list)
+	if nil != err {
+		return nil, err
+	}
+
+	return &DomainPolicy{
+		rejectDisposable: cfg.RejectDisposable,
+		disposable:       disposable,
+		allowed:          newDomainSet(cfg.AllowedDomains),
+		denied:           newDomainSet(cfg.DeniedDomains),
+	}, nil
+}
+
+func readDomainList(list io.Reader) (map[string]struct{}, error) {
+	out := make(map[string]struct{})
+	scanner := bufio.NewScanner(list)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out[line] = struct{}{}
+	}
+
+	return out, scanner.Err()
+}
+
+func (p *DomainPolicy) isDisposable(domain string) bool {
+	for candidate := domain; len(candidate) != 0; {
+		if _, exists := p.disposable[candidate]; exists {
+			return true
+		}
+
+		dot := strings.IndexByte(candidate, '.')
+		if dot < 0 {
+			break
+		}
+		candidate = candidate[dot+1:]
+	}
+
+	return false
+}
+
+func (p *DomainPolicy) Check(email string) *ValidationError {
+	address, err := mail.ParseAddress(email)
+	if nil != err {
+		return &ValidationError{Field: "email", Message: "invalid email"}
+	}
+	domain := strings.ToLower(address.Address[strings.LastIndexByte(address.Address, '@')+1:])
+
+	if p.denied.matches(domain) {
+		return &ValidationError{Field: "email", Message: "email domain is blocked"}
+	}
+
+	if p.allowed.matches(domain) {
+		return nil
+	}
+
+	if p.rejectDisposable && p.isDisposable(domain) {
+		return &ValidationError{Field: "email", Message: "disposable email addresses are not allowed"}
+	}
+
+	return nil
+}
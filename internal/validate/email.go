@@ -0,0 +1,56 @@
+package validate
+
+import (
+	"errors"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/normalize"
+)
+
+// validateAvailableEmail checks that the email is valid and neither used nor
+// reserved by a pending email change, except one of the user with the given
+// ID.
+func (v validator) validateAvailableEmail(ctx Context, field, email, reservedByUserID string) (string, error) {
+	if len(email) == 0 {
+		ctx.span.Status = sentry.SpanStatusInvalidArgument
+		return "", &ValidationError{Field: field, Message: "cannot be empty"}
+	}
+	if isValid, err := isEmailValid(email); nil != err {
+		ctx.span.Status = sentry.SpanStatusInternalError
+		log := v.logger.WithError(err).WithField("err_code", "E_VALIDATE_EMAIL_FORMAT")
+		apm.SetSpanTagsFromLogEntry(ctx.span, log)
+		log.Error("failed validating email format")
+		return "", errors.New("failed to validate email field")
+	} else if !isValid {
+		ctx.span.Status = sentry.SpanStatusInvalidArgument
+		return "", &ValidationError{Field: field, Message: "invalid email"}
+	}
+	if validationErr := v.domainPolicy.Check(email); nil != validationErr {
+		ctx.span.Status = sentry.SpanStatusInvalidArgument
+		validationErr.Field = field
+		return "", validationErr
+	}
+	normalizedEmail, err := normalize.Email(email)
+	if nil != err {
+		ctx.span.Status = sentry.SpanStatusInternalError
+		log := v.logger.WithError(err).WithField("err_code", "E_NORMALIZE_EMAIL")
+		apm.SetSpanTagsFromLogEntry(ctx.span, log)
+		log.Error("failed normalizing email address")
+		return "", errors.New("failed normalizing email address")
+	}
+	if exists, err := v.repo.NormalizedEmailExists(repository.NewDBOperationContext(ctx, ctx.span), normalizedEmail, reservedByUserID); nil != err {
+		ctx.span.Status = sentry.SpanStatusInternalError
+		log := v.logger.WithError(err).WithField("err_code", "E_CHECK_NORMALIZED_EMAIL_EXISTENCE")
+		apm.SetSpanTagsFromLogEntry(ctx.span, log)
+		log.Error("failed checking normalized email existence")
+		return "", err
+	} else if exists {
+		ctx.span.Status = sentry.SpanStatusInvalidArgument
+		return "", &ValidationError{Field: field, Message: "duplicate email address"}
+	}
+
+	return normalizedEmail, nil
+}
@@ -0,0 +1,47 @@
+package validate
+
+import (
+	"github.com/getsentry/sentry-go"
+)
+
+// RequestEmailChangeForm is validated once the request token is verified.
+// UserID is the verified requester, whose own pending reservation of the new
+// email does not count as taken, so a lost confirmation can be re-requested.
+type RequestEmailChangeForm struct {
+	UserID   string
+	NewEmail string
+}
+
+type ConfirmEmailChangeForm struct {
+	ConfirmationToken string
+}
+
+func (v validator) ValidateRequestEmailChangeForm(ctx Context, form RequestEmailChangeForm) (*NormalizedForm, error) {
+	span := ctx.span.StartChild("validate-new-email")
+	span.Status = sentry.SpanStatusOK
+	normalizedEmail, err := v.validateAvailableEmail(NewContext(ctx, span), "new_email", form.NewEmail, form.UserID)
+	if nil != err {
+		defer span.Finish()
+
+		return nil, err
+	}
+	span.Finish()
+
+	return &NormalizedForm{
+		Email: normalizedEmail,
+	}, nil
+}
+
+func (v validator) ValidateConfirmEmailChangeForm(ctx Context, form ConfirmEmailChangeForm) error {
+	span := ctx.span.StartChild("validate-confirmation-token")
+	span.Status = sentry.SpanStatusOK
+	if len(form.ConfirmationToken) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "confirmation_token", Message: "cannot be empty"}
+	}
+	span.Finish()
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package validate
+
+import (
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/export"
+)
+
+type ExportUserDataForm struct {
+	Token  string
+	Format string
+}
+
+type AdminExportUserDataForm struct {
+	ID     string
+	Format string
+}
+
+func validateExportFormat(ctx Context, format string) error {
+	span := ctx.span.StartChild("validate-format")
+	span.Status = sentry.SpanStatusOK
+	if len(format) != 0 && format != export.FormatJSON && format != export.FormatZip {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "format", Message: "must be either 'json' or 'zip'"}
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (v validator) ValidateExportUserDataForm(ctx Context, form ExportUserDataForm) error {
+	if err := v.ValidateAuthenticateForm(ctx, AuthenticateForm{Token: form.Token}); nil != err {
+		return err
+	}
+
+	return validateExportFormat(ctx, form.Format)
+}
+
+func (v validator) ValidateAdminExportUserDataForm(ctx Context, form AdminExportUserDataForm) error {
+	if err := v.ValidateGetUserForm(ctx, GetUserForm{ID: form.ID}); nil != err {
+		return err
+	}
+
+	return validateExportFormat(ctx, form.Format)
+}
@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"crypto/sha1"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type hashcashVerifier struct {
+	difficulty uint
+	maxAge     time.Duration
+	mu         *sync.Mutex
+	spent      map[string]time.Time
+}
+
+// NewHashcashVerifier verifies version 1 hashcash stamps, i.e.
+// '1:BITS:DATE:RESOURCE:EXT:RAND:COUNTER', minted for the submitted email
+// address. Each stamp is accepted only once within its max age.
+func NewHashcashVerifier(difficulty uint, maxAge time.Duration) ChallengeVerifier {
+	return hashcashVerifier{
+		difficulty: difficulty,
+		maxAge:     maxAge,
+		mu:         &sync.Mutex{},
+		spent:      make(map[string]time.Time),
+	}
+}
+
+func (v hashcashVerifier) VerifyChallenge(ctx Context, challenge Challenge) error {
+	parts := strings.Split(challenge.Response, ":")
+	if len(parts) != 7 || parts[0] != "1" {
+		return ErrChallengeFailed
+	}
+
+	bits, err := strconv.ParseUint(parts[1], 10, 8)
+	if nil != err || uint(bits) < v.difficulty {
+		return ErrChallengeFailed
+	}
+
+	mintedAt, err := parseHashcashDate(parts[2])
+	if nil != err {
+		return ErrChallengeFailed
+	}
+	now := time.Now()
+	if now.Sub(mintedAt) > v.maxAge || mintedAt.Sub(now) > time.Minute*5 {
+		return ErrChallengeFailed
+	}
+
+	if !strings.EqualFold(parts[3], challenge.Resource) {
+		return ErrChallengeFailed
+	}
+
+	digest := sha1.Sum([]byte(challenge.Response))
+	if leadingZeroBits(digest[:]) < v.difficulty {
+		return ErrChallengeFailed
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for stamp, expiresAt := range v.spent {
+		if now.After(expiresAt) {
+			delete(v.spent, stamp)
+		}
+	}
+	if _, spent := v.spent[challenge.Response]; spent {
+		return ErrChallengeFailed
+	}
+	v.spent[challenge.Response] = mintedAt.Add(v.maxAge)
+
+	return nil
+}
+
+func parseHashcashDate(raw string) (time.Time, error) {
+	switch len(raw) {
+	case 6:
+		return time.Parse("060102", raw)
+	case 10:
+		return time.Parse("0601021504", raw)
+	default:
+		return time.Parse("060102150405", raw)
+	}
+}
+
+func leadingZeroBits(digest []byte) uint {
+	var out uint
+	for _, b := range digest {
+		if b == 0 {
+			out += 8
+			continue
+		}
+		for mask := byte(0x80); mask != 0 && b&mask == 0; mask >>= 1 {
+			out++
+		}
+		break
+	}
+
+	return out
+}
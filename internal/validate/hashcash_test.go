@@ -0,0 +1,97 @@
+package validate
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+const testHashcashDifficulty = 8
+
+// mintHashcash searches for a stamp of the given resource and time with the
+// test difficulty, like a client would.
+func mintHashcash(t *testing.T, bits uint, resource string, at time.Time) string {
+	t.Helper()
+
+	for counter := 0; counter < 1<<24; counter++ {
+		stamp := fmt.Sprintf("1:%d:%s:%s::%s:%x", bits, at.UTC().Format("060102150405"), resource, "c2FsdA", counter)
+		digest := sha1.Sum([]byte(stamp))
+		if leadingZeroBits(digest[:]) >= bits {
+			return stamp
+		}
+	}
+
+	t.Fatal("unable to mint hashcash stamp")
+	return ""
+}
+
+func TestHashcashVerifierAcceptsStampOnce(t *testing.T) {
+	verifier := NewHashcashVerifier(testHashcashDifficulty, time.Hour)
+	stamp := mintHashcash(t, testHashcashDifficulty, "jane@example.com", time.Now())
+	challenge := Challenge{Response: stamp, Resource: "Jane@Example.com"}
+
+	if err := verifier.VerifyChallenge(newTestContext(t), challenge); nil != err {
+		t.Fatalf("expected fresh stamp to pass, got %s", err)
+	}
+	if err := verifier.VerifyChallenge(newTestContext(t), challenge); !errors.Is(err, ErrChallengeFailed) {
+		t.Fatalf("expected spent stamp to fail, got %v", err)
+	}
+}
+
+func TestHashcashVerifierRejectsInvalidStamps(t *testing.T) {
+	now := time.Now()
+	tests := map[string]Challenge{
+		"malformed":      {Response: "not-a-stamp", Resource: "jane@example.com"},
+		"other resource": {Response: mintHashcash(t, testHashcashDifficulty, "john@example.com", now), Resource: "jane@example.com"},
+		"too few bits":   {Response: mintHashcash(t, testHashcashDifficulty-4, "jane@example.com", now), Resource: "jane@example.com"},
+		"expired":        {Response: mintHashcash(t, testHashcashDifficulty, "jane@example.com", now.Add(-time.Hour*2)), Resource: "jane@example.com"},
+		"from future":    {Response: mintHashcash(t, testHashcashDifficulty, "jane@example.com", now.Add(time.Hour)), Resource: "jane@example.com"},
+	}
+
+	for name, challenge := range tests {
+		t.Run(name, func(t *testing.T) {
+			verifier := NewHashcashVerifier(testHashcashDifficulty, time.Hour)
+			if err := verifier.VerifyChallenge(newTestContext(t), challenge); !errors.Is(err, ErrChallengeFailed) {
+				t.Fatalf("expected ErrChallengeFailed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHashcashVerifierRejectsUnderclaimedWork(t *testing.T) {
+	// a stamp claiming enough bits whose digest does not have them
+	verifier := NewHashcashVerifier(testHashcashDifficulty, time.Hour)
+	for counter := 0; ; counter++ {
+		stamp := fmt.Sprintf("1:%d:%s:%s::%s:%x", testHashcashDifficulty, time.Now().UTC().Format("060102150405"), "jane@example.com", "c2FsdA", counter)
+		digest := sha1.Sum([]byte(stamp))
+		if leadingZeroBits(digest[:]) >= testHashcashDifficulty {
+			continue
+		}
+
+		err := verifier.VerifyChallenge(newTestContext(t), Challenge{Response: stamp, Resource: "jane@example.com"})
+		if !errors.Is(err, ErrChallengeFailed) {
+			t.Fatalf("expected ErrChallengeFailed, got %v", err)
+		}
+		return
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		digest []byte
+		want   uint
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x10}, 11},
+		{[]byte{0x00, 0x00}, 16},
+	}
+
+	for _, test := range tests {
+		if got := leadingZeroBits(test.digest); got != test.want {
+			t.Errorf("leadingZeroBits(%x) = %d, want %d", test.digest, got, test.want)
+		}
+	}
+}
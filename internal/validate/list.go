@@ -0,0 +1,75 @@
+package validate
+
+import (
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+type ListUsersForm struct {
+	RegisteredFrom *time.Time
+	RegisteredTo   *time.Time
+	Status         string
+	EmailDomain    string
+	PageSize       uint32
+}
+
+func isUserStatusValid(status string) bool {
+	switch status {
+	case repository.UserStatusActive,
+		repository.UserStatusSuspended,
+		repository.UserStatusPendingVerification,
+		repository.UserStatusPendingDeletion,
+		repository.UserStatusDeleted:
+		return true
+	default:
+		return false
+	}
+}
+
+func (v validator) ValidateListUsersForm(ctx Context, form ListUsersForm) error {
+	span := ctx.span.StartChild("validate-registration-range")
+	span.Status = sentry.SpanStatusOK
+	if nil != form.RegisteredFrom && nil != form.RegisteredTo && !form.RegisteredFrom.Before(*form.RegisteredTo) {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "registered_to", Message: "must be after 'registered_from'"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-status")
+	span.Status = sentry.SpanStatusOK
+	if len(form.Status) != 0 && !isUserStatusValid(form.Status) {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "status", Message: "invalid"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-email-domain")
+	span.Status = sentry.SpanStatusOK
+	if strings.Contains(form.EmailDomain, "@") {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "email_domain", Message: "must not contain '@'"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-page-size")
+	span.Status = sentry.SpanStatusOK
+	if form.PageSize > MaxPageSize {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "page_size", Message: "must not be greater than 500"}
+	}
+	span.Finish()
+
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 
 	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
 )
 
 type LoginForm struct {
@@ -11,10 +13,30 @@ type LoginForm struct {
 	Password        string
 	DeviceUserAgent string
 	IP              string
+	Challenge       string
 }
 
 func (v validator) ValidateLoginForm(ctx Context, form LoginForm) error {
-	span := ctx.span.StartChild("validate-password")
+	span := ctx.span.StartChild("validate-challenge")
+	span.Status = sentry.SpanStatusOK
+	if err := v.validateChallenge(NewContext(ctx, span), Challenge{Response: form.Challenge, Resource: form.Email, RemoteIP: form.IP}); nil != err {
+		defer span.Finish()
+
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			span.Status = sentry.SpanStatusInvalidArgument
+			return validationErr
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := v.logger.WithError(err).WithField("err_code", "E_VERIFY_CHALLENGE")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed verifying challenge")
+		return errors.New("failed to verify challenge")
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-password")
 	span.Status = sentry.SpanStatusOK
 	if len(form.Password) == 0 {
 		defer span.Finish()
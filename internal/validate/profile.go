@@ -0,0 +1,67 @@
+package validate
+
+import (
+	"github.com/getsentry/sentry-go"
+)
+
+type UpdateProfileForm struct {
+	Token     string
+	FirstName string
+	LastName  string
+	Paths     []string
+	Version   uint64
+}
+
+func (v validator) ValidateUpdateProfileForm(ctx Context, form UpdateProfileForm) error {
+	if err := v.ValidateAuthenticateForm(ctx, AuthenticateForm{Token: form.Token}); nil != err {
+		return err
+	}
+
+	span := ctx.span.StartChild("validate-version")
+	span.Status = sentry.SpanStatusOK
+	if form.Version == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "version", Message: "cannot be empty"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-update-mask")
+	span.Status = sentry.SpanStatusOK
+	if len(form.Paths) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "update_mask", Message: "cannot be empty"}
+	}
+	seen := make(map[string]struct{}, len(form.Paths))
+	for _, path := range form.Paths {
+		if _, duplicate := seen[path]; duplicate {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInvalidArgument
+			return &ValidationError{Field: "update_mask", Message: "contains duplicate paths"}
+		}
+		seen[path] = struct{}{}
+
+		var validationErr *ValidationError
+		switch path {
+		case "first_name":
+			validationErr = validateName("first_name", form.FirstName)
+		case "last_name":
+			validationErr = validateName("last_name", form.LastName)
+		default:
+			validationErr = &ValidationError{Field: "update_mask", Message: "unsupported path: " + path}
+		}
+		if nil != validationErr {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInvalidArgument
+			return validationErr
+		}
+	}
+	span.Finish()
+
+	return nil
+}
@@ -6,8 +6,6 @@ import (
 	"github.com/getsentry/sentry-go"
 
 	"github.com/game-sales-analytics/users-service/internal/apm"
-	"github.com/game-sales-analytics/users-service/internal/db/repository"
-	"github.com/game-sales-analytics/users-service/internal/normalize"
 )
 
 type RegisterForm struct {
@@ -16,6 +14,7 @@ type RegisterForm struct {
 	PasswordConfirmation string
 	FirstName            string
 	LastName             string
+	Challenge            string
 }
 
 func (v validator) ValidateRegisterForm(ctx Context, form RegisterForm) (*NormalizedForm, error) {
@@ -50,69 +49,52 @@ func (v validator) ValidateRegisterForm(ctx Context, form RegisterForm) (*Normal
 
 	span = ctx.span.StartChild("validate-email")
 	span.Status = sentry.SpanStatusOK
-	if len(form.Email) == 0 {
-		defer span.Finish()
-
-		span.Status = sentry.SpanStatusInvalidArgument
-		return nil, &ValidationError{Field: "email", Message: "cannot be empty"}
-	}
-	if isValid, err := isEmailValid(form.Email); nil != err {
-		defer span.Finish()
-
-		span.Status = sentry.SpanStatusInternalError
-		log := v.logger.WithError(err).WithField("err_code", "E_VALIDATE_EMAIL_FORMAT")
-		apm.SetSpanTagsFromLogEntry(span, log)
-		log.Error("failed validating email format")
-		return nil, errors.New("failed to validate email field")
-	} else if !isValid {
-		defer span.Finish()
-
-		span.Status = sentry.SpanStatusInvalidArgument
-		return nil, &ValidationError{Field: "email", Message: "invalid email"}
-	}
-	normalizedEmail, err := normalize.Email(form.Email)
+	normalizedEmail, err := v.validateAvailableEmail(NewContext(ctx, span), "email", form.Email, "")
 	if nil != err {
 		defer span.Finish()
 
-		span.Status = sentry.SpanStatusInternalError
-		log := v.logger.WithError(err).WithField("err_code", "E_NORMALIZE_EMAIL")
-		apm.SetSpanTagsFromLogEntry(span, log)
-		log.Error("failed normalizing email address")
-		return nil, errors.New("failed normalizing email address")
+		return nil, err
 	}
-	if exists, err := v.repo.NormalizedEmailExists(repository.NewDBOperationContext(ctx, span), normalizedEmail); nil != err {
-		defer span.Finish()
+	span.Finish()
 
-		span.Status = sentry.SpanStatusInternalError
-		log := v.logger.WithError(err).WithField("err_code", "E_CHECK_NORMALIZED_EMAIL_EXISTENCE")
-		apm.SetSpanTagsFromLogEntry(span, log)
-		log.Error("failed checking normalized email existence")
-		return nil, err
-	} else if exists {
+	span = ctx.span.StartChild("validate-first-name")
+	span.Status = sentry.SpanStatusOK
+	if validationErr := validateName("first_name", form.FirstName); nil != validationErr {
 		defer span.Finish()
 
 		span.Status = sentry.SpanStatusInvalidArgument
-		return nil, &ValidationError{Field: "email", Message: "duplicate email address"}
+		return nil, validationErr
 	}
 	span.Finish()
 
-	span = ctx.span.StartChild("validate-first-name")
+	span = ctx.span.StartChild("validate-last-name")
 	span.Status = sentry.SpanStatusOK
-	if len(form.FirstName) == 0 {
+	if validationErr := validateName("last_name", form.LastName); nil != validationErr {
 		defer span.Finish()
 
 		span.Status = sentry.SpanStatusInvalidArgument
-		return nil, &ValidationError{Field: "first_name", Message: "cannot be empty"}
+		return nil, validationErr
 	}
 	span.Finish()
 
-	span = ctx.span.StartChild("validate-last-name")
+	// The challenge comes last, since verifying a hashcash stamp spends it
+	// and a form rejected for another reason should not cost a new one.
+	span = ctx.span.StartChild("validate-challenge")
 	span.Status = sentry.SpanStatusOK
-	if len(form.LastName) == 0 {
+	if err := v.validateChallenge(NewContext(ctx, span), Challenge{Response: form.Challenge, Resource: form.Email, RemoteIP: ""}); nil != err {
 		defer span.Finish()
 
-		span.Status = sentry.SpanStatusInvalidArgument
-		return nil, &ValidationError{Field: "last_name", Message: "cannot be empty"}
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			span.Status = sentry.SpanStatusInvalidArgument
+			return nil, validationErr
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		log := v.logger.WithError(err).WithField("err_code", "E_VERIFY_CHALLENGE")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed verifying challenge")
+		return nil, errors.New("failed to verify challenge")
 	}
 	span.Finish()
 
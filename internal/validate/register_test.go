@@ -0,0 +1,58 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+func newTestValidator(t *testing.T, verifier ChallengeVerifier) Validator {
+	t.Helper()
+
+	domainPolicy, err := NewDomainPolicy(&config.EmailDomainPolicyConfig{})
+	if nil != err {
+		t.Fatalf("unable to load email domain policy: %s", err)
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	return New(logrus.NewEntry(logger), repository.NewMemoryStore(), verifier, domainPolicy, &config.UsersConfig{}, &config.APIKeysConfig{})
+}
+
+func TestValidateRegisterFormKeepsStampOfRejectedForm(t *testing.T) {
+	validator := newTestValidator(t, NewHashcashVerifier(testHashcashDifficulty, time.Hour))
+	form := RegisterForm{
+		Email:                "jane@example.com",
+		Password:             "correct horse",
+		PasswordConfirmation: "battery staple",
+		FirstName:            "Jane",
+		LastName:             "Doe",
+		Challenge:            mintHashcash(t, testHashcashDifficulty, "jane@example.com", time.Now()),
+	}
+
+	_, err := validator.ValidateRegisterForm(newTestContext(t), form)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "password_confirmation" {
+		t.Fatalf("expected password_confirmation validation error, got %v", err)
+	}
+
+	form.PasswordConfirmation = form.Password
+	normalized, err := validator.ValidateRegisterForm(newTestContext(t), form)
+	if nil != err {
+		t.Fatalf("expected corrected form to pass with the same stamp, got %s", err)
+	}
+	if normalized.Email != "jane@example.com" {
+		t.Fatalf("unexpected normalized email %q", normalized.Email)
+	}
+
+	_, err = validator.ValidateRegisterForm(newTestContext(t), form)
+	if !errors.As(err, &validationErr) || validationErr.Field != "challenge" {
+		t.Fatalf("expected spent stamp to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package validate
+
+import (
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/rbac"
+)
+
+type ChangeRoleForm struct {
+	ID   string
+	Role string
+}
+
+func (v validator) ValidateChangeRoleForm(ctx Context, form ChangeRoleForm) error {
+	if err := v.ValidateGetUserForm(ctx, GetUserForm{ID: form.ID}); nil != err {
+		return err
+	}
+
+	span := ctx.span.StartChild("validate-role")
+	span.Status = sentry.SpanStatusOK
+	if len(form.Role) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "role", Message: "cannot be empty"}
+	}
+	if !rbac.IsRoleValid(form.Role) {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "role", Message: "unknown role"}
+	}
+	span.Finish()
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	hCaptchaVerifyURL  = "https://hcaptcha.com/siteverify"
+	turnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+)
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+type siteVerifyVerifier struct {
+	client    *http.Client
+	verifyURL string
+	secret    string
+}
+
+func NewHCaptchaVerifier(client *http.Client, verifyURL, secret string) ChallengeVerifier {
+	if len(verifyURL) == 0 {
+		verifyURL = hCaptchaVerifyURL
+	}
+
+	return NewSiteVerifyVerifier(client, verifyURL, secret)
+}
+
+func NewTurnstileVerifier(client *http.Client, verifyURL, secret string) ChallengeVerifier {
+	if len(verifyURL) == 0 {
+		verifyURL = turnstileVerifyURL
+	}
+
+	return NewSiteVerifyVerifier(client, verifyURL, secret)
+}
+
+// NewSiteVerifyVerifier verifies challenge responses against any endpoint
+// implementing the siteverify protocol shared by hCaptcha and Turnstile, so
+// it can also point at a local fake.
+func NewSiteVerifyVerifier(client *http.Client, verifyURL, secret string) ChallengeVerifier {
+	return siteVerifyVerifier{
+		client,
+		verifyURL,
+		secret,
+	}
+}
+
+func (v siteVerifyVerifier) VerifyChallenge(ctx Context, challenge Challenge) error {
+	form := url.Values{}
+	form.Set("secret", v.secret)
+	form.Set("response", challenge.Response)
+	if len(challenge.RemoteIP) != 0 {
+		form.Set("remoteip", challenge.RemoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if nil != err {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := v.client.Do(req)
+	if nil != err {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected challenge verification response status: %d", res.StatusCode)
+	}
+
+	var body siteVerifyResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); nil != err {
+		return err
+	}
+
+	if !body.Success {
+		return ErrChallengeFailed
+	}
+
+	return nil
+}
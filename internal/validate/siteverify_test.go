@@ -0,0 +1,94 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func newTestContext(t *testing.T) Context {
+	t.Helper()
+
+	span := sentry.StartSpan(context.Background(), "test")
+	t.Cleanup(span.Finish)
+
+	return NewContext(context.Background(), span)
+}
+
+// newFakeSiteVerify serves the siteverify protocol, accepting only the given
+// response for the given secret.
+func newFakeSiteVerify(t *testing.T, secret, response string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		if err := r.ParseForm(); nil != err {
+			t.Errorf("unable to parse siteverify form: %s", err)
+		}
+		if r.PostForm.Get("remoteip") != "203.0.113.7" {
+			t.Errorf("unexpected remoteip %q", r.PostForm.Get("remoteip"))
+		}
+
+		body := siteVerifyResponse{Success: r.PostForm.Get("secret") == secret && r.PostForm.Get("response") == response}
+		if !body.Success {
+			body.ErrorCodes = []string{"invalid-input-response"}
+		}
+		if err := json.NewEncoder(w).Encode(body); nil != err {
+			t.Errorf("unable to encode siteverify response: %s", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestSiteVerifyVerifierAcceptsValidResponse(t *testing.T) {
+	server := newFakeSiteVerify(t, "s3cret", "token")
+	verifier := NewSiteVerifyVerifier(server.Client(), server.URL, "s3cret")
+
+	err := verifier.VerifyChallenge(newTestContext(t), Challenge{Response: "token", RemoteIP: "203.0.113.7"})
+	if nil != err {
+		t.Fatalf("expected challenge to pass, got %s", err)
+	}
+}
+
+func TestSiteVerifyVerifierRejectsInvalidResponse(t *testing.T) {
+	server := newFakeSiteVerify(t, "s3cret", "token")
+
+	for name, verifier := range map[string]ChallengeVerifier{
+		"wrong response": NewSiteVerifyVerifier(server.Client(), server.URL, "s3cret"),
+		"wrong secret":   NewSiteVerifyVerifier(server.Client(), server.URL, "other"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			response := "token"
+			if name == "wrong response" {
+				response = "forged"
+			}
+
+			err := verifier.VerifyChallenge(newTestContext(t), Challenge{Response: response, RemoteIP: "203.0.113.7"})
+			if !errors.Is(err, ErrChallengeFailed) {
+				t.Fatalf("expected ErrChallengeFailed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSiteVerifyVerifierReportsUnavailableEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	verifier := NewSiteVerifyVerifier(server.Client(), server.URL, "s3cret")
+
+	err := verifier.VerifyChallenge(newTestContext(t), Challenge{Response: "token"})
+	if nil == err || errors.Is(err, ErrChallengeFailed) {
+		t.Fatalf("expected an error other than ErrChallengeFailed, got %v", err)
+	}
+}
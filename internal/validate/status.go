@@ -0,0 +1,73 @@
+package validate
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const maxStatusReasonLength = 500
+
+type SuspendUserForm struct {
+	ID        string
+	Reason    string
+	ExpiresAt *time.Time
+}
+
+type ReactivateUserForm struct {
+	ID string
+}
+
+func (v validator) ValidateSuspendUserForm(ctx Context, form SuspendUserForm) error {
+	span := ctx.span.StartChild("validate-id")
+	span.Status = sentry.SpanStatusOK
+	if len(form.ID) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "id", Message: "cannot be empty"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-reason")
+	span.Status = sentry.SpanStatusOK
+	if len(form.Reason) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "reason", Message: "cannot be empty"}
+	}
+	if len(form.Reason) > maxStatusReasonLength {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "reason", Message: "must not be longer than 500 characters"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-expires-at")
+	span.Status = sentry.SpanStatusOK
+	if nil != form.ExpiresAt && !form.ExpiresAt.After(time.Now()) {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "expires_at", Message: "must be in the future"}
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (v validator) ValidateReactivateUserForm(ctx Context, form ReactivateUserForm) error {
+	span := ctx.span.StartChild("validate-id")
+	span.Status = sentry.SpanStatusOK
+	if len(form.ID) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "id", Message: "cannot be empty"}
+	}
+	span.Finish()
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type GetUserForm struct {
+	ID string
+}
+
+type BatchGetUsersForm struct {
+	IDs []string
+}
+
+func (v validator) ValidateGetUserForm(ctx Context, form GetUserForm) error {
+	span := ctx.span.StartChild("validate-id")
+	span.Status = sentry.SpanStatusOK
+	if len(form.ID) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "id", Message: "cannot be empty"}
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (v validator) ValidateBatchGetUsersForm(ctx Context, form BatchGetUsersForm) error {
+	span := ctx.span.StartChild("validate-ids")
+	span.Status = sentry.SpanStatusOK
+	if len(form.IDs) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "ids", Message: "cannot be empty"}
+	}
+	if uint(len(form.IDs)) > v.usersCfg.BatchGetLimit {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "ids", Message: fmt.Sprintf("cannot contain more than %d ids", v.usersCfg.BatchGetLimit)}
+	}
+	for _, id := range form.IDs {
+		if len(id) == 0 {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInvalidArgument
+			return &ValidationError{Field: "ids", Message: "cannot contain empty ids"}
+		}
+	}
+	span.Finish()
+
+	return nil
+}
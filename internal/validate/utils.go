@@ -14,3 +14,11 @@ func isEmailValid(email string) (bool, error) {
 func isIPValid(ip string) (bool, error) {
 	return net.ParseIP(ip) != nil, nil
 }
+
+func validateName(field, value string) *ValidationError {
+	if len(value) == 0 {
+		return &ValidationError{Field: field, Message: "cannot be empty"}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package validate
 import (
 	"github.com/sirupsen/logrus"
 
+	"github.com/game-sales-analytics/users-service/internal/config"
 	"github.com/game-sales-analytics/users-service/internal/db/repository"
 )
 
@@ -14,16 +15,50 @@ type Validator interface {
 	ValidateRegisterForm(ctx Context, form RegisterForm) (*NormalizedForm, error)
 	ValidateLoginForm(ctx Context, form LoginForm) error
 	ValidateAuthenticateForm(ctx Context, form AuthenticateForm) error
+	ValidateQueryAuditEventsForm(ctx Context, form QueryAuditEventsForm) error
+	ValidateGetUserForm(ctx Context, form GetUserForm) error
+	ValidateBatchGetUsersForm(ctx Context, form BatchGetUsersForm) error
+	ValidateUpdateProfileForm(ctx Context, form UpdateProfileForm) error
+	ValidateRequestEmailChangeForm(ctx Context, form RequestEmailChangeForm) (*NormalizedForm, error)
+	ValidateConfirmEmailChangeForm(ctx Context, form ConfirmEmailChangeForm) error
+	ValidateListUsersForm(ctx Context, form ListUsersForm) error
+	ValidateSuspendUserForm(ctx Context, form SuspendUserForm) error
+	ValidateReactivateUserForm(ctx Context, form ReactivateUserForm) error
+	ValidateDeleteAccountForm(ctx Context, form DeleteAccountForm) error
+	ValidateExportUserDataForm(ctx Context, form ExportUserDataForm) error
+	ValidateAdminExportUserDataForm(ctx Context, form AdminExportUserDataForm) error
+	ValidateChangeRoleForm(ctx Context, form ChangeRoleForm) error
+	ValidateCreateAPIKeyForm(ctx Context, form CreateAPIKeyForm) error
+	ValidateRevokeAPIKeyForm(ctx Context, form RevokeAPIKeyForm) error
+	ValidateCreateWebhookSubscriptionForm(ctx Context, form CreateWebhookSubscriptionForm) error
+	ValidateDeleteWebhookSubscriptionForm(ctx Context, form DeleteWebhookSubscriptionForm) error
+	ValidateListWebhookDeliveriesForm(ctx Context, form ListWebhookDeliveriesForm) error
+	ValidateRetryWebhookDeliveryForm(ctx Context, form RetryWebhookDeliveryForm) error
 }
 
 type validator struct {
-	logger *logrus.Entry
-	repo   *repository.Repo
+	logger            *logrus.Entry
+	repo              repository.Store
+	challengeVerifier ChallengeVerifier
+	domainPolicy      *DomainPolicy
+	usersCfg          *config.UsersConfig
+	apiKeysCfg        *config.APIKeysConfig
 }
 
-func New(logger *logrus.Entry, repo *repository.Repo) Validator {
+func New(
+	logger *logrus.Entry,
+	repo repository.Store,
+	challengeVerifier ChallengeVerifier,
+	domainPolicy *DomainPolicy,
+	usersCfg *config.UsersConfig,
+	apiKeysCfg *config.APIKeysConfig,
+) Validator {
 	return validator{
 		logger,
 		repo,
+		challengeVerifier,
+		domainPolicy,
+		usersCfg,
+		apiKeysCfg,
 	}
 }
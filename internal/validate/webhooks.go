@@ -0,0 +1,143 @@
+package validate
+
+import (
+	"net/url"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/webhook"
+)
+
+const maxWebhookURLLength = 2048
+
+type CreateWebhookSubscriptionForm struct {
+	URL    string
+	Events []string
+}
+
+type DeleteWebhookSubscriptionForm struct {
+	ID string
+}
+
+type ListWebhookDeliveriesForm struct {
+	Status   string
+	PageSize uint32
+}
+
+type RetryWebhookDeliveryForm struct {
+	ID string
+}
+
+func (v validator) ValidateCreateWebhookSubscriptionForm(ctx Context, form CreateWebhookSubscriptionForm) error {
+	span := ctx.span.StartChild("validate-url")
+	span.Status = sentry.SpanStatusOK
+	if len(form.URL) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "url", Message: "cannot be empty"}
+	}
+	if len(form.URL) > maxWebhookURLLength {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "url", Message: "must not be longer than 2048 characters"}
+	}
+	parsed, err := url.Parse(form.URL)
+	if nil != err || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "url", Message: "must be an absolute http or https url"}
+	}
+	if nil != parsed.User {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "url", Message: "must not contain credentials"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-events")
+	span.Status = sentry.SpanStatusOK
+	if len(form.Events) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "events", Message: "cannot be empty"}
+	}
+	seen := map[string]struct{}{}
+	for _, event := range form.Events {
+		if !webhook.IsEventTypeSupported(event) {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInvalidArgument
+			return &ValidationError{Field: "events", Message: "unknown event type"}
+		}
+		if _, duplicate := seen[event]; duplicate {
+			defer span.Finish()
+
+			span.Status = sentry.SpanStatusInvalidArgument
+			return &ValidationError{Field: "events", Message: "must not contain duplicates"}
+		}
+		seen[event] = struct{}{}
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (v validator) ValidateDeleteWebhookSubscriptionForm(ctx Context, form DeleteWebhookSubscriptionForm) error {
+	span := ctx.span.StartChild("validate-id")
+	span.Status = sentry.SpanStatusOK
+	if len(form.ID) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "id", Message: "cannot be empty"}
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (v validator) ValidateListWebhookDeliveriesForm(ctx Context, form ListWebhookDeliveriesForm) error {
+	span := ctx.span.StartChild("validate-status")
+	span.Status = sentry.SpanStatusOK
+	switch form.Status {
+	case "", repository.WebhookDeliveryStatusPending, repository.WebhookDeliveryStatusDelivered, repository.WebhookDeliveryStatusDeadLetter:
+	default:
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "status", Message: "must be one of 'pending', 'delivered' or 'dead_letter'"}
+	}
+	span.Finish()
+
+	span = ctx.span.StartChild("validate-page-size")
+	span.Status = sentry.SpanStatusOK
+	if form.PageSize > MaxPageSize {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "page_size", Message: "must not be greater than 500"}
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (v validator) ValidateRetryWebhookDeliveryForm(ctx Context, form RetryWebhookDeliveryForm) error {
+	span := ctx.span.StartChild("validate-id")
+	span.Status = sentry.SpanStatusOK
+	if len(form.ID) == 0 {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInvalidArgument
+		return &ValidationError{Field: "id", Message: "cannot be empty"}
+	}
+	span.Finish()
+
+	return nil
+}
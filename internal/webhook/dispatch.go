@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+// leaseMargin is added to the request timeout when claiming a delivery, so a
+// claim never runs out while its request is still in flight.
+const leaseMargin = time.Minute
+
+// maxDrainedResponseBytes bounds how much of a response body is read before
+// closing it, which lets the connection be reused.
+const maxDrainedResponseBytes = 64 << 10
+
+func (d dispatcher) Run(ctx context.Context) {
+	d.logger.WithField("interval", d.cfg.DeliveryInterval).WithField("max_attempts", d.cfg.MaxAttempts).Debug("starting webhook dispatcher")
+
+	ticker := time.NewTicker(d.cfg.DeliveryInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchDueDeliveries(ctx)
+
+		select {
+		case <-ctx.Done():
+			d.logger.Debug("stopping webhook dispatcher")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchDueDeliveries sends up to a batch of due deliveries. Unlike the
+// outbox relay it carries on past failures: each subscription is an
+// independent receiver, and one being down must not hold back the others.
+func (d dispatcher) dispatchDueDeliveries(ctx context.Context) {
+	span := sentry.StartSpan(ctx, "dispatch-webhook-deliveries", sentry.TransactionName("dispatch-webhook-deliveries"))
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	delivered, failed := 0, 0
+	for i := uint(0); i < d.cfg.BatchSize; i++ {
+		if nil != ctx.Err() {
+			break
+		}
+
+		now := time.Now()
+		child := span.StartChild("claim-webhook-delivery")
+		child.Status = sentry.SpanStatusOK
+		delivery, err := d.repo.ClaimDueWebhookDelivery(repository.NewDBOperationContext(ctx, child), now, now.Add(d.cfg.Timeout+leaseMargin))
+		if nil != err {
+			defer child.Finish()
+
+			if errors.Is(err, repository.ErrWebhookDeliveryNotExists) {
+				break
+			}
+
+			child.Status = sentry.SpanStatusInternalError
+			log := d.logger.WithError(err).WithField("err_code", "E_CLAIM_WEBHOOK_DELIVERY")
+			apm.SetSpanTagsFromLogEntry(child, log)
+			log.Error("failed claiming due webhook delivery")
+			break
+		}
+		child.Finish()
+
+		child = span.StartChild("deliver-webhook")
+		child.Status = sentry.SpanStatusOK
+		if d.deliver(ctx, child, *delivery) {
+			delivered++
+		} else {
+			child.Status = sentry.SpanStatusUnavailable
+			failed++
+		}
+		child.Finish()
+	}
+
+	if delivered > 0 || failed > 0 {
+		d.logger.WithField("delivered", delivered).WithField("failed", failed).Debug("dispatched webhook deliveries")
+	}
+}
+
+// deliver sends one delivery and records the outcome. It reports whether the
+// receiver accepted the delivery.
+func (d dispatcher) deliver(ctx context.Context, span *sentry.Span, delivery repository.WebhookDelivery) bool {
+	log := d.logger.WithField("delivery_id", delivery.ID).WithField("subscription_id", delivery.SubscriptionID).WithField("event_id", delivery.EventID)
+
+	subscription, err := d.repo.GetWebhookSubscription(repository.NewDBOperationContext(ctx, span), delivery.SubscriptionID)
+	if nil != err {
+		if !errors.Is(err, repository.ErrWebhookSubscriptionNotExists) {
+			log.WithError(err).WithField("err_code", "E_GET_WEBHOOK_SUBSCRIPTION").Error("failed retrieving webhook subscription. will retry once the claim expires")
+		}
+		// a deleted subscription takes its deliveries with it
+		return false
+	}
+
+	statusCode, err := d.send(ctx, *subscription, delivery)
+	if nil == err {
+		if err := d.repo.MarkWebhookDeliveryDelivered(repository.NewDBOperationContext(ctx, span), delivery.ID, statusCode, time.Now()); nil != err {
+			log.WithError(err).WithField("err_code", "E_MARK_WEBHOOK_DELIVERY_DELIVERED").Error("failed marking webhook delivery as delivered. it will be sent again")
+		}
+		return true
+	}
+
+	attempt := delivery.Attempts + 1
+	failure := repository.WebhookDeliveryFailure{
+		Reason:     err.Error(),
+		StatusCode: statusCode,
+	}
+	if attempt < d.cfg.MaxAttempts {
+		nextAttemptAt := time.Now().Add(d.backoff(attempt))
+		failure.NextAttemptAt = &nextAttemptAt
+	}
+
+	log = log.WithError(err).WithField("attempts", attempt)
+	if nil == failure.NextAttemptAt {
+		log.WithField("err_code", "E_WEBHOOK_DELIVERY_DEAD_LETTERED").Warn("webhook delivery failed on its last attempt. moving it to the dead-letter state")
+	} else {
+		log.WithField("next_attempt_at", *failure.NextAttemptAt).Info("webhook delivery failed. will retry")
+	}
+
+	if err := d.repo.MarkWebhookDeliveryFailed(repository.NewDBOperationContext(ctx, span), delivery.ID, failure); nil != err {
+		log.WithError(err).WithField("err_code", "E_MARK_WEBHOOK_DELIVERY_FAILED").Error("failed recording webhook delivery failure")
+	}
+
+	return false
+}
+
+// backoff doubles the delay after every failed attempt, starting from the
+// configured base and capped at the configured maximum.
+func (d dispatcher) backoff(attempt uint) time.Duration {
+	delay := d.cfg.BackoffBase
+	for i := uint(1); i < attempt; i++ {
+		delay *= 2
+		if delay >= d.cfg.BackoffMax || delay <= 0 {
+			return d.cfg.BackoffMax
+		}
+	}
+	if delay > d.cfg.BackoffMax {
+		return d.cfg.BackoffMax
+	}
+
+	return delay
+}
+
+// send posts the delivery body and reports the response status code. Only
+// 2xx responses count as delivered.
+func (d dispatcher) send(ctx context.Context, subscription repository.WebhookSubscription, delivery repository.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if nil != err {
+		return 0, err
+	}
+
+	sentAt := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "users-service-webhooks")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(sentAt.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, sentAt, body))
+
+	res, err := d.client.Do(req)
+	if nil != err {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxDrainedResponseBytes))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver responded with status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,219 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+const testSecret = "whsec_test"
+
+func TestSignatureRoundTrip(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	sentAt := time.Unix(1700000000, 0)
+	signature := Sign(testSecret, sentAt, body)
+	timestamp := "1700000000"
+
+	tests := map[string]struct {
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		want      bool
+	}{
+		"valid":               {testSecret, timestamp, signature, body, sentAt, true},
+		"within tolerance":    {testSecret, timestamp, signature, body, sentAt.Add(time.Minute * 4), true},
+		"tampered body":       {testSecret, timestamp, signature, []byte(`{"id":"evt_2"}`), sentAt, false},
+		"other secret":        {"whsec_other", timestamp, signature, body, sentAt, false},
+		"other timestamp":     {testSecret, "1700000001", signature, body, sentAt, false},
+		"replayed later":      {testSecret, timestamp, signature, body, sentAt.Add(time.Minute * 6), false},
+		"from the future":     {testSecret, timestamp, signature, body, sentAt.Add(-time.Minute * 6), false},
+		"malformed timestamp": {testSecret, "yesterday", signature, body, sentAt, false},
+		"missing prefix":      {testSecret, timestamp, signature[len(signaturePrefix):], body, sentAt, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := VerifySignature(test.secret, test.timestamp, test.signature, test.body, time.Minute*5, test.now)
+			if got != test.want {
+				t.Fatalf("VerifySignature() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	d := dispatcher{cfg: &config.WebhooksConfig{BackoffBase: time.Second, BackoffMax: time.Second * 5}}
+
+	want := []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5}
+	for i, delay := range want {
+		if got := d.backoff(uint(i + 1)); got != delay {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, delay)
+		}
+	}
+}
+
+type dispatchFixture struct {
+	repo       *repository.MemoryStore
+	dispatcher dispatcher
+	ctx        repository.DBOperationContext
+}
+
+func newDispatchFixture(t *testing.T, receiverURL string, cfg *config.WebhooksConfig) dispatchFixture {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	span := sentry.StartSpan(context.Background(), "test")
+	t.Cleanup(span.Finish)
+	ctx := repository.NewDBOperationContext(context.Background(), span)
+
+	repo := repository.NewMemoryStore()
+	subscription := repository.WebhookSubscription{
+		ID:        "whs_1",
+		URL:       receiverURL,
+		Events:    []repository.OutboxEventType{repository.OutboxEventTypeUserRegistered},
+		Secret:    testSecret,
+		CreatedAt: time.Now(),
+	}
+	if err := repo.SaveNewWebhookSubscription(ctx, subscription); nil != err {
+		t.Fatalf("unable to save webhook subscription: %s", err)
+	}
+	delivery := repository.WebhookDelivery{
+		ID:             "whd_1",
+		SubscriptionID: subscription.ID,
+		EventID:        "evt_1",
+		EventType:      repository.OutboxEventTypeUserRegistered,
+		Body:           `{"id":"evt_1"}`,
+		NextAttemptAt:  time.Now(),
+		CreatedAt:      time.Now(),
+	}
+	if err := repo.SaveNewWebhookDeliveries(ctx, []repository.WebhookDelivery{delivery}); nil != err {
+		t.Fatalf("unable to save webhook delivery: %s", err)
+	}
+
+	return dispatchFixture{
+		repo:       repo,
+		dispatcher: NewDispatcher(logrus.NewEntry(logger), repo, http.DefaultClient, cfg).(dispatcher),
+		ctx:        ctx,
+	}
+}
+
+func (f dispatchFixture) delivery(t *testing.T) repository.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := f.repo.ListWebhookDeliveries(f.ctx, repository.WebhookDeliveriesFilter{})
+	if nil != err || len(deliveries) != 1 {
+		t.Fatalf("expected exactly one webhook delivery, got %d (%v)", len(deliveries), err)
+	}
+
+	return deliveries[0]
+}
+
+func TestDispatcherDeliversSignedRequest(t *testing.T) {
+	var received int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+
+		body, err := io.ReadAll(r.Body)
+		if nil != err {
+			t.Errorf("unable to read webhook body: %s", err)
+		}
+		if r.Header.Get(HeaderID) != "whd_1" || r.Header.Get(HeaderEvent) != repository.OutboxEventTypeUserRegistered {
+			t.Errorf("unexpected webhook headers %v", r.Header)
+		}
+		if !VerifySignature(testSecret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute, time.Now()) {
+			t.Errorf("webhook signature did not verify")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	f := newDispatchFixture(t, receiver.URL, &config.WebhooksConfig{
+		Timeout:     time.Second * 5,
+		MaxAttempts: 3,
+		BackoffBase: time.Minute,
+		BackoffMax:  time.Hour,
+		BatchSize:   10,
+	})
+	f.dispatcher.dispatchDueDeliveries(context.Background())
+
+	delivery := f.delivery(t)
+	if atomic.LoadInt32(&received) != 1 {
+		t.Fatalf("expected one request, got %d", received)
+	}
+	if delivery.Status != repository.WebhookDeliveryStatusDelivered || delivery.LastStatusCode != http.StatusNoContent || delivery.Attempts != 1 {
+		t.Fatalf("unexpected delivery state %+v", delivery)
+	}
+}
+
+func TestDispatcherRetriesWithBackoffThenDeadLetters(t *testing.T) {
+	var received int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	cfg := &config.WebhooksConfig{
+		Timeout:     time.Second * 5,
+		MaxAttempts: 4,
+		BackoffBase: time.Minute,
+		BackoffMax:  time.Minute * 3,
+		BatchSize:   10,
+	}
+	f := newDispatchFixture(t, receiver.URL, cfg)
+	wantDelays := []time.Duration{time.Minute, time.Minute * 2, time.Minute * 3}
+
+	for attempt := uint(1); attempt <= cfg.MaxAttempts; attempt++ {
+		// claim as if the scheduled retry were due, rather than waiting for it
+		claimedAt := time.Now().Add(time.Hour)
+		claimed, err := f.repo.ClaimDueWebhookDelivery(f.ctx, claimedAt, claimedAt.Add(time.Minute))
+		if nil != err {
+			t.Fatalf("attempt %d: unable to claim webhook delivery: %s", attempt, err)
+		}
+
+		before := time.Now()
+		if f.dispatcher.deliver(context.Background(), sentry.StartSpan(context.Background(), "test"), *claimed) {
+			t.Fatalf("attempt %d: expected delivery to fail", attempt)
+		}
+
+		delivery := f.delivery(t)
+		if delivery.Attempts != attempt || delivery.LastStatusCode != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: unexpected delivery state %+v", attempt, delivery)
+		}
+		if attempt == cfg.MaxAttempts {
+			if delivery.Status != repository.WebhookDeliveryStatusDeadLetter {
+				t.Fatalf("expected delivery to be dead-lettered after %d attempts, got %s", attempt, delivery.Status)
+			}
+			break
+		}
+
+		if delivery.Status != repository.WebhookDeliveryStatusPending {
+			t.Fatalf("attempt %d: expected delivery to stay pending, got %s", attempt, delivery.Status)
+		}
+		delay := delivery.NextAttemptAt.Sub(before)
+		if delay < wantDelays[attempt-1]-time.Second || delay > wantDelays[attempt-1]+time.Second {
+			t.Fatalf("attempt %d: expected retry in %s, got %s", attempt, wantDelays[attempt-1], delay)
+		}
+	}
+
+	if _, err := f.repo.ClaimDueWebhookDelivery(f.ctx, time.Now().Add(time.Hour*24), time.Now().Add(time.Hour*25)); nil == err {
+		t.Fatal("expected a dead-lettered delivery not to be claimed again")
+	}
+	if got := atomic.LoadInt32(&received); got != int32(cfg.MaxAttempts) {
+		t.Fatalf("expected %d requests, got %d", cfg.MaxAttempts, got)
+	}
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/id"
+	"github.com/game-sales-analytics/users-service/internal/outbox"
+)
+
+// Publish records the deliveries of an event; the dispatcher sends them. The
+// body is fixed here so every attempt sends, and signs, the same bytes.
+func (e enqueuer) Publish(ctx context.Context, event outbox.Event) error {
+	span := sentry.StartSpan(ctx, "enqueue-webhook-deliveries")
+	span.Status = sentry.SpanStatusOK
+	defer span.Finish()
+
+	child := span.StartChild("list-webhook-subscriptions")
+	child.Status = sentry.SpanStatusOK
+	subscriptions, err := e.repo.ListWebhookSubscriptions(repository.NewDBOperationContext(ctx, child))
+	if nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		return err
+	}
+	child.Finish()
+
+	body, err := json.Marshal(event)
+	if nil != err {
+		span.Status = sentry.SpanStatusInternalError
+		log := e.logger.WithError(err).WithField("err_code", "E_ENCODE_WEBHOOK_BODY").WithField("event_id", event.ID)
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed encoding webhook body")
+		return err
+	}
+
+	now := time.Now()
+	deliveries := []repository.WebhookDelivery{}
+	for _, subscription := range subscriptions {
+		if !subscribedTo(subscription, event.Type) {
+			continue
+		}
+
+		deliveryID, err := id.GenerateWebhookDeliveryID()
+		if nil != err {
+			span.Status = sentry.SpanStatusInternalError
+			log := e.logger.WithError(err).WithField("err_code", "E_GENERATE_WEBHOOK_DELIVERY_ID")
+			apm.SetSpanTagsFromLogEntry(span, log)
+			log.Error("failed generating webhook delivery id")
+			return err
+		}
+		deliveries = append(deliveries, repository.WebhookDelivery{
+			ID:             deliveryID,
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Body:           string(body),
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+
+	child = span.StartChild("save-webhook-deliveries")
+	child.Status = sentry.SpanStatusOK
+	if err := e.repo.SaveNewWebhookDeliveries(repository.NewDBOperationContext(ctx, child), deliveries); nil != err {
+		defer child.Finish()
+
+		child.Status = sentry.SpanStatusInternalError
+		return err
+	}
+	child.Finish()
+
+	return nil
+}
+
+func (e enqueuer) Close() error {
+	return nil
+}
+
+func subscribedTo(subscription repository.WebhookSubscription, eventType string) bool {
+	for _, subscribed := range subscription.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+
+	return false
+}
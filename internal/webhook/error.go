@@ -0,0 +1,12 @@
+package webhook
+
+import (
+	"errors"
+)
+
+var (
+	ErrSubscriptionNotExists   = errors.New("webhook subscription does not exist")
+	ErrDeliveryNotExists       = errors.New("webhook delivery does not exist")
+	ErrDeliveryNotDeadLettered = errors.New("webhook delivery is not dead-lettered")
+	ErrInternal                = errors.New("internal error occurred")
+)
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/game-sales-analytics/users-service/internal/config"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/outbox"
+)
+
+type webhooks struct {
+	repo   repository.Store
+	logger *logrus.Entry
+}
+
+func New(repo repository.Store, logger *logrus.Entry) Webhooks {
+	return webhooks{
+		repo,
+		logger,
+	}
+}
+
+type enqueuer struct {
+	logger *logrus.Entry
+	repo   repository.Store
+}
+
+// NewEnqueuer returns the outbox publisher that turns every event into one
+// delivery per subscription interested in it.
+func NewEnqueuer(logger *logrus.Entry, repo repository.Store) outbox.EventPublisher {
+	return enqueuer{
+		logger,
+		repo,
+	}
+}
+
+type dispatcher struct {
+	logger *logrus.Entry
+	repo   repository.Store
+	client *http.Client
+	cfg    *config.WebhooksConfig
+}
+
+func NewDispatcher(
+	logger *logrus.Entry,
+	repo repository.Store,
+	client *http.Client,
+	cfg *config.WebhooksConfig,
+) Dispatcher {
+	return dispatcher{
+		logger,
+		repo,
+		client,
+		cfg,
+	}
+}
@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/game-sales-analytics/users-service/internal/apm"
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+	"github.com/game-sales-analytics/users-service/internal/id"
+)
+
+func (w webhooks) Create(ctx Context, req CreateRequest) (*repository.WebhookSubscription, error) {
+	span := ctx.span.StartChild("generate-webhook-subscription")
+	span.Status = sentry.SpanStatusOK
+	subscriptionID, err := id.GenerateWebhookSubscriptionID()
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := w.logger.WithError(err).WithField("err_code", "E_GENERATE_WEBHOOK_SUBSCRIPTION_ID")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed generating webhook subscription id")
+		return nil, ErrInternal
+	}
+	secret, err := id.GenerateSecretToken()
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		log := w.logger.WithError(err).WithField("err_code", "E_GENERATE_WEBHOOK_SECRET")
+		apm.SetSpanTagsFromLogEntry(span, log)
+		log.Error("failed generating webhook signing secret")
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	subscription := repository.WebhookSubscription{
+		ID:        subscriptionID,
+		URL:       req.URL,
+		Events:    req.Events,
+		Secret:    secret,
+		CreatedBy: req.CreatedBy,
+		CreatedAt: time.Now(),
+	}
+
+	span = ctx.span.StartChild("save-webhook-subscription")
+	span.Status = sentry.SpanStatusOK
+	if err := w.repo.SaveNewWebhookSubscription(repository.NewDBOperationContext(ctx, span), subscription); nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	return &subscription, nil
+}
+
+func (w webhooks) List(ctx Context) ([]repository.WebhookSubscription, error) {
+	span := ctx.span.StartChild("list-webhook-subscriptions")
+	span.Status = sentry.SpanStatusOK
+	list, err := w.repo.ListWebhookSubscriptions(repository.NewDBOperationContext(ctx, span))
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	return list, nil
+}
+
+func (w webhooks) Delete(ctx Context, subscriptionID string) error {
+	span := ctx.span.StartChild("delete-webhook-subscription")
+	span.Status = sentry.SpanStatusOK
+	if err := w.repo.DeleteWebhookSubscription(repository.NewDBOperationContext(ctx, span), subscriptionID); nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, repository.ErrWebhookSubscriptionNotExists) {
+			span.Status = sentry.SpanStatusNotFound
+			return ErrSubscriptionNotExists
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		return ErrInternal
+	}
+	span.Finish()
+
+	return nil
+}
+
+func (w webhooks) ListDeliveries(ctx Context, filter repository.WebhookDeliveriesFilter) ([]repository.WebhookDelivery, error) {
+	span := ctx.span.StartChild("list-webhook-deliveries")
+	span.Status = sentry.SpanStatusOK
+	list, err := w.repo.ListWebhookDeliveries(repository.NewDBOperationContext(ctx, span), filter)
+	if nil != err {
+		defer span.Finish()
+
+		span.Status = sentry.SpanStatusInternalError
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	return list, nil
+}
+
+// RetryDelivery makes a dead-lettered delivery due right away, with the full
+// attempt budget.
+func (w webhooks) RetryDelivery(ctx Context, deliveryID string) (*repository.WebhookDelivery, error) {
+	span := ctx.span.StartChild("retry-webhook-delivery")
+	span.Status = sentry.SpanStatusOK
+	delivery, err := w.repo.RetryWebhookDelivery(repository.NewDBOperationContext(ctx, span), deliveryID, time.Now())
+	if nil != err {
+		defer span.Finish()
+
+		if errors.Is(err, repository.ErrWebhookDeliveryNotExists) {
+			span.Status = sentry.SpanStatusNotFound
+			return nil, ErrDeliveryNotExists
+		}
+		if errors.Is(err, repository.ErrWebhookDeliveryNotDeadLettered) {
+			span.Status = sentry.SpanStatusFailedPrecondition
+			return nil, ErrDeliveryNotDeadLettered
+		}
+
+		span.Status = sentry.SpanStatusInternalError
+		return nil, ErrInternal
+	}
+	span.Finish()
+
+	return delivery, nil
+}
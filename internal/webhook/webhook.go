@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/game-sales-analytics/users-service/internal/db/repository"
+)
+
+// EventTypes lists the events a subscription can ask for.
+var EventTypes = []repository.OutboxEventType{
+	repository.OutboxEventTypeUserRegistered,
+	repository.OutboxEventTypeUserLoggedIn,
+	repository.OutboxEventTypeUserUpdated,
+	repository.OutboxEventTypeUserDeleted,
+}
+
+func IsEventTypeSupported(eventType string) bool {
+	for _, supported := range EventTypes {
+		if supported == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+type CreateRequest struct {
+	URL       string
+	Events    []repository.OutboxEventType
+	CreatedBy string
+}
+
+type Webhooks interface {
+	// Create returns the subscription with its generated signing secret.
+	Create(ctx Context, req CreateRequest) (*repository.WebhookSubscription, error)
+	List(ctx Context) ([]repository.WebhookSubscription, error)
+	Delete(ctx Context, subscriptionID string) error
+	ListDeliveries(ctx Context, filter repository.WebhookDeliveriesFilter) ([]repository.WebhookDelivery, error)
+	RetryDelivery(ctx Context, deliveryID string) (*repository.WebhookDelivery, error)
+}
+
+// Dispatcher sends due webhook deliveries until its context is canceled.
+type Dispatcher interface {
+	Run(ctx context.Context)
+}